@@ -0,0 +1,53 @@
+package conformance
+
+import (
+	"core/pkg/conformance"
+	"log"
+	"os"
+
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	// OutFlag corpus output file flag
+	OutFlag string = "out"
+)
+
+// Command conformance command entry
+var Command cli.Command = cli.Command{
+	Name:        "conformance",
+	Usage:       "Evaluation conformance corpus",
+	Description: "Manage the evaluation conformance corpus replayed by SDKs.",
+	Subcommands: []*cli.Command{
+		&GenerateCommand,
+	},
+}
+
+// GenerateCommand generate conformance corpus command entry
+var GenerateCommand cli.Command = cli.Command{
+	Name:        "generate",
+	Description: "Generate the conformance corpus using the Go evaluator",
+	Usage:       "Generate conformance corpus",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  OutFlag,
+			Usage: "Corpus output file [defaults to stdout]",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		corpus, err := conformance.Generate()
+		if err != nil {
+			log.Fatal("Unable to generate corpus. Reason: ", err.Error())
+		}
+		b, err := conformance.Marshal(corpus)
+		if err != nil {
+			log.Fatal("Unable to encode corpus. Reason: ", err.Error())
+		}
+
+		if out := ctx.String(OutFlag); out != "" {
+			return os.WriteFile(out, b, 0644)
+		}
+		_, err = os.Stdout.Write(b)
+		return err
+	},
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"core/cmd/conformance"
 	"core/cmd/manage"
 	"core/cmd/worker"
 
@@ -16,5 +17,6 @@ var AppCommand *cli.App = &cli.App{
 	Commands: []*cli.Command{
 		&worker.Command,
 		&manage.Command,
+		&conformance.Command,
 	},
 }
@@ -10,6 +10,7 @@ import (
 	cons "core/internal/pkg/constants"
 	"core/internal/pkg/srvenv"
 	"core/internal/pkg/workermode"
+	"core/pkg/evaluator"
 
 	"github.com/urfave/cli/v2"
 )
@@ -23,8 +24,22 @@ const (
 	APIPortFlag string = "api-port"
 	// StreamerPortFlag Port streamer will operate within
 	StreamerPortFlag string = "streamer-port"
+	// StreamerRefreshIntervalFlag How often the streamer checks flagsets for changes
+	StreamerRefreshIntervalFlag string = "streamer-refresh-interval"
+	// StreamerHeartbeatIntervalFlag How often the streamer sends heartbeats
+	StreamerHeartbeatIntervalFlag string = "streamer-heartbeat-interval"
 	// PollingPortFlag Port streamer will operate within
 	PollerPortFlag string = "poller-port"
+	// PollerCacheExpiryFlag How long the poller caches SDK keys and flagsets
+	PollerCacheExpiryFlag string = "poller-cache-expiry"
+	// MaxBatchSizeFlag Max number of contexts evaluated per batch
+	MaxBatchSizeFlag string = "max-batch-size"
+	// MatcherCacheSizeFlag Max number of rule values cached per matcher cache
+	MatcherCacheSizeFlag string = "matcher-cache-size"
+	// MaxPatternLengthFlag Max length of regex rule values
+	MaxPatternLengthFlag string = "max-pattern-length"
+	// MaxPatternComplexityFlag Max number of instructions of compiled regex rule values
+	MaxPatternComplexityFlag string = "max-pattern-complexity"
 )
 
 // Command worker command entry
@@ -61,10 +76,45 @@ var StartCommand cli.Command = cli.Command{
 			Name:  StreamerPortFlag,
 			Value: cons.DefaultStreamerPort,
 		},
+		&cli.DurationFlag{
+			Name:  StreamerRefreshIntervalFlag,
+			Usage: "How often the flagsets of environments with connected clients are checked for changes, in addition to checking whenever a change is notified",
+			Value: cons.DefaultStreamerRefreshInterval,
+		},
+		&cli.DurationFlag{
+			Name:  StreamerHeartbeatIntervalFlag,
+			Usage: "How often heartbeats are sent to connected clients",
+			Value: cons.DefaultStreamerHeartbeatInterval,
+		},
 		&cli.IntFlag{
 			Name:  PollerPortFlag,
 			Value: cons.DefaultPollingPort,
 		},
+		&cli.DurationFlag{
+			Name:  PollerCacheExpiryFlag,
+			Usage: "How long the poller caches SDK keys and flagsets (entries are invalidated as soon as a change is notified)",
+			Value: cons.DefaultCacheExpiry,
+		},
+		&cli.IntFlag{
+			Name:  MaxBatchSizeFlag,
+			Usage: "Max number of contexts evaluated per batch request, larger batches are rejected",
+			Value: cons.DefaultMaxBatchSize,
+		},
+		&cli.IntFlag{
+			Name:  MatcherCacheSizeFlag,
+			Usage: "Max number of parsed rule values (numbers, regexes, versions) cached per matcher",
+			Value: evaluator.DefaultCacheLimits.Size,
+		},
+		&cli.IntFlag{
+			Name:  MaxPatternLengthFlag,
+			Usage: "Max length of regex rule values, longer patterns are rejected when rules are written",
+			Value: evaluator.DefaultCacheLimits.MaxPatternLength,
+		},
+		&cli.IntFlag{
+			Name:  MaxPatternComplexityFlag,
+			Usage: "Max number of instructions of compiled regex rule values, more complex patterns are rejected when rules are written",
+			Value: evaluator.DefaultCacheLimits.MaxPatternComplexity,
+		},
 	}, cmdutil.GlobalFlags...),
 	Action: startCommand,
 }
@@ -6,6 +6,7 @@ import (
 	"core/internal/pkg/srvenv"
 	"core/internal/pkg/workermode"
 	"sync"
+	"time"
 
 	"github.com/urfave/cli/v2"
 )
@@ -19,6 +20,7 @@ type PollingConfig struct {
 	RedisAddr     string
 	RedisPassword string
 	RedisDB       int
+	CacheExpiry   time.Duration
 }
 
 // StartPolling start polling
@@ -29,6 +31,7 @@ func StartPoller(ctx *cli.Context, senv *srvenv.Env, wg *sync.WaitGroup) {
 		PollingPort: ctx.Int(PollerPortFlag),
 		PGConnStr:   ctx.String(cmdutil.PGConnStrFlag),
 		Verbose:     ctx.Bool(cmdutil.VerboseFlag),
+		CacheExpiry: ctx.Duration(PollerCacheExpiryFlag),
 	}
 
 	senv.Log.Info().Str(
@@ -37,11 +40,14 @@ func StartPoller(ctx *cli.Context, senv *srvenv.Env, wg *sync.WaitGroup) {
 		cmdutil.VerboseFlag, cfg.Verbose,
 	).Int(
 		PollerPortFlag, cfg.PollingPort,
+	).Dur(
+		PollerCacheExpiryFlag, cfg.CacheExpiry,
 	).Msg(workermode.StrStartingWorker(workermode.PollerMode))
 
 	poller.New(senv, poller.Config{
 		Host:        cfg.Host,
 		PollingPort: cfg.PollingPort,
 		Verbose:     cfg.Verbose,
+		CacheExpiry: cfg.CacheExpiry,
 	})
 }
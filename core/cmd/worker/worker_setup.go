@@ -5,9 +5,12 @@ import (
 	"log"
 	"sync"
 
+	evaluationservice "core/internal/app/evaluation/service"
 	srv "core/internal/infra/server"
 	"core/internal/pkg/cmdutil"
+	"core/internal/pkg/httpmetrics"
 	"core/internal/pkg/srvenv"
+	"core/pkg/evaluator"
 
 	"github.com/urfave/cli/v2"
 )
@@ -25,5 +28,14 @@ func Setup(ctx *cli.Context, wg *sync.WaitGroup) *srvenv.Env {
 	if err != nil {
 		log.Fatal("Unable to setup app context. Reason: ", err.Error())
 	}
+
+	evaluator.SetCacheLimits(evaluator.CacheLimits{
+		Size:                 ctx.Int(MatcherCacheSizeFlag),
+		MaxPatternLength:     ctx.Int(MaxPatternLengthFlag),
+		MaxPatternComplexity: ctx.Int(MaxPatternComplexityFlag),
+	})
+	httpmetrics.ApplyEvaluatorMetrics()
+	evaluationservice.SetMaxBatchSize(ctx.Int(MaxBatchSizeFlag))
+
 	return senv
 }
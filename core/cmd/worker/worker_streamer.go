@@ -1,30 +1,36 @@
 package worker
 
 import (
+	"core/internal/infra/streamer"
 	"core/internal/pkg/cmdutil"
 	"core/internal/pkg/srvenv"
 	"core/internal/pkg/workermode"
 	"sync"
+	"time"
 
 	"github.com/urfave/cli/v2"
 )
 
 // StreamerConfig API worker configuration
 type StreamerConfig struct {
-	Host         string
-	StreamerPort int
-	PGConnStr    string
-	Verbose      bool
+	Host              string
+	StreamerPort      int
+	PGConnStr         string
+	Verbose           bool
+	RefreshInterval   time.Duration
+	HeartbeatInterval time.Duration
 }
 
 // StartStreamer start streamer worker
 func StartSteamer(ctx *cli.Context, senv *srvenv.Env, wg *sync.WaitGroup) {
 	defer wg.Done()
 	cfg := StreamerConfig{
-		Host:         ctx.String(HostFlag),
-		StreamerPort: ctx.Int(StreamerPortFlag),
-		PGConnStr:    ctx.String(cmdutil.PGConnStrFlag),
-		Verbose:      ctx.Bool(cmdutil.VerboseFlag),
+		Host:              ctx.String(HostFlag),
+		StreamerPort:      ctx.Int(StreamerPortFlag),
+		PGConnStr:         ctx.String(cmdutil.PGConnStrFlag),
+		Verbose:           ctx.Bool(cmdutil.VerboseFlag),
+		RefreshInterval:   ctx.Duration(StreamerRefreshIntervalFlag),
+		HeartbeatInterval: ctx.Duration(StreamerHeartbeatIntervalFlag),
 	}
 
 	senv.Log.Info().Str(
@@ -33,7 +39,17 @@ func StartSteamer(ctx *cli.Context, senv *srvenv.Env, wg *sync.WaitGroup) {
 		cmdutil.VerboseFlag, cfg.Verbose,
 	).Int(
 		StreamerPortFlag, cfg.StreamerPort,
+	).Dur(
+		StreamerRefreshIntervalFlag, cfg.RefreshInterval,
+	).Dur(
+		StreamerHeartbeatIntervalFlag, cfg.HeartbeatInterval,
 	).Msg(workermode.StrStartingWorker(workermode.StreamerMode))
 
-	senv.Log.Warn().Msg("Streamer has not been implemented yet.")
+	streamer.New(senv, streamer.Config{
+		Host:              cfg.Host,
+		StreamerPort:      cfg.StreamerPort,
+		Verbose:           cfg.Verbose,
+		RefreshInterval:   cfg.RefreshInterval,
+		HeartbeatInterval: cfg.HeartbeatInterval,
+	})
 }
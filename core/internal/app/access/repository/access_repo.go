@@ -9,7 +9,6 @@ import (
 	"database/sql"
 
 	"github.com/jackc/pgx/v4/pgxpool"
-	"github.com/lib/pq"
 )
 
 type Repo struct {
@@ -184,7 +183,7 @@ RETURNING
 			i.ExpiresAt,
 			i.Name,
 			i.Description,
-			pq.Array(i.Tags),
+			i.Tags,
 			i.Scope,
 			i.WorkspaceKey,
 			i.ProjectKey,
@@ -301,7 +300,7 @@ WHERE key = $1;`
 		i.Key,
 		i.Name,
 		i.Description,
-		pq.Array(i.Tags),
+		i.Tags,
 		i.Type,
 		i.ExpiresAt,
 		i.Secret,
@@ -10,4 +10,7 @@ type Environment struct {
 	Name        rsc.Name        `json:"name,omitempty" jsonapi:"attr,name,omitempty"`
 	Description rsc.Description `json:"description,omitempty" jsonapi:"attr,description,omitempty"`
 	Tags        rsc.Tags        `json:"tags,omitempty" jsonapi:"attr,tags,omitempty"`
+	// Holdout the first Holdout buckets of the environment never enter any experiment
+	Holdout  int32 `json:"holdout" jsonapi:"attr,holdout"`
+	Revision int64 `json:"revision" jsonapi:"attr,revision"`
 }
@@ -8,7 +8,6 @@ import (
 	"core/pkg/dbutil"
 
 	"github.com/jackc/pgx/v4/pgxpool"
-	"github.com/lib/pq"
 )
 
 type Repo struct {
@@ -32,7 +31,9 @@ SELECT
   e.key,
   e.name,
   e.description,
-  e.tags
+  e.tags,
+  e.holdout,
+  e.revision
 FROM environment e
 LEFT JOIN project p
   ON p.id = e.project_id
@@ -57,6 +58,8 @@ WHERE w.key = $1
 			&_o.Name,
 			&_o.Description,
 			&_o.Tags,
+			&_o.Holdout,
+			&_o.Revision,
 		); err != nil {
 			return nil, err
 		}
@@ -78,6 +81,7 @@ INSERT INTO
     name,
     description,
     tags,
+    holdout,
     project_id
   )
 VALUES
@@ -86,6 +90,7 @@ VALUES
     $2,
     $3,
     $4,
+    $7,
     (
       SELECT p.id
       FROM project p
@@ -100,7 +105,9 @@ RETURNING
   key,
   name,
   description,
-  tags;`
+  tags,
+  holdout,
+  revision;`
 	err := dbutil.ParseError(
 		rsc.Environment.String(),
 		environmentmodel.ResourceArgs{
@@ -114,15 +121,18 @@ RETURNING
 			i.Key,
 			i.Name,
 			i.Description,
-			pq.Array(i.Tags),
+			i.Tags,
 			a.WorkspaceKey,
 			a.ProjectKey,
+			i.Holdout,
 		).Scan(
 			&o.ID,
 			&o.Key,
 			&o.Name,
 			&o.Description,
 			&o.Tags,
+			&o.Holdout,
+			&o.Revision,
 		),
 	)
 	return &o, err
@@ -139,7 +149,9 @@ SELECT
   e.key,
   e.name,
   e.description,
-  e.tags
+  e.tags,
+  e.holdout,
+  e.revision
 FROM environment e
 LEFT JOIN project p
   ON p.id = e.project_id
@@ -163,6 +175,8 @@ WHERE w.key = $1
 			&o.Name,
 			&o.Description,
 			&o.Tags,
+			&o.Holdout,
+			&o.Revision,
 		),
 	)
 	return &o, err
@@ -179,16 +193,21 @@ SET
   key = $2,
   name = $3,
   description = $4,
-  tags = $5
-WHERE id = $1`
-	if _, err := r.DB.Exec(
+  tags = $5,
+  holdout = $6
+WHERE id = $1
+RETURNING revision`
+	if err := r.DB.QueryRow(
 		ctx,
 		sqlStatement,
 		i.ID,
 		i.Key,
 		i.Name,
 		i.Description,
-		pq.Array(i.Tags),
+		i.Tags,
+		i.Holdout,
+	).Scan(
+		&i.Revision,
 	); err != nil {
 		return &i, dbutil.ParseError(
 			rsc.Environment.String(),
@@ -79,6 +79,11 @@ func (s *Service) Create(
 		return nil, &e
 	}
 
+	if err := validateHoldout(i.Holdout); !err.IsEmpty() {
+		e.Extend(err)
+		return nil, &e
+	}
+
 	r, err := s.EnvironmentRepo.Create(ctx, i, a)
 	if err != nil {
 		e.Append(cons.ErrorInput, err.Error())
@@ -134,6 +139,11 @@ func (s *Service) Update(
 		cancel()
 	}
 
+	if err := validateHoldout(o.Holdout); !err.IsEmpty() {
+		e.Extend(err)
+		return r, &e
+	}
+
 	r, err = s.EnvironmentRepo.Update(ctx, o, a)
 	if err != nil {
 		e.Append(cons.ErrorInternal, err.Error())
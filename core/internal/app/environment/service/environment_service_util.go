@@ -9,6 +9,7 @@ import (
 	variationmodel "core/internal/app/variation/model"
 	cons "core/internal/pkg/constants"
 	rsc "core/internal/pkg/resource"
+	"core/pkg/evaluator"
 	"core/pkg/model"
 	res "core/pkg/response"
 	"fmt"
@@ -63,6 +64,7 @@ func (s *Service) createChildren(
 			e.Append(cons.ErrorInternal, fmt.Sprintf("No variation found on flag with key=%s", f.Key))
 		}
 
+		offVariationKey := ""
 		fallthroughVariations := make([]*model.Variation, 0)
 		for idx, _v := range vl {
 			nV := &model.Variation{
@@ -71,11 +73,13 @@ func (s *Service) createChildren(
 			}
 			if idx == 0 {
 				nV.Weight = model.DefaultVariationOnWeight
+				offVariationKey = nV.VariationKey
 			}
 			fallthroughVariations = append(fallthroughVariations, nV)
 		}
 		_, _err = s.TargetingRepo.Create(ctx, targetingmodel.Targeting{
 			Enabled:               false,
+			OffVariationKey:       offVariationKey,
 			FallthroughVariations: fallthroughVariations,
 		}, targetingmodel.RootArgs{
 			WorkspaceKey:   a.WorkspaceKey,
@@ -90,3 +94,14 @@ func (s *Service) createChildren(
 
 	return &e
 }
+
+// validateHoldout checks the global holdout is a share of the environment's bucket space
+func validateHoldout(holdout int32) *res.Errors {
+	var e res.Errors
+
+	if holdout < 0 || holdout > int32(evaluator.BucketSize) {
+		e.Append(cons.ErrorInput, fmt.Sprintf("holdout must be between 0 and %d", evaluator.BucketSize))
+	}
+
+	return &e
+}
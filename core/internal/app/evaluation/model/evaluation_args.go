@@ -8,3 +8,19 @@ type RootArgs struct {
 	ProjectKey     rsc.Key
 	EnvironmentKey rsc.Key
 }
+
+// FlagArgs arguments for selecting a single flag
+type FlagArgs struct {
+	WorkspaceKey   rsc.Key
+	ProjectKey     rsc.Key
+	EnvironmentKey rsc.Key
+	FlagKey        rsc.Key
+}
+
+// Options options used when evaluating a flagset
+type Options struct {
+	// Explain include a trace explaining how each flag was evaluated
+	Explain bool
+	// FlagKeys restrict the evaluation to these flags (all flags are evaluated if empty)
+	FlagKeys []string
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"core/pkg/evaluator"
+	"core/pkg/model"
+	"sync"
+)
+
+// EvaluateInput evaluation context, optionally restricted to a subset of flags
+type EvaluateInput struct {
+	model.Context
+	FlagKeys []string `json:"flagKeys,omitempty"`
+}
+
+// EvaluateFlagInput evaluation context along with the caller's default,
+// which is served when the flag can't be evaluated (e.g. it doesn't exist)
+type EvaluateFlagInput struct {
+	model.Context
+	DefaultVariationKey string      `json:"defaultVariationKey"`
+	DefaultValue        model.Value `json:"defaultValue,omitempty"`
+}
+
+// EvaluateBatchInput evaluation contexts evaluated against the same flagset,
+// optionally restricted to a subset of flags
+type EvaluateBatchInput struct {
+	Contexts []model.Context `json:"contexts"`
+	FlagKeys []string        `json:"flagKeys,omitempty"`
+}
+
+// Assignment the variation an identity was first bucketed into by a sticky flag
+type Assignment struct {
+	IdentityKey  string
+	FlagKey      string
+	VariationKey string
+}
+
+// Scope IDs of the environment and its project, i.e. what change notifications refer to
+type Scope struct {
+	ProjectID     string
+	EnvironmentID string
+}
+
+// Flagset an environment's flags along with the revision of its configuration
+type Flagset struct {
+	Revision int64
+	Flags    []*model.Flag
+
+	compileOnce sync.Once
+	compiled    *evaluator.CompiledFlagset
+}
+
+// Compiled compiles the flags the first time it's called, so a flagset
+// which is shared between requests (e.g. cached) is only compiled once
+func (f *Flagset) Compiled() *evaluator.CompiledFlagset {
+	f.compileOnce.Do(func() {
+		f.compiled = evaluator.Compile(f.Flags)
+	})
+	return f.compiled
+}
@@ -3,9 +3,12 @@ package repository
 import (
 	"context"
 	evaluationmodel "core/internal/app/evaluation/model"
+	rsc "core/internal/pkg/resource"
 	"core/internal/pkg/srvenv"
+	"core/pkg/dbutil"
 	"core/pkg/model"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -19,15 +22,77 @@ func NewRepo(senv *srvenv.Env) *Repo {
 	}
 }
 
+// querier runs queries on the pool or within a transaction
+type querier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
 func (r *Repo) List(
 	ctx context.Context,
 	a evaluationmodel.RootArgs,
+) ([]*model.Flag, error) {
+	return list(ctx, r.DB, a)
+}
+
+// GetFlagset gets the environment's flags along with its revision, which are
+// read from the same snapshot (i.e. the flags are those of the revision)
+func (r *Repo) GetFlagset(
+	ctx context.Context,
+	a evaluationmodel.RootArgs,
+) (*evaluationmodel.Flagset, error) {
+	var o evaluationmodel.Flagset
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	sqlStatement := `
+SELECT e.revision
+FROM environment e
+LEFT JOIN project p ON p.id = e.project_id
+LEFT JOIN workspace w ON w.id = p.workspace_id
+WHERE 1=1
+	AND w.key = $1
+	AND p.key = $2
+	AND e.key = $3`
+	if err := dbutil.ParseError(
+		rsc.Environment.String(),
+		a,
+		tx.QueryRow(
+			ctx,
+			sqlStatement,
+			a.WorkspaceKey,
+			a.ProjectKey,
+			a.EnvironmentKey,
+		).Scan(
+			&o.Revision,
+		),
+	); err != nil {
+		return nil, err
+	}
+
+	if o.Flags, err = list(ctx, tx, a); err != nil {
+		return nil, err
+	}
+
+	return &o, tx.Commit(ctx)
+}
+
+func list(
+	ctx context.Context,
+	db querier,
+	a evaluationmodel.RootArgs,
 ) ([]*model.Flag, error) {
 	var o []*model.Flag
 	sqlStatement := `
 SELECT 
 	f.id AS id,
 	f.key AS flag_key,
+	f.value_type AS value_type,
 	(
 		SELECT not t.enabled
 		FROM targeting t 
@@ -35,12 +100,43 @@ SELECT
 			AND t.flag_id = f.id 
 			AND t.environment_id = e.id
 	) AS use_fallthrough,
+	f.killed AS killed,
+	COALESCE((
+		SELECT ov.key
+		FROM targeting t
+		LEFT JOIN variation ov ON ov.id = t.off_variation_id
+		WHERE 1=1
+			AND t.flag_id = f.id
+			AND t.environment_id = e.id
+	), '') AS off_variation_key,
+	COALESCE((
+		SELECT t.bucket_by
+		FROM targeting t
+		WHERE 1=1
+			AND t.flag_id = f.id
+			AND t.environment_id = e.id
+	), '') AS bucket_by,
+	COALESCE((
+		SELECT t.seed
+		FROM targeting t
+		WHERE 1=1
+			AND t.flag_id = f.id
+			AND t.environment_id = e.id
+	), '') AS seed,
+	COALESCE((
+		SELECT t.sticky
+		FROM targeting t
+		WHERE 1=1
+			AND t.flag_id = f.id
+			AND t.environment_id = e.id
+	), false) AS sticky,
 	(
 		SELECT json_agg(
 			json_build_object(
 				'id', tfv.variation_id,
 				'variationKey', v.key,
-				'weight', tfv.weight
+				'weight', tfv.weight,
+				'value', v.value
 			)
 		)
 		FROM targeting_fallthrough_variation tfv 
@@ -57,14 +153,63 @@ SELECT
 				'ruleType', tr.type,
 				'traitKey', tr.trait_key,
 				'traitValue', tr.trait_value,
+				'traitValues', tr.trait_values,
 				'operator', tr.operator,
 				'negate', tr.negate,
+				'match', tr.match_type,
+				'clauses', (
+					SELECT json_agg(
+						json_build_object(
+							'traitKey', trc.trait_key,
+							'traitValue', trc.trait_value,
+							'traitValues', trc.trait_values,
+							'operator', trc.operator,
+							'negate', trc.negate
+						) ORDER BY trc.position
+					)
+					FROM targeting_rule_clause trc
+					WHERE trc.targeting_rule_id = tr.id
+				),
+				'identityKey', i.key,
+				'segmentKey', s.key,
+				'segmentRules', (
+					SELECT json_agg(
+						json_build_object(
+							'id', sr.id,
+							'ruleType', 'trait',
+							'traitKey', sr.trait_key,
+							'traitValue', sr.trait_value,
+							'traitValues', sr.trait_values,
+							'operator', sr.operator,
+							'negate', sr.negate,
+							'match', sr.match_type,
+							'clauses', (
+								SELECT json_agg(
+									json_build_object(
+										'traitKey', src.trait_key,
+										'traitValue', src.trait_value,
+										'traitValues', src.trait_values,
+										'operator', src.operator,
+										'negate', src.negate
+									) ORDER BY src.position
+								)
+								FROM segment_rule_clause src
+								WHERE src.segment_rule_id = sr.id
+							)
+						)
+					)
+					FROM segment_rule sr
+					WHERE 1=1
+						AND sr.segment_id = tr.segment_id
+						AND sr.environment_id = e.id
+				),
 				'ruleVariations', (
 					SELECT json_agg(
 						json_build_object(
 							'id', trv.variation_id,
 							'variationKey', v2.key,
-							'weight', trv.weight
+							'weight', trv.weight,
+							'value', v2.value
 						)
 					)
 					FROM targeting_rule_variation trv 
@@ -75,10 +220,45 @@ SELECT
 		)
 		FROM targeting_rule tr 
 		LEFT JOIN targeting t ON t.id = tr.targeting_id
+		LEFT JOIN identity i ON i.id = tr.identity_id
+		LEFT JOIN segment s ON s.id = tr.segment_id
 		WHERE 1=1
 			AND t.flag_id = f.id 
 			AND t.environment_id = e.id
-	) AS rules
+	) AS rules,
+	(
+		SELECT json_agg(
+			json_build_object(
+				'flagKey', pf.key,
+				'variationKeys', tp.variation_keys
+			) ORDER BY pf.key
+		)
+		FROM (
+			SELECT
+				tp.prerequisite_flag_id,
+				array_agg(pv.key ORDER BY pv.key) AS variation_keys
+			FROM targeting_prerequisite tp
+			LEFT JOIN targeting t ON t.id = tp.targeting_id
+			LEFT JOIN variation pv ON pv.id = tp.variation_id
+			WHERE 1=1
+				AND t.flag_id = f.id
+				AND t.environment_id = e.id
+			GROUP BY tp.prerequisite_flag_id
+		) tp
+		LEFT JOIN flag pf ON pf.id = tp.prerequisite_flag_id
+	) AS prerequisites,
+	(
+		SELECT json_build_object(
+			'layerKey', l.key,
+			'holdout', l.holdout,
+			'start', lf.bucket_start,
+			'end', lf.bucket_end
+		)
+		FROM layer_flag lf
+		LEFT JOIN layer l ON l.id = lf.layer_id
+		WHERE lf.flag_id = f.id
+	) AS layer,
+	e.holdout
 FROM flag f 
 LEFT JOIN project p ON p.id = f.project_id
 LEFT JOIN workspace w ON w.id = p.workspace_id
@@ -87,7 +267,7 @@ WHERE 1=1
 	AND w.key = $1
 	AND p.key = $2
 	AND e.key = $3`
-	rows, err := r.DB.Query(
+	rows, err := db.Query(
 		ctx,
 		sqlStatement,
 		a.WorkspaceKey,
@@ -104,9 +284,18 @@ WHERE 1=1
 		if err = rows.Scan(
 			&_o.ID,
 			&_o.FlagKey,
+			&_o.ValueType,
 			&_o.UseFallthrough,
+			&_o.Killed,
+			&_o.OffVariationKey,
+			&_o.BucketBy,
+			&_o.Seed,
+			&_o.Sticky,
 			&_o.FallthroughVariations,
 			&_o.Rules,
+			&_o.Prerequisites,
+			&_o.Layer,
+			&_o.Holdout,
 		); err != nil {
 			return nil, err
 		}
@@ -119,3 +308,141 @@ WHERE 1=1
 
 	return o, nil
 }
+
+// GetScope gets the IDs of the environment and its project
+func (r *Repo) GetScope(
+	ctx context.Context,
+	a evaluationmodel.RootArgs,
+) (*evaluationmodel.Scope, error) {
+	var o evaluationmodel.Scope
+	sqlStatement := `
+SELECT
+  p.id,
+  e.id
+FROM environment e
+LEFT JOIN project p
+  ON p.id = e.project_id
+LEFT JOIN workspace w
+  ON w.id = p.workspace_id
+WHERE w.key = $1
+  AND p.key = $2
+  AND e.key = $3`
+	err := dbutil.ParseError(
+		rsc.Environment.String(),
+		a,
+		r.DB.QueryRow(
+			ctx,
+			sqlStatement,
+			a.WorkspaceKey,
+			a.ProjectKey,
+			a.EnvironmentKey,
+		).Scan(
+			&o.ProjectID,
+			&o.EnvironmentID,
+		),
+	)
+	return &o, err
+}
+
+// ListAssignments lists the variations recorded for the identities by sticky flags
+func (r *Repo) ListAssignments(
+	ctx context.Context,
+	identityKeys []string,
+	a evaluationmodel.RootArgs,
+) ([]*evaluationmodel.Assignment, error) {
+	var o []*evaluationmodel.Assignment
+	sqlStatement := `
+SELECT
+	ta.identity_key,
+	f.key,
+	v.key
+FROM targeting_assignment ta
+LEFT JOIN targeting t ON t.id = ta.targeting_id
+LEFT JOIN variation v ON v.id = ta.variation_id
+LEFT JOIN flag f ON f.id = t.flag_id
+LEFT JOIN environment e ON e.id = t.environment_id
+LEFT JOIN project p ON p.id = e.project_id
+LEFT JOIN workspace w ON w.id = p.workspace_id
+WHERE 1=1
+	AND w.key = $1
+	AND p.key = $2
+	AND e.key = $3
+	AND t.sticky
+	AND ta.identity_key = ANY($4)`
+	rows, err := r.DB.Query(
+		ctx,
+		sqlStatement,
+		a.WorkspaceKey,
+		a.ProjectKey,
+		a.EnvironmentKey,
+		identityKeys,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var _o evaluationmodel.Assignment
+		if err = rows.Scan(
+			&_o.IdentityKey,
+			&_o.FlagKey,
+			&_o.VariationKey,
+		); err != nil {
+			return nil, err
+		}
+		o = append(o, &_o)
+	}
+
+	return o, rows.Err()
+}
+
+// SaveAssignments records the identities' first assignments, assignments
+// recorded in the meantime (e.g. by a concurrent evaluation) are kept
+func (r *Repo) SaveAssignments(
+	ctx context.Context,
+	i []*evaluationmodel.Assignment,
+	a evaluationmodel.RootArgs,
+) error {
+	identityKeys := make([]string, len(i))
+	flagKeys := make([]string, len(i))
+	variationKeys := make([]string, len(i))
+	for idx, _i := range i {
+		identityKeys[idx] = _i.IdentityKey
+		flagKeys[idx] = _i.FlagKey
+		variationKeys[idx] = _i.VariationKey
+	}
+
+	sqlStatement := `
+INSERT INTO
+  targeting_assignment(
+    identity_key,
+    targeting_id,
+    variation_id
+  )
+SELECT
+  ta.identity_key,
+  t.id,
+  v.id
+FROM unnest($4::text[], $5::text[], $6::text[]) AS ta(identity_key, flag_key, variation_key)
+LEFT JOIN workspace w ON w.key = $1
+LEFT JOIN project p ON p.workspace_id = w.id AND p.key = $2
+LEFT JOIN environment e ON e.project_id = p.id AND e.key = $3
+LEFT JOIN flag f ON f.project_id = p.id AND f.key = ta.flag_key
+LEFT JOIN targeting t ON t.flag_id = f.id AND t.environment_id = e.id
+LEFT JOIN variation v ON v.flag_id = f.id AND v.key = ta.variation_key
+WHERE t.sticky
+  AND v.id IS NOT NULL
+ON CONFLICT (targeting_id, identity_key) DO NOTHING`
+	_, err := r.DB.Exec(
+		ctx,
+		sqlStatement,
+		a.WorkspaceKey,
+		a.ProjectKey,
+		a.EnvironmentKey,
+		identityKeys,
+		flagKeys,
+		variationKeys,
+	)
+	return err
+}
@@ -13,11 +13,24 @@ import (
 	rsc "core/internal/pkg/resource"
 	"core/internal/pkg/srvenv"
 	"core/pkg/evaluator"
-	"core/pkg/hashutil"
 	"core/pkg/model"
 	res "core/pkg/response"
+	"fmt"
 )
 
+// FlagsetLoader loads the flagset of an environment along with its revision
+type FlagsetLoader interface {
+	GetFlagset(ctx context.Context, a evaluationmodel.RootArgs) (*evaluationmodel.Flagset, error)
+}
+
+// maxBatchSize max number of contexts evaluated per batch
+var maxBatchSize = cons.DefaultMaxBatchSize
+
+// SetMaxBatchSize sets the max number of contexts evaluated per batch, larger batches are rejected
+func SetMaxBatchSize(size int) {
+	maxBatchSize = size
+}
+
 type Service struct {
 	Senv              *srvenv.Env
 	EvaluationRepo    *evaluationrepo.Repo
@@ -26,79 +39,286 @@ type Service struct {
 	SegmentRuleRepo   *segmentrulerepo.Repo
 	TargetingRepo     *targetingrepo.Repo
 	TargetingRuleRepo *targetingrulerepo.Repo
+	// Flagsets loads the flagsets which are evaluated, i.e. the
+	// evaluation repo unless they're cached (e.g. by the poller)
+	Flagsets FlagsetLoader
 }
 
 func NewService(senv *srvenv.Env) *Service {
+	evaluationRepo := evaluationrepo.NewRepo(senv)
 	return &Service{
 		Senv:              senv,
-		EvaluationRepo:    evaluationrepo.NewRepo(senv),
+		EvaluationRepo:    evaluationRepo,
 		FlagRepo:          flagrepo.NewRepo(senv),
 		SegmentRepo:       segmentrepo.NewRepo(senv),
 		SegmentRuleRepo:   segmentrulerepo.NewRepo(senv),
 		TargetingRepo:     targetingrepo.NewRepo(senv),
 		TargetingRuleRepo: targetingrulerepo.NewRepo(senv),
+		Flagsets:          evaluationRepo,
 	}
 }
 
-// Get returns a set raw (non-evaluated) flagsets
+// Get returns a set raw (non-evaluated) flagsets, along with
+// the revision of the environment's configuration
 // (*) atk: access_type <= service
 func (s *Service) Get(
 	atk rsc.Token,
 	a evaluationmodel.RootArgs,
-) ([]*model.Flag, *res.Errors) {
+) ([]*model.Flag, int64, *res.Errors) {
+	r, e := s.getFlagset(a)
+	if !e.IsEmpty() {
+		return nil, 0, e
+	}
+
+	return r.Flags, r.Revision, e
+}
+
+// getFlagset loads the environment's flagset, which might be shared between
+// requests (i.e. it has to be treated as read-only)
+func (s *Service) getFlagset(a evaluationmodel.RootArgs) (*evaluationmodel.Flagset, *res.Errors) {
 	var e res.Errors
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	r, err := s.EvaluationRepo.List(ctx, a)
+	r, err := s.Flagsets.GetFlagset(ctx, a)
 	if err != nil {
 		e.Append(cons.ErrorNotFound, err.Error())
+		return nil, &e
 	}
 
 	return r, &e
 }
 
-// Evaluate returns an evaluated flagset given the user context
+// Evaluate returns an evaluated flagset given the user context (along with the revision
+// evaluated against), in explain mode every evaluation includes a trace
 func (s *Service) Evaluate(
 	atk rsc.Token,
 	ectx model.Context,
+	opts evaluationmodel.Options,
+	a evaluationmodel.RootArgs,
+) (*model.Evaluations, int64, *res.Errors) {
+	var e res.Errors
+
+	var o model.Evaluations
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, err := s.getFlagset(a)
+	if !err.IsEmpty() {
+		e.Extend(err)
+		return &o, 0, &e
+	}
+
+	sticky := stickyFlags(r.Flags)
+	contexts := []model.Context{ectx}
+	if err := s.loadAssignments(ctx, sticky, contexts, a); err != nil {
+		e.Append(cons.ErrorInternal, err.Error())
+		return &o, r.Revision, &e
+	}
+
+	o = newFlagsetEvaluator(r, opts)(contexts[0])
+	s.recordAssignments(ctx, newAssignments(sticky, ectx.Identifier, o), a)
+
+	return &o, r.Revision, &e
+}
+
+// EvaluateFlag returns a single evaluated flag given the user context (along with the revision evaluated
+// against), the caller's default is served if the flag isn't part of the flagset
+func (s *Service) EvaluateFlag(
+	atk rsc.Token,
+	i evaluationmodel.EvaluateFlagInput,
+	opts evaluationmodel.Options,
+	a evaluationmodel.FlagArgs,
+) (*model.Evaluation, int64, *res.Errors) {
+	var e res.Errors
+
+	o := &model.Evaluation{
+		FlagKey:      a.FlagKey.String(),
+		VariationKey: i.DefaultVariationKey,
+		Reason:       model.ReasonDefault,
+		Value:        i.DefaultValue,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ra := evaluationmodel.RootArgs{
+		WorkspaceKey:   a.WorkspaceKey,
+		ProjectKey:     a.ProjectKey,
+		EnvironmentKey: a.EnvironmentKey,
+	}
+	r, err := s.getFlagset(ra)
+	if !err.IsEmpty() {
+		e.Extend(err)
+		return o, 0, &e
+	}
+
+	sticky := stickyFlags(r.Flags)
+	contexts := []model.Context{i.Context}
+	if err := s.loadAssignments(ctx, sticky, contexts, ra); err != nil {
+		e.Append(cons.ErrorInternal, err.Error())
+		return o, r.Revision, &e
+	}
+
+	opts.FlagKeys = []string{a.FlagKey.String()}
+	evals := newFlagsetEvaluator(r, opts)(contexts[0])
+	if len(evals) > 0 && evals[0].VariationKey != "" {
+		o = evals[0]
+	}
+	s.recordAssignments(ctx, newAssignments(sticky, i.Identifier, evals), ra)
+
+	return o, r.Revision, &e
+}
+
+// EvaluateBatch evaluates the flagset for every context, the flagset is only loaded (and compiled)
+// once. The evaluations of each context (along with the revision evaluated against) are passed
+// to emit in order, evaluation stops if emit fails. Evaluations are reused for the next context,
+// so emit has to copy them (see model.BatchEvaluation.Clone) if they're kept after it returns.
+func (s *Service) EvaluateBatch(
+	atk rsc.Token,
+	contexts []model.Context,
+	opts evaluationmodel.Options,
 	a evaluationmodel.RootArgs,
-) (*model.Evaluations, *res.Errors) {
+	emit func(*model.BatchEvaluation) error,
+) *res.Errors {
 	var e res.Errors
 
-	r, err := s.Get(atk, a)
+	if _e := ValidateBatchSize(contexts); !_e.IsEmpty() {
+		return _e
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, err := s.getFlagset(a)
 	if !err.IsEmpty() {
 		e.Extend(err)
+		return &e
 	}
 
-	type evalResult struct {
-		eval    *model.Evaluation
-		err     error
-		flagIdx int
+	// assignments are loaded (and recorded) for the whole batch at once
+	sticky := stickyFlags(r.Flags)
+	if err := s.loadAssignments(ctx, sticky, contexts, a); err != nil {
+		e.Append(cons.ErrorInternal, err.Error())
+		return &e
 	}
-	evalResultChan := make(chan evalResult, len(r))
 
-	for idx, flag := range r {
-		go func(flag model.Flag, flagIdx int) {
-			res := evalResult{flagIdx: flagIdx}
-			salt := hashutil.HashKeys(
-				flag.FlagKey,
-				ectx.Identifier,
-			)
-			res.eval = evaluator.Evaluate(flag, salt, ectx)
-			evalResultChan <- res
-		}(*flag, idx)
+	// identifiers can repeat within a batch (e.g. anonymous contexts), so an identity's
+	// later contexts are served the variations assigned while evaluating earlier ones
+	var assignments []*evaluationmodel.Assignment
+	assigned := make(map[string]map[string]string)
+	evaluate := newFlagsetEvaluator(r, opts)
+	o := &model.BatchEvaluation{Revision: r.Revision}
+	for _, ectx := range contexts {
+		ectx.Assignments = mergeAssignments(ectx.Assignments, assigned[ectx.Identifier])
+		o.Identifier = ectx.Identifier
+		o.Evaluations = evaluate(ectx)
+		if err := emit(o); err != nil {
+			e.Append(cons.ErrorInternal, err.Error())
+			break
+		}
+		for _, _a := range newAssignments(sticky, ectx.Identifier, o.Evaluations) {
+			if assigned[_a.IdentityKey] == nil {
+				assigned[_a.IdentityKey] = make(map[string]string)
+			}
+			assigned[_a.IdentityKey][_a.FlagKey] = _a.VariationKey
+			assignments = append(assignments, _a)
+		}
 	}
+	s.recordAssignments(ctx, assignments, a)
 
-	o := make(model.Evaluations, len(r))
+	return &e
+}
+
+// ValidateBatchSize checks if the batch doesn't exceed the max batch size (see SetMaxBatchSize)
+func ValidateBatchSize(contexts []model.Context) *res.Errors {
+	var e res.Errors
+	if len(contexts) > maxBatchSize {
+		e.Append(cons.ErrorInput, fmt.Sprintf(
+			"batch of %d contexts exceeds the max batch size (%d)",
+			len(contexts),
+			maxBatchSize,
+		))
+	}
+	return &e
+}
 
-	for range r {
-		res := <-evalResultChan
-		if res.err != nil {
-			e.Append(cons.ErrorInternal, res.err.Error())
+// newFlagsetEvaluator prepares the flags for evaluation (restricted to opts.FlagKeys if set),
+// the returned function evaluates the prepared flags given the user context. Unless explained,
+// the flagset's compiled flags are evaluated into the same buffer every time, i.e. evaluations
+// are only valid until the function is called again.
+func newFlagsetEvaluator(
+	flagset *evaluationmodel.Flagset,
+	opts evaluationmodel.Options,
+) func(ectx model.Context) model.Evaluations {
+	var requested map[string]bool
+	if len(opts.FlagKeys) > 0 {
+		requested = make(map[string]bool, len(opts.FlagKeys))
+		for _, key := range opts.FlagKeys {
+			requested[key] = true
 		}
-		o[res.flagIdx] = res.eval
 	}
 
-	return &o, &e
+	if opts.Explain {
+		flags := flagset.Flags
+		if requested != nil {
+			// prerequisites are evaluated as well, but only the requested flags are returned
+			flags = evaluator.Subset(flags, opts.FlagKeys)
+		}
+		return func(ectx model.Context) model.Evaluations {
+			return filterEvaluations(explainFlagset(flags, ectx), requested)
+		}
+	}
+
+	// the whole flagset is evaluated, as it's compiled once per revision (rather than per request)
+	compiled := flagset.Compiled()
+	var buf []model.Evaluation
+	var o model.Evaluations
+	return func(ectx model.Context) model.Evaluations {
+		buf = compiled.EvaluateInto(ectx, buf)
+		o = o[:0]
+		for idx := range buf {
+			o = append(o, &buf[idx])
+		}
+		return filterEvaluations(o, requested)
+	}
+}
+
+// filterEvaluations restricts the evaluations to the requested flags (in place), all
+// evaluations are returned if no flags were requested
+func filterEvaluations(evals model.Evaluations, requested map[string]bool) model.Evaluations {
+	if requested == nil {
+		return evals
+	}
+	o := evals[:0]
+	for _, eval := range evals {
+		if requested[eval.FlagKey] {
+			o = append(o, eval)
+		}
+	}
+	return o
+}
+
+// explainFlagset evaluates the flags including a trace for every evaluation
+func explainFlagset(flags []*model.Flag, ectx model.Context) model.Evaluations {
+	// flags are explained in dependency order, so prerequisites are
+	// evaluated before the flags that depend on them
+	evaluated := make(map[string]*model.Evaluation, len(flags))
+	for _, level := range evaluator.DependencyLevels(flags) {
+		explained := make([]*model.Evaluation, 0, len(level))
+		for _, flag := range level {
+			salt := evaluator.DeriveSalt(*flag, ectx)
+			explained = append(explained, evaluator.ExplainWithPrerequisites(*flag, salt, ectx, evaluated))
+		}
+		// evaluated is only written once every flag of the level is done
+		for _, eval := range explained {
+			evaluated[eval.FlagKey] = eval
+		}
+	}
+
+	o := make(model.Evaluations, len(flags))
+	for idx, flag := range flags {
+		o[idx] = evaluated[flag.FlagKey]
+	}
+	return o
 }
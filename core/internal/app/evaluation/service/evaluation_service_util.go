@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	evaluationmodel "core/internal/app/evaluation/model"
+	"core/pkg/evaluator"
+	"core/pkg/model"
+)
+
+// stickyFlags the flagset's sticky flags keyed by flag key
+func stickyFlags(flags []*model.Flag) map[string]*model.Flag {
+	o := make(map[string]*model.Flag)
+	for _, f := range flags {
+		if f.Sticky {
+			o[f.FlagKey] = f
+		}
+	}
+	return o
+}
+
+// loadAssignments sets the variations recorded for each identity by the sticky flags,
+// contexts without an identifier can't be assigned a variation
+func (s *Service) loadAssignments(
+	ctx context.Context,
+	sticky map[string]*model.Flag,
+	contexts []model.Context,
+	a evaluationmodel.RootArgs,
+) error {
+	if len(sticky) == 0 {
+		return nil
+	}
+
+	identityKeys := make([]string, 0, len(contexts))
+	for _, ectx := range contexts {
+		if ectx.Identifier != "" {
+			identityKeys = append(identityKeys, ectx.Identifier)
+		}
+	}
+	if len(identityKeys) == 0 {
+		return nil
+	}
+
+	r, err := s.EvaluationRepo.ListAssignments(ctx, identityKeys, a)
+	if err != nil {
+		return err
+	}
+
+	assignments := make(map[string]map[string]string)
+	for _, _r := range r {
+		if assignments[_r.IdentityKey] == nil {
+			assignments[_r.IdentityKey] = make(map[string]string)
+		}
+		assignments[_r.IdentityKey][_r.FlagKey] = _r.VariationKey
+	}
+	for idx := range contexts {
+		contexts[idx].Assignments = assignments[contexts[idx].Identifier]
+	}
+
+	return nil
+}
+
+// mergeAssignments adds the assignments to the context's assignments (without
+// modifying them, as contexts of the same identity share their assignments)
+func mergeAssignments(recorded map[string]string, assigned map[string]string) map[string]string {
+	if len(assigned) == 0 {
+		return recorded
+	}
+	o := make(map[string]string, len(recorded)+len(assigned))
+	for flagKey, variationKey := range recorded {
+		o[flagKey] = variationKey
+	}
+	for flagKey, variationKey := range assigned {
+		o[flagKey] = variationKey
+	}
+	return o
+}
+
+// newAssignments the bucketed variations of the sticky flags,
+// which are recorded as the identity's first assignments
+func newAssignments(
+	sticky map[string]*model.Flag,
+	identifier string,
+	evals model.Evaluations,
+) []*evaluationmodel.Assignment {
+	if len(sticky) == 0 || identifier == "" {
+		return nil
+	}
+
+	var o []*evaluationmodel.Assignment
+	for _, eval := range evals {
+		if f, ok := sticky[eval.FlagKey]; ok && evaluator.Assignable(*f, eval) {
+			o = append(o, &evaluationmodel.Assignment{
+				IdentityKey:  identifier,
+				FlagKey:      eval.FlagKey,
+				VariationKey: eval.VariationKey,
+			})
+		}
+	}
+	return o
+}
+
+// recordAssignments records the identities' first assignments (if there are any), the
+// evaluations were already served so failing to record them is only logged
+func (s *Service) recordAssignments(
+	ctx context.Context,
+	assignments []*evaluationmodel.Assignment,
+	a evaluationmodel.RootArgs,
+) {
+	if len(assignments) == 0 {
+		return
+	}
+	if err := s.EvaluationRepo.SaveAssignments(ctx, assignments, a); err != nil {
+		s.Senv.Log.Error().Msg(err.Error())
+	}
+}
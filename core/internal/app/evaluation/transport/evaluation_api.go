@@ -36,9 +36,18 @@ func ApplyRoutes(senv *srvenv.Env, r *gin.RouterGroup) {
 		rsc.ProjectKey,
 		rsc.EnvironmentKey,
 	)
+	resourcePath := httputil.AppendPath(
+		rootPath,
+		rsc.FlagKey,
+	)
 
 	routes.GET(rootPath, h.getEvaluationAPIHandler)
 	routes.POST(rootPath, h.evaluateAPIHandler)
+	routes.POST(resourcePath, h.evaluateFlagAPIHandler)
+	routes.POST(
+		httputil.AppendRoute(rootPath, rsc.RouteBatch),
+		h.evaluateBatchAPIHandler,
+	)
 }
 
 func (h *APIHandler) getEvaluationAPIHandler(ctx *gin.Context) {
@@ -49,7 +58,7 @@ func (h *APIHandler) getEvaluationAPIHandler(ctx *gin.Context) {
 		e.Append(cons.ErrorAuth, err.Error())
 	}
 
-	r, _err := h.EvaluationService.Get(
+	r, revision, _err := h.EvaluationService.Get(
 		atk,
 		evaluationmodel.RootArgs{
 			WorkspaceKey:   httputil.GetParam(ctx, rsc.WorkspaceKey),
@@ -60,6 +69,9 @@ func (h *APIHandler) getEvaluationAPIHandler(ctx *gin.Context) {
 	if !_err.IsEmpty() {
 		e.Extend(_err)
 	}
+	if e.IsEmpty() {
+		httputil.SetRevision(ctx, revision)
+	}
 
 	httputil.SendJSON(
 		ctx,
@@ -78,14 +90,18 @@ func (h *APIHandler) evaluateAPIHandler(ctx *gin.Context) {
 		e.Append(cons.ErrorAuth, err.Error())
 	}
 
-	var i model.Context
+	var i evaluationmodel.EvaluateInput
 	if err := ctx.BindJSON(&i); err != nil {
 		e.Append(cons.ErrorInternal, err.Error())
 	}
 
-	r, _err := h.EvaluationService.Evaluate(
+	r, revision, _err := h.EvaluationService.Evaluate(
 		atk,
-		i,
+		i.Context,
+		evaluationmodel.Options{
+			Explain:  httputil.GetQueryBool(ctx, "explain"),
+			FlagKeys: i.FlagKeys,
+		},
 		evaluationmodel.RootArgs{
 			WorkspaceKey:   httputil.GetParam(ctx, rsc.WorkspaceKey),
 			ProjectKey:     httputil.GetParam(ctx, rsc.ProjectKey),
@@ -95,6 +111,51 @@ func (h *APIHandler) evaluateAPIHandler(ctx *gin.Context) {
 	if !_err.IsEmpty() {
 		e.Extend(_err)
 	}
+	if e.IsEmpty() {
+		httputil.SetRevision(ctx, revision)
+	}
+
+	httputil.SendJSON(
+		ctx,
+		http.StatusOK,
+		*r,
+		http.StatusInternalServerError,
+		e,
+	)
+}
+
+func (h *APIHandler) evaluateFlagAPIHandler(ctx *gin.Context) {
+	var e res.Errors
+
+	atk, err := httputil.ExtractATK(ctx)
+	if err != nil {
+		e.Append(cons.ErrorAuth, err.Error())
+	}
+
+	var i evaluationmodel.EvaluateFlagInput
+	if err := ctx.BindJSON(&i); err != nil {
+		e.Append(cons.ErrorInternal, err.Error())
+	}
+
+	r, revision, _err := h.EvaluationService.EvaluateFlag(
+		atk,
+		i,
+		evaluationmodel.Options{
+			Explain: httputil.GetQueryBool(ctx, "explain"),
+		},
+		evaluationmodel.FlagArgs{
+			WorkspaceKey:   httputil.GetParam(ctx, rsc.WorkspaceKey),
+			ProjectKey:     httputil.GetParam(ctx, rsc.ProjectKey),
+			EnvironmentKey: httputil.GetParam(ctx, rsc.EnvironmentKey),
+			FlagKey:        httputil.GetParam(ctx, rsc.FlagKey),
+		},
+	)
+	if !_err.IsEmpty() {
+		e.Extend(_err)
+	}
+	if e.IsEmpty() {
+		httputil.SetRevision(ctx, revision)
+	}
 
 	httputil.SendJSON(
 		ctx,
@@ -104,3 +165,30 @@ func (h *APIHandler) evaluateAPIHandler(ctx *gin.Context) {
 		e,
 	)
 }
+
+func (h *APIHandler) evaluateBatchAPIHandler(ctx *gin.Context) {
+	var e res.Errors
+
+	atk, err := httputil.ExtractATK(ctx)
+	if err != nil {
+		e.Append(cons.ErrorAuth, err.Error())
+	}
+
+	httputil.SendBatch(ctx, &e, func(
+		contexts []model.Context,
+		opts evaluationmodel.Options,
+		emit func(o *model.BatchEvaluation) error,
+	) *res.Errors {
+		return h.EvaluationService.EvaluateBatch(
+			atk,
+			contexts,
+			opts,
+			evaluationmodel.RootArgs{
+				WorkspaceKey:   httputil.GetParam(ctx, rsc.WorkspaceKey),
+				ProjectKey:     httputil.GetParam(ctx, rsc.ProjectKey),
+				EnvironmentKey: httputil.GetParam(ctx, rsc.EnvironmentKey),
+			},
+			emit,
+		)
+	})
+}
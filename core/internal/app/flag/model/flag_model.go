@@ -1,6 +1,9 @@
 package model
 
-import rsc "core/internal/pkg/resource"
+import (
+	rsc "core/internal/pkg/resource"
+	"core/pkg/model"
+)
 
 // Flag (aka feature flag) consists of a set of variations and represents the state of a feature in a project.
 type Flag struct {
@@ -9,4 +12,6 @@ type Flag struct {
 	Name        rsc.Name        `json:"name,omitempty" jsonapi:"attr,name,omitempty"`
 	Description rsc.Description `json:"description,omitempty" jsonapi:"attr,description,omitempty"`
 	Tags        rsc.Tags        `json:"tags,omitempty" jsonapi:"attr,tags,omitempty"`
+	ValueType   model.ValueType `json:"valueType,omitempty" jsonapi:"attr,valueType,omitempty"`
+	Killed      bool            `json:"killed" jsonapi:"attr,killed"`
 }
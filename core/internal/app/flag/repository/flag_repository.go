@@ -8,7 +8,6 @@ import (
 	"core/pkg/dbutil"
 
 	"github.com/jackc/pgx/v4/pgxpool"
-	"github.com/lib/pq"
 )
 
 type Repo struct {
@@ -32,7 +31,9 @@ SELECT
   f.key,
   f.name,
   f.description,
-  f.tags
+  f.tags,
+  f.value_type,
+  f.killed
 FROM flag f
 LEFT JOIN project p
   ON p.id = f.project_id
@@ -57,6 +58,8 @@ WHERE w.key = $1
 			&_o.Name,
 			&_o.Description,
 			&_o.Tags,
+			&_o.ValueType,
+			&_o.Killed,
 		); err != nil {
 			return nil, err
 		}
@@ -78,6 +81,7 @@ INSERT INTO
     name,
     description,
     tags,
+    value_type,
     project_id
   )
 VALUES
@@ -86,6 +90,7 @@ VALUES
     $2,
     $3,
     $4,
+    $7,
     (
       SELECT p.id
       FROM project p
@@ -100,7 +105,9 @@ RETURNING
   key,
   name,
   description,
-  tags;`
+  tags,
+  value_type,
+  killed;`
 	err := dbutil.ParseError(
 		rsc.Flag.String(),
 		flagmodel.ResourceArgs{
@@ -113,15 +120,18 @@ RETURNING
 			i.Key,
 			i.Name,
 			i.Description,
-			pq.Array(i.Tags),
+			i.Tags,
 			a.WorkspaceKey,
 			a.ProjectKey,
+			i.ValueType,
 		).Scan(
 			&o.ID,
 			&o.Key,
 			&o.Name,
 			&o.Description,
 			&o.Tags,
+			&o.ValueType,
+			&o.Killed,
 		),
 	)
 	return &o, err
@@ -138,7 +148,9 @@ SELECT
   f.key,
   f.name,
   f.description,
-  f.tags
+  f.tags,
+  f.value_type,
+  f.killed
 FROM flag f
 LEFT JOIN project p
   ON p.id = f.project_id
@@ -162,6 +174,8 @@ WHERE w.key = $1
 			&o.Name,
 			&o.Description,
 			&o.Tags,
+			&o.ValueType,
+			&o.Killed,
 		),
 	)
 	return &o, err
@@ -179,7 +193,8 @@ SET
   key = $2,
   name = $3,
   description = $4,
-  tags = $5
+  tags = $5,
+  value_type = $6
 WHERE id = $1`
 	if _, err := r.DB.Exec(
 		ctx,
@@ -188,7 +203,8 @@ WHERE id = $1`
 		i.Key,
 		i.Name,
 		i.Description,
-		pq.Array(i.Tags),
+		i.Tags,
+		i.ValueType,
 	); err != nil {
 		return &i, dbutil.ParseError(
 			rsc.Flag.String(),
@@ -199,6 +215,59 @@ WHERE id = $1`
 	return &i, nil
 }
 
+// UpdateKilled engages (or releases) the flag's kill switch, killed flags
+// serve their off variation in every environment of the project
+func (r *Repo) UpdateKilled(
+	ctx context.Context,
+	killed bool,
+	a flagmodel.ResourceArgs,
+) (*flagmodel.Flag, error) {
+	var o flagmodel.Flag
+	sqlStatement := `
+UPDATE
+  flag
+SET
+  killed = $4
+WHERE key = $3
+  AND project_id = (
+    SELECT p.id
+    FROM project p
+    LEFT JOIN workspace w
+      ON w.id = p.workspace_id
+    WHERE w.key = $1
+      AND p.key = $2
+  )
+RETURNING
+  id,
+  key,
+  name,
+  description,
+  tags,
+  value_type,
+  killed;`
+	err := dbutil.ParseError(
+		rsc.Flag.String(),
+		a,
+		r.DB.QueryRow(
+			ctx,
+			sqlStatement,
+			a.WorkspaceKey,
+			a.ProjectKey,
+			a.FlagKey,
+			killed,
+		).Scan(
+			&o.ID,
+			&o.Key,
+			&o.Name,
+			&o.Description,
+			&o.Tags,
+			&o.ValueType,
+			&o.Killed,
+		),
+	)
+	return &o, err
+}
+
 func (r *Repo) Delete(
 	ctx context.Context,
 	a flagmodel.ResourceArgs,
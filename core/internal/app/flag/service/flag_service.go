@@ -11,8 +11,10 @@ import (
 	cons "core/internal/pkg/constants"
 	rsc "core/internal/pkg/resource"
 	"core/internal/pkg/srvenv"
+	"core/pkg/model"
 	"core/pkg/patch"
 	res "core/pkg/response"
+	"fmt"
 )
 
 type Service struct {
@@ -76,6 +78,14 @@ func (s *Service) Create(
 		return nil, &e
 	}
 
+	if i.ValueType == "" {
+		i.ValueType = model.ValueTypeBoolean
+	}
+	if !i.ValueType.IsValid() {
+		e.Append(cons.ErrorInput, fmt.Sprintf("unsupported value type %q", i.ValueType))
+		return nil, &e
+	}
+
 	r, err := s.FlagRepo.Create(ctx, i, a)
 	if err != nil {
 		e.Append(cons.ErrorInput, err.Error())
@@ -131,6 +141,16 @@ func (s *Service) Update(
 		cancel()
 	}
 
+	// the kill switch can only be changed using Kill
+	o.Killed = r.Killed
+
+	if o.ValueType != r.ValueType {
+		if err := s.validateValueType(ctx, o.ValueType, a); !err.IsEmpty() {
+			e.Extend(err)
+			return r, &e
+		}
+	}
+
 	r, err = s.FlagRepo.Update(ctx, o, a)
 	if err != nil {
 		e.Append(cons.ErrorInternal, err.Error())
@@ -139,6 +159,33 @@ func (s *Service) Update(
 	return r, &e
 }
 
+// Kill engages (or releases) the kill switch of a resource instance given an atk & key,
+// a killed flag serves its off variation in every environment
+// (*) atk: access_type <= user
+func (s *Service) Kill(
+	atk rsc.Token,
+	killed bool,
+	a flagmodel.ResourceArgs,
+) (*flagmodel.Flag, *res.Errors) {
+	var e res.Errors
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Verify access is authorized
+	_, err := authutil.Authorize(s.Senv, atk)
+	if err != nil {
+		e.Append(cons.ErrorAuth, err.Error())
+		return nil, &e
+	}
+
+	r, err := s.FlagRepo.UpdateKilled(ctx, killed, a)
+	if err != nil {
+		e.Append(cons.ErrorNotFound, err.Error())
+	}
+
+	return r, &e
+}
+
 // Delete deletes a resource instance given an atk & key
 // (*) atk: access_type <= admin
 func (s *Service) Delete(
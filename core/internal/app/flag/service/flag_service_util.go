@@ -10,6 +10,7 @@ import (
 	rsc "core/internal/pkg/resource"
 	"core/pkg/model"
 	res "core/pkg/response"
+	"fmt"
 )
 
 func (s *Service) createChildren(
@@ -30,6 +31,8 @@ func (s *Service) createChildren(
 		e.Append(cons.ErrorInternal, _err.Error())
 	}
 
+	cVal, tVal := i.ValueType.DefaultValues()
+
 	cVar, _e := s.VariationRepo.Create(
 		ctx,
 		variationmodel.Variation{
@@ -37,6 +40,7 @@ func (s *Service) createChildren(
 			Name:        "Control",
 			Description: "Baseline feature variation",
 			Tags:        rsc.Tags{"generated"},
+			Value:       cVal,
 		},
 		variationmodel.RootArgs{
 			WorkspaceKey: a.WorkspaceKey,
@@ -55,6 +59,7 @@ func (s *Service) createChildren(
 			Name:        "Treatment",
 			Description: "Treatment feature variation",
 			Tags:        rsc.Tags{"generated"},
+			Value:       tVal,
 		},
 		variationmodel.RootArgs{
 			WorkspaceKey: a.WorkspaceKey,
@@ -70,7 +75,8 @@ func (s *Service) createChildren(
 		_, _err := s.TargetingRepo.Create(
 			ctx,
 			targetingmodel.Targeting{
-				Enabled: false,
+				Enabled:         false,
+				OffVariationKey: string(cVar.Key),
 				FallthroughVariations: []*model.Variation{
 					{
 						VariationKey: string(cVar.Key),
@@ -96,3 +102,35 @@ func (s *Service) createChildren(
 
 	return &e
 }
+
+// validateValueType checks if every variation of the flag can be served by the value type
+func (s *Service) validateValueType(
+	ctx context.Context,
+	t model.ValueType,
+	a flagmodel.ResourceArgs,
+) *res.Errors {
+	var e res.Errors
+
+	if !t.IsValid() {
+		e.Append(cons.ErrorInput, fmt.Sprintf("unsupported value type %q", t))
+		return &e
+	}
+
+	vars, err := s.VariationRepo.List(ctx, variationmodel.RootArgs{
+		WorkspaceKey: a.WorkspaceKey,
+		ProjectKey:   a.ProjectKey,
+		FlagKey:      a.FlagKey,
+	})
+	if err != nil {
+		e.Append(cons.ErrorInternal, err.Error())
+		return &e
+	}
+
+	for _, v := range vars {
+		if err := t.Validate(v.Value); err != nil {
+			e.Append(cons.ErrorInput, fmt.Sprintf("variation %s: %s", v.Key, err.Error()))
+		}
+	}
+
+	return &e
+}
@@ -40,12 +40,18 @@ func ApplyRoutes(senv *srvenv.Env, r *gin.RouterGroup) {
 		rootPath,
 		rsc.FlagKey,
 	)
+	killSwitchPath := httputil.AppendRoute(
+		resourcePath,
+		rsc.RouteKillSwitch,
+	)
 
 	routes.GET(rootPath, h.listAPIHandler)
 	routes.POST(rootPath, h.createAPIHandler)
 	routes.GET(resourcePath, h.getAPIHandler)
 	routes.PATCH(resourcePath, h.updateAPIHandler)
 	routes.DELETE(resourcePath, h.deleteAPIHandler)
+	routes.PUT(killSwitchPath, h.killAPIHandler)
+	routes.DELETE(killSwitchPath, h.reviveAPIHandler)
 	variationtransport.ApplyRoutes(senv, routes)
 }
 
@@ -202,3 +208,41 @@ func (h *APIHandler) deleteAPIHandler(ctx *gin.Context) {
 		e,
 	)
 }
+
+func (h *APIHandler) killAPIHandler(ctx *gin.Context) {
+	h.updateKillSwitch(ctx, true)
+}
+
+func (h *APIHandler) reviveAPIHandler(ctx *gin.Context) {
+	h.updateKillSwitch(ctx, false)
+}
+
+func (h *APIHandler) updateKillSwitch(ctx *gin.Context, killed bool) {
+	var e res.Errors
+
+	atk, err := httputil.ExtractATK(ctx)
+	if err != nil {
+		e.Append(cons.ErrorAuth, err.Error())
+	}
+
+	r, _err := h.FlagService.Kill(
+		atk,
+		killed,
+		flagmodel.ResourceArgs{
+			WorkspaceKey: httputil.GetParam(ctx, rsc.WorkspaceKey),
+			ProjectKey:   httputil.GetParam(ctx, rsc.ProjectKey),
+			FlagKey:      httputil.GetParam(ctx, rsc.FlagKey),
+		},
+	)
+	if !_err.IsEmpty() {
+		e.Extend(_err)
+	}
+
+	httputil.SendJSON(
+		ctx,
+		http.StatusOK,
+		r,
+		http.StatusInternalServerError,
+		e,
+	)
+}
@@ -0,0 +1,24 @@
+package model
+
+import rsc "core/internal/pkg/resource"
+
+// RootArgs arguments for selecting root resource
+type RootArgs struct {
+	WorkspaceKey rsc.Key
+	ProjectKey   rsc.Key
+}
+
+// ResourceArgs arguments for selecting specific resource
+type ResourceArgs struct {
+	WorkspaceKey rsc.Key
+	ProjectKey   rsc.Key
+	LayerKey     rsc.Key
+}
+
+// FlagArgs arguments for selecting a flag of a layer
+type FlagArgs struct {
+	WorkspaceKey rsc.Key
+	ProjectKey   rsc.Key
+	LayerKey     rsc.Key
+	FlagKey      rsc.Key
+}
@@ -0,0 +1,26 @@
+package model
+
+import rsc "core/internal/pkg/resource"
+
+// Layer splits the bucket space between experiments of a project, so an identity is part of
+// at most one of the layer's experiments. Identities in the holdout (i.e. the first Holdout
+// buckets) never enter any of the layer's experiments and are served control.
+type Layer struct {
+	ID          string          `json:"id" jsonapi:"primary,layer"`
+	Key         rsc.Key         `json:"key" jsonapi:"attr,key"`
+	Name        rsc.Name        `json:"name,omitempty" jsonapi:"attr,name,omitempty"`
+	Description rsc.Description `json:"description,omitempty" jsonapi:"attr,description,omitempty"`
+	Tags        rsc.Tags        `json:"tags,omitempty" jsonapi:"attr,tags,omitempty"`
+	Holdout     int32           `json:"holdout" jsonapi:"attr,holdout"`
+}
+
+// LayerFlag flag which is part of a layer, the flag's experiment owns
+// the range [Start, End) of the layer's bucket space
+type LayerFlag struct {
+	ID       string  `json:"id" jsonapi:"primary,layer_flag"`
+	FlagKey  rsc.Key `json:"flagKey" jsonapi:"attr,flagKey"`
+	LayerKey rsc.Key `json:"layerKey" jsonapi:"attr,layerKey"`
+	Weight   int32   `json:"weight" jsonapi:"attr,weight"`
+	Start    int32   `json:"start" jsonapi:"attr,start"`
+	End      int32   `json:"end" jsonapi:"attr,end"`
+}
@@ -0,0 +1,577 @@
+package repository
+
+import (
+	"context"
+	layermodel "core/internal/app/layer/model"
+	rsc "core/internal/pkg/resource"
+	"core/internal/pkg/srvenv"
+	"core/pkg/dbutil"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type Repo struct {
+	DB *pgxpool.Pool
+}
+
+func NewRepo(senv *srvenv.Env) *Repo {
+	return &Repo{
+		DB: senv.DB,
+	}
+}
+
+func (r *Repo) List(
+	ctx context.Context,
+	a layermodel.RootArgs,
+) ([]*layermodel.Layer, error) {
+	var o []*layermodel.Layer
+	sqlStatement := `
+SELECT
+  l.id,
+  l.key,
+  l.name,
+  l.description,
+  l.tags,
+  l.holdout
+FROM layer l
+LEFT JOIN project p
+  ON p.id = l.project_id
+LEFT JOIN workspace w
+  ON w.id = p.workspace_id
+WHERE w.key = $1
+  AND p.key = $2`
+	rows, err := r.DB.Query(
+		ctx,
+		sqlStatement,
+		a.WorkspaceKey,
+		a.ProjectKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var _o layermodel.Layer
+		if err = rows.Scan(
+			&_o.ID,
+			&_o.Key,
+			&_o.Name,
+			&_o.Description,
+			&_o.Tags,
+			&_o.Holdout,
+		); err != nil {
+			return nil, err
+		}
+		o = append(o, &_o)
+	}
+	return o, rows.Err()
+}
+
+func (r *Repo) Create(
+	ctx context.Context,
+	i layermodel.Layer,
+	a layermodel.RootArgs,
+) (*layermodel.Layer, error) {
+	var o layermodel.Layer
+	sqlStatement := `
+INSERT INTO
+  layer(
+    key,
+    name,
+    description,
+    tags,
+    holdout,
+    project_id
+  )
+VALUES
+  (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    (
+      SELECT p.id
+      FROM project p
+      LEFT JOIN workspace w
+        ON w.id = p.workspace_id
+      WHERE w.key = $6
+        AND p.key = $7
+    )
+  )
+RETURNING
+  id,
+  key,
+  name,
+  description,
+  tags,
+  holdout;`
+	err := dbutil.ParseError(
+		rsc.Layer.String(),
+		layermodel.ResourceArgs{
+			WorkspaceKey: a.WorkspaceKey,
+			ProjectKey:   a.ProjectKey,
+			LayerKey:     i.Key,
+		},
+		r.DB.QueryRow(
+			ctx,
+			sqlStatement,
+			i.Key,
+			i.Name,
+			i.Description,
+			i.Tags,
+			i.Holdout,
+			a.WorkspaceKey,
+			a.ProjectKey,
+		).Scan(
+			&o.ID,
+			&o.Key,
+			&o.Name,
+			&o.Description,
+			&o.Tags,
+			&o.Holdout,
+		),
+	)
+	return &o, err
+}
+
+func (r *Repo) Get(
+	ctx context.Context,
+	a layermodel.ResourceArgs,
+) (*layermodel.Layer, error) {
+	var o layermodel.Layer
+	sqlStatement := `
+SELECT
+  l.id,
+  l.key,
+  l.name,
+  l.description,
+  l.tags,
+  l.holdout
+FROM layer l
+LEFT JOIN project p
+  ON p.id = l.project_id
+LEFT JOIN workspace w
+  ON w.id = p.workspace_id
+WHERE w.key = $1
+  AND p.key = $2
+  AND l.key = $3`
+	err := dbutil.ParseError(
+		rsc.Layer.String(),
+		a,
+		r.DB.QueryRow(
+			ctx,
+			sqlStatement,
+			a.WorkspaceKey,
+			a.ProjectKey,
+			a.LayerKey,
+		).Scan(
+			&o.ID,
+			&o.Key,
+			&o.Name,
+			&o.Description,
+			&o.Tags,
+			&o.Holdout,
+		),
+	)
+	return &o, err
+}
+
+func (r *Repo) Update(
+	ctx context.Context,
+	i layermodel.Layer,
+	a layermodel.ResourceArgs,
+) (*layermodel.Layer, error) {
+	sqlStatement := `
+UPDATE
+  layer
+SET
+  key = $2,
+  name = $3,
+  description = $4,
+  tags = $5,
+  holdout = $6
+WHERE id = $1`
+	if _, err := r.DB.Exec(
+		ctx,
+		sqlStatement,
+		i.ID,
+		i.Key,
+		i.Name,
+		i.Description,
+		i.Tags,
+		i.Holdout,
+	); err != nil {
+		return &i, dbutil.ParseError(
+			rsc.Layer.String(),
+			a,
+			err,
+		)
+	}
+	return &i, nil
+}
+
+func (r *Repo) Delete(
+	ctx context.Context,
+	a layermodel.ResourceArgs,
+) error {
+	sqlStatement := `
+DELETE FROM layer
+WHERE key = $3
+  AND project_id = (
+    SELECT p.id
+    FROM project p
+    LEFT JOIN workspace w
+      ON w.id = p.workspace_id
+    WHERE w.key = $1
+      AND p.key = $2
+  )`
+	if _, err := r.DB.Exec(
+		ctx,
+		sqlStatement,
+		a.WorkspaceKey,
+		a.ProjectKey,
+		a.LayerKey,
+	); err != nil {
+		return dbutil.ParseError(
+			rsc.Layer.String(),
+			a,
+			err,
+		)
+	}
+	return nil
+}
+
+// ListFlags lists the flags of the layer ordered by their range of the bucket space
+func (r *Repo) ListFlags(
+	ctx context.Context,
+	a layermodel.ResourceArgs,
+) ([]*layermodel.LayerFlag, error) {
+	var o []*layermodel.LayerFlag
+	err := r.DB.BeginFunc(ctx, func(tx pgx.Tx) error {
+		var err error
+		o, err = r.listFlags(ctx, tx, a)
+		return err
+	})
+	return o, err
+}
+
+func (r *Repo) listFlags(
+	ctx context.Context,
+	tx pgx.Tx,
+	a layermodel.ResourceArgs,
+) ([]*layermodel.LayerFlag, error) {
+	var o []*layermodel.LayerFlag
+	sqlStatement := `
+SELECT
+  f.id,
+  f.key,
+  l.key,
+  lf.bucket_start,
+  lf.bucket_end
+FROM layer_flag lf
+LEFT JOIN layer l
+  ON l.id = lf.layer_id
+LEFT JOIN flag f
+  ON f.id = lf.flag_id
+LEFT JOIN project p
+  ON p.id = l.project_id
+LEFT JOIN workspace w
+  ON w.id = p.workspace_id
+WHERE w.key = $1
+  AND p.key = $2
+  AND l.key = $3
+ORDER BY lf.bucket_start`
+	rows, err := tx.Query(
+		ctx,
+		sqlStatement,
+		a.WorkspaceKey,
+		a.ProjectKey,
+		a.LayerKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var _o layermodel.LayerFlag
+		if err = rows.Scan(
+			&_o.ID,
+			&_o.FlagKey,
+			&_o.LayerKey,
+			&_o.Start,
+			&_o.End,
+		); err != nil {
+			return nil, err
+		}
+		_o.Weight = _o.End - _o.Start
+		o = append(o, &_o)
+	}
+	return o, rows.Err()
+}
+
+// getFlag gets the layer membership of a flag (in any layer of the project),
+// nil is returned if the flag isn't part of a layer
+func (r *Repo) getFlag(
+	ctx context.Context,
+	tx pgx.Tx,
+	a layermodel.FlagArgs,
+) (*layermodel.LayerFlag, error) {
+	var o layermodel.LayerFlag
+	sqlStatement := `
+SELECT
+  f.id,
+  f.key,
+  l.key,
+  lf.bucket_start,
+  lf.bucket_end
+FROM layer_flag lf
+LEFT JOIN layer l
+  ON l.id = lf.layer_id
+LEFT JOIN flag f
+  ON f.id = lf.flag_id
+LEFT JOIN project p
+  ON p.id = f.project_id
+LEFT JOIN workspace w
+  ON w.id = p.workspace_id
+WHERE w.key = $1
+  AND p.key = $2
+  AND f.key = $3`
+	err := tx.QueryRow(
+		ctx,
+		sqlStatement,
+		a.WorkspaceKey,
+		a.ProjectKey,
+		a.FlagKey,
+	).Scan(
+		&o.ID,
+		&o.FlagKey,
+		&o.LayerKey,
+		&o.Start,
+		&o.End,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, dbutil.ParseError(rsc.LayerFlag.String(), a, err)
+	}
+	o.Weight = o.End - o.Start
+	return &o, nil
+}
+
+// AllocateFunc derives the flag's range of the layer's bucket space, given the layer, the
+// flag's current membership (nil if it isn't part of a layer) & the flags of the layer
+type AllocateFunc func(
+	layer *layermodel.Layer,
+	current *layermodel.LayerFlag,
+	flags []*layermodel.LayerFlag,
+) (*layermodel.LayerFlag, error)
+
+// AllocateFlag adds the flag to the layer (or updates its range if it's already part of the
+// layer) in a single transaction. The layer is locked while the range is allocated, so
+// concurrent allocations never hand out overlapping ranges of the layer's bucket space.
+func (r *Repo) AllocateFlag(
+	ctx context.Context,
+	a layermodel.FlagArgs,
+	allocate AllocateFunc,
+) (*layermodel.LayerFlag, error) {
+	var o *layermodel.LayerFlag
+	err := r.DB.BeginFunc(ctx, func(tx pgx.Tx) error {
+		ra := layermodel.ResourceArgs{
+			WorkspaceKey: a.WorkspaceKey,
+			ProjectKey:   a.ProjectKey,
+			LayerKey:     a.LayerKey,
+		}
+		layer, err := r.getForUpdate(ctx, tx, ra)
+		if err != nil {
+			return err
+		}
+		current, err := r.getFlag(ctx, tx, a)
+		if err != nil {
+			return err
+		}
+		flags, err := r.listFlags(ctx, tx, ra)
+		if err != nil {
+			return err
+		}
+
+		i, err := allocate(layer, current, flags)
+		if err != nil {
+			return err
+		}
+		o, err = r.saveFlag(ctx, tx, *i, a)
+		return err
+	})
+	return o, err
+}
+
+// getForUpdate gets the layer, locking its row until the transaction ends
+func (r *Repo) getForUpdate(
+	ctx context.Context,
+	tx pgx.Tx,
+	a layermodel.ResourceArgs,
+) (*layermodel.Layer, error) {
+	var o layermodel.Layer
+	sqlStatement := `
+SELECT
+  l.id,
+  l.key,
+  l.name,
+  l.description,
+  l.tags,
+  l.holdout
+FROM layer l
+LEFT JOIN project p
+  ON p.id = l.project_id
+LEFT JOIN workspace w
+  ON w.id = p.workspace_id
+WHERE w.key = $1
+  AND p.key = $2
+  AND l.key = $3
+FOR UPDATE OF l`
+	err := dbutil.ParseError(
+		rsc.Layer.String(),
+		a,
+		tx.QueryRow(
+			ctx,
+			sqlStatement,
+			a.WorkspaceKey,
+			a.ProjectKey,
+			a.LayerKey,
+		).Scan(
+			&o.ID,
+			&o.Key,
+			&o.Name,
+			&o.Description,
+			&o.Tags,
+			&o.Holdout,
+		),
+	)
+	return &o, err
+}
+
+// saveFlag adds the flag to the layer (or updates its range if it's already part of the layer)
+func (r *Repo) saveFlag(
+	ctx context.Context,
+	tx pgx.Tx,
+	i layermodel.LayerFlag,
+	a layermodel.FlagArgs,
+) (*layermodel.LayerFlag, error) {
+	o := layermodel.LayerFlag{
+		FlagKey:  a.FlagKey,
+		LayerKey: a.LayerKey,
+	}
+	sqlStatement := `
+INSERT INTO
+  layer_flag(
+    layer_id,
+    flag_id,
+    bucket_start,
+    bucket_end
+  )
+VALUES
+  (
+    (
+      SELECT l.id
+      FROM layer l
+      LEFT JOIN project p
+        ON p.id = l.project_id
+      LEFT JOIN workspace w
+        ON w.id = p.workspace_id
+      WHERE w.key = $1
+        AND p.key = $2
+        AND l.key = $3
+    ),
+    (
+      SELECT f.id
+      FROM flag f
+      LEFT JOIN project p
+        ON p.id = f.project_id
+      LEFT JOIN workspace w
+        ON w.id = p.workspace_id
+      WHERE w.key = $1
+        AND p.key = $2
+        AND f.key = $4
+    ),
+    $5,
+    $6
+  )
+ON CONFLICT (flag_id) DO UPDATE
+SET
+  bucket_start = EXCLUDED.bucket_start,
+  bucket_end = EXCLUDED.bucket_end
+RETURNING
+  flag_id,
+  bucket_start,
+  bucket_end;`
+	err := dbutil.ParseError(
+		rsc.LayerFlag.String(),
+		a,
+		tx.QueryRow(
+			ctx,
+			sqlStatement,
+			a.WorkspaceKey,
+			a.ProjectKey,
+			a.LayerKey,
+			a.FlagKey,
+			i.Start,
+			i.End,
+		).Scan(
+			&o.ID,
+			&o.Start,
+			&o.End,
+		),
+	)
+	o.Weight = o.End - o.Start
+	return &o, err
+}
+
+// DeleteFlag removes the flag from the layer, freeing its range of the bucket space
+func (r *Repo) DeleteFlag(
+	ctx context.Context,
+	a layermodel.FlagArgs,
+) error {
+	sqlStatement := `
+DELETE FROM layer_flag
+WHERE layer_id = (
+    SELECT l.id
+    FROM layer l
+    LEFT JOIN project p
+      ON p.id = l.project_id
+    LEFT JOIN workspace w
+      ON w.id = p.workspace_id
+    WHERE w.key = $1
+      AND p.key = $2
+      AND l.key = $3
+  )
+  AND flag_id = (
+    SELECT f.id
+    FROM flag f
+    LEFT JOIN project p
+      ON p.id = f.project_id
+    LEFT JOIN workspace w
+      ON w.id = p.workspace_id
+    WHERE w.key = $1
+      AND p.key = $2
+      AND f.key = $4
+  )`
+	if _, err := r.DB.Exec(
+		ctx,
+		sqlStatement,
+		a.WorkspaceKey,
+		a.ProjectKey,
+		a.LayerKey,
+		a.FlagKey,
+	); err != nil {
+		return dbutil.ParseError(
+			rsc.LayerFlag.String(),
+			a,
+			err,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	flagmodel "core/internal/app/flag/model"
+	flagrepo "core/internal/app/flag/repository"
+	layermodel "core/internal/app/layer/model"
+	layerrepo "core/internal/app/layer/repository"
+	"core/internal/pkg/authutil"
+	cons "core/internal/pkg/constants"
+	rsc "core/internal/pkg/resource"
+	"core/internal/pkg/srvenv"
+	"core/pkg/patch"
+	res "core/pkg/response"
+	"fmt"
+)
+
+type Service struct {
+	Senv      *srvenv.Env
+	LayerRepo *layerrepo.Repo
+	FlagRepo  *flagrepo.Repo
+}
+
+func NewService(senv *srvenv.Env) *Service {
+	return &Service{
+		Senv:      senv,
+		LayerRepo: layerrepo.NewRepo(senv),
+		FlagRepo:  flagrepo.NewRepo(senv),
+	}
+}
+
+// List returns a list of resource instances
+// (*) atk: access_type <= service
+func (s *Service) List(
+	atk rsc.Token,
+	a layermodel.RootArgs,
+) ([]*layermodel.Layer, *res.Errors) {
+	var e res.Errors
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Verify access is authorized
+	_, err := authutil.Authorize(s.Senv, atk)
+	if err != nil {
+		e.Append(cons.ErrorAuth, err.Error())
+		return nil, &e
+	}
+
+	r, err := s.LayerRepo.List(ctx, a)
+	if err != nil {
+		e.Append(cons.ErrorNotFound, err.Error())
+	}
+
+	return r, &e
+}
+
+// Create creates a new resource instance given the resource instance
+// (*) atk: access_type <= admin
+func (s *Service) Create(
+	atk rsc.Token,
+	i layermodel.Layer,
+	a layermodel.RootArgs,
+) (*layermodel.Layer, *res.Errors) {
+	var e res.Errors
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Verify access is authorized
+	_, err := authutil.Authorize(s.Senv, atk)
+	if err != nil {
+		e.Append(cons.ErrorAuth, err.Error())
+		return nil, &e
+	}
+
+	if err := validateHoldout(i.Holdout, nil); !err.IsEmpty() {
+		e.Extend(err)
+		return nil, &e
+	}
+
+	r, err := s.LayerRepo.Create(ctx, i, a)
+	if err != nil {
+		e.Append(cons.ErrorInput, err.Error())
+	}
+
+	return r, &e
+}
+
+// Get gets a resource instance given an atk & key
+// (*) atk: access_type <= service
+func (s *Service) Get(
+	atk rsc.Token,
+	a layermodel.ResourceArgs,
+) (*layermodel.Layer, *res.Errors) {
+	var e res.Errors
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	o, err := s.LayerRepo.Get(ctx, a)
+	if err != nil {
+		e.Append(cons.ErrorNotFound, err.Error())
+	}
+
+	return o, &e
+}
+
+// Update updates resource instance given an atk, key & patch object,
+// the holdout can't overlap the range of any of the layer's flags
+// (*) atk: access_type <= user
+func (s *Service) Update(
+	atk rsc.Token,
+	patchDoc patch.Patch,
+	a layermodel.ResourceArgs,
+) (*layermodel.Layer, *res.Errors) {
+	var o layermodel.Layer
+	var e res.Errors
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, err := s.LayerRepo.Get(ctx, a)
+	if err != nil {
+		e.Append(cons.ErrorNotFound, err.Error())
+		return nil, &e
+	}
+
+	if err := patch.Transform(r, patchDoc, &o); err != nil {
+		e.Append(cons.ErrorInternal, err.Error())
+		return r, &e
+	}
+
+	if o.Holdout != r.Holdout {
+		flags, err := s.LayerRepo.ListFlags(ctx, a)
+		if err != nil {
+			e.Append(cons.ErrorInternal, err.Error())
+			return r, &e
+		}
+		if err := validateHoldout(o.Holdout, flags); !err.IsEmpty() {
+			e.Extend(err)
+			return r, &e
+		}
+	}
+
+	r, err = s.LayerRepo.Update(ctx, o, a)
+	if err != nil {
+		e.Append(cons.ErrorInternal, err.Error())
+	}
+
+	return r, &e
+}
+
+// Delete deletes a resource instance given an atk & key,
+// the layer's flags are no longer mutually exclusive
+// (*) atk: access_type <= admin
+func (s *Service) Delete(
+	atk rsc.Token,
+	a layermodel.ResourceArgs,
+) *res.Errors {
+	var e res.Errors
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := s.LayerRepo.Delete(ctx, a); err != nil {
+		e.Append(cons.ErrorInternal, err.Error())
+	}
+
+	return &e
+}
+
+// ListFlags returns the flags of a layer along with their range of the layer's bucket space
+// (*) atk: access_type <= service
+func (s *Service) ListFlags(
+	atk rsc.Token,
+	a layermodel.ResourceArgs,
+) ([]*layermodel.LayerFlag, *res.Errors) {
+	var e res.Errors
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, err := s.LayerRepo.ListFlags(ctx, a)
+	if err != nil {
+		e.Append(cons.ErrorNotFound, err.Error())
+	}
+
+	return r, &e
+}
+
+// AddFlag adds a flag to the layer, allocating a range of the layer's bucket space that fits
+// the weight. Changing the weight of a flag keeps its range in place if the range can grow,
+// otherwise the flag moves to the first free range (i.e. identities may change experiment).
+// (*) atk: access_type <= user
+func (s *Service) AddFlag(
+	atk rsc.Token,
+	i layermodel.LayerFlag,
+	a layermodel.FlagArgs,
+) (*layermodel.LayerFlag, *res.Errors) {
+	var e res.Errors
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Verify access is authorized
+	_, err := authutil.Authorize(s.Senv, atk)
+	if err != nil {
+		e.Append(cons.ErrorAuth, err.Error())
+		return nil, &e
+	}
+
+	if err := validateWeight(i.Weight); !err.IsEmpty() {
+		e.Extend(err)
+		return nil, &e
+	}
+
+	if _, err := s.LayerRepo.Get(ctx, layermodel.ResourceArgs{
+		WorkspaceKey: a.WorkspaceKey,
+		ProjectKey:   a.ProjectKey,
+		LayerKey:     a.LayerKey,
+	}); err != nil {
+		e.Append(cons.ErrorNotFound, err.Error())
+		return nil, &e
+	}
+	if _, err := s.FlagRepo.Get(ctx, flagmodel.ResourceArgs{
+		WorkspaceKey: a.WorkspaceKey,
+		ProjectKey:   a.ProjectKey,
+		FlagKey:      a.FlagKey,
+	}); err != nil {
+		e.Append(cons.ErrorNotFound, err.Error())
+		return nil, &e
+	}
+
+	// the range is allocated while the layer is locked, so concurrent
+	// requests never allocate overlapping ranges
+	r, err := s.LayerRepo.AllocateFlag(ctx, a, func(
+		layer *layermodel.Layer,
+		current *layermodel.LayerFlag,
+		flags []*layermodel.LayerFlag,
+	) (*layermodel.LayerFlag, error) {
+		// a flag is part of at most one layer
+		if current != nil && current.LayerKey != a.LayerKey {
+			e.Append(cons.ErrorInput, fmt.Sprintf(
+				"flag %s is already part of layer %s",
+				a.FlagKey,
+				current.LayerKey,
+			))
+			return nil, errAllocate
+		}
+
+		start, end, ok := allocateRange(flags, layer.Holdout, current, i.Weight)
+		if !ok {
+			e.Append(cons.ErrorInput, fmt.Sprintf(
+				"layer %s doesn't have a free range of weight %d",
+				a.LayerKey,
+				i.Weight,
+			))
+			return nil, errAllocate
+		}
+		i.Start, i.End = start, end
+		return &i, nil
+	})
+	if err != nil && err != errAllocate {
+		e.Append(cons.ErrorInternal, err.Error())
+	}
+	if !e.IsEmpty() {
+		return nil, &e
+	}
+
+	return r, &e
+}
+
+// RemoveFlag removes a flag from the layer, freeing its range of the layer's bucket space
+// (*) atk: access_type <= user
+func (s *Service) RemoveFlag(
+	atk rsc.Token,
+	a layermodel.FlagArgs,
+) *res.Errors {
+	var e res.Errors
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Verify access is authorized
+	_, err := authutil.Authorize(s.Senv, atk)
+	if err != nil {
+		e.Append(cons.ErrorAuth, err.Error())
+		return &e
+	}
+
+	if err := s.LayerRepo.DeleteFlag(ctx, a); err != nil {
+		e.Append(cons.ErrorInternal, err.Error())
+	}
+
+	return &e
+}
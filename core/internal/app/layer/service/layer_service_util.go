@@ -0,0 +1,92 @@
+package service
+
+import (
+	layermodel "core/internal/app/layer/model"
+	cons "core/internal/pkg/constants"
+	"core/pkg/evaluator"
+	res "core/pkg/response"
+	"errors"
+	"fmt"
+)
+
+const bucketSize = int32(evaluator.BucketSize)
+
+// errAllocate the flag's range couldn't be allocated (the reason is appended to the errors)
+var errAllocate = errors.New("unable to allocate range")
+
+// validateHoldout checks the holdout is a valid weight which doesn't overlap
+// the range of any of the layer's flags (flags are ordered by their range)
+func validateHoldout(holdout int32, flags []*layermodel.LayerFlag) *res.Errors {
+	var e res.Errors
+
+	if holdout < 0 || holdout > bucketSize {
+		e.Append(cons.ErrorInput, fmt.Sprintf("holdout must be between 0 and %d", bucketSize))
+		return &e
+	}
+	if len(flags) > 0 && holdout > flags[0].Start {
+		e.Append(cons.ErrorInput, fmt.Sprintf(
+			"holdout overlaps the range of flag %s (starting at %d)",
+			flags[0].FlagKey,
+			flags[0].Start,
+		))
+	}
+
+	return &e
+}
+
+// validateWeight checks the flag's share of the layer's bucket space
+func validateWeight(weight int32) *res.Errors {
+	var e res.Errors
+
+	if weight <= 0 || weight > bucketSize {
+		e.Append(cons.ErrorInput, fmt.Sprintf("weight must be between 1 and %d", bucketSize))
+	}
+
+	return &e
+}
+
+// allocateRange finds a range of the layer's bucket space (after the holdout) that fits the
+// weight. The current range of the flag is kept if it can grow (or shrink) in place, otherwise
+// the first free range is used. Flags are ordered by their range.
+func allocateRange(
+	flags []*layermodel.LayerFlag,
+	holdout int32,
+	current *layermodel.LayerFlag,
+	weight int32,
+) (int32, int32, bool) {
+	others := make([]*layermodel.LayerFlag, 0, len(flags))
+	for _, f := range flags {
+		if current == nil || f.FlagKey != current.FlagKey {
+			others = append(others, f)
+		}
+	}
+
+	fits := func(start int32) bool {
+		end := start + weight
+		if start < holdout || end > bucketSize {
+			return false
+		}
+		for _, f := range others {
+			if start < f.End && f.Start < end {
+				return false
+			}
+		}
+		return true
+	}
+
+	if current != nil && fits(current.Start) {
+		return current.Start, current.Start + weight, true
+	}
+
+	// free ranges start where the holdout or another flag's range ends
+	if fits(holdout) {
+		return holdout, holdout + weight, true
+	}
+	for _, f := range others {
+		if fits(f.End) {
+			return f.End, f.End + weight, true
+		}
+	}
+
+	return 0, 0, false
+}
@@ -0,0 +1,307 @@
+package transport
+
+import (
+	layermodel "core/internal/app/layer/model"
+	layerservice "core/internal/app/layer/service"
+	cons "core/internal/pkg/constants"
+	"core/internal/pkg/httputil"
+	rsc "core/internal/pkg/resource"
+	"core/internal/pkg/srvenv"
+	"core/pkg/patch"
+	res "core/pkg/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIHandler API handler context
+type APIHandler struct {
+	Senv         *srvenv.Env
+	LayerService *layerservice.Service
+}
+
+func newAPIHandler(senv *srvenv.Env) *APIHandler {
+	return &APIHandler{
+		Senv:         senv,
+		LayerService: layerservice.NewService(senv),
+	}
+}
+
+// ApplyRoutes layer route handlers
+func ApplyRoutes(senv *srvenv.Env, r *gin.RouterGroup) {
+	h := newAPIHandler(senv)
+	routes := r.Group(rsc.RouteLayer)
+	rootPath := httputil.BuildPath(
+		rsc.WorkspaceKey,
+		rsc.ProjectKey,
+	)
+	resourcePath := httputil.AppendPath(
+		rootPath,
+		rsc.LayerKey,
+	)
+	flagsPath := httputil.AppendRoute(
+		resourcePath,
+		rsc.RouteFlag,
+	)
+	flagPath := httputil.AppendPath(
+		flagsPath,
+		rsc.FlagKey,
+	)
+
+	routes.GET(rootPath, h.listAPIHandler)
+	routes.POST(rootPath, h.createAPIHandler)
+	routes.GET(resourcePath, h.getAPIHandler)
+	routes.PATCH(resourcePath, h.updateAPIHandler)
+	routes.DELETE(resourcePath, h.deleteAPIHandler)
+	routes.GET(flagsPath, h.listFlagsAPIHandler)
+	routes.PUT(flagPath, h.addFlagAPIHandler)
+	routes.DELETE(flagPath, h.removeFlagAPIHandler)
+}
+
+func (h *APIHandler) listAPIHandler(ctx *gin.Context) {
+	var e res.Errors
+
+	atk, err := httputil.ExtractATK(ctx)
+	if err != nil {
+		e.Append(cons.ErrorAuth, err.Error())
+	}
+
+	r, _err := h.LayerService.List(
+		atk,
+		layermodel.RootArgs{
+			WorkspaceKey: httputil.GetParam(ctx, rsc.WorkspaceKey),
+			ProjectKey:   httputil.GetParam(ctx, rsc.ProjectKey),
+		},
+	)
+	if !_err.IsEmpty() {
+		e.Extend(_err)
+	}
+
+	httputil.SendJSON(
+		ctx,
+		http.StatusOK,
+		r,
+		http.StatusInternalServerError,
+		e,
+	)
+}
+
+func (h *APIHandler) createAPIHandler(ctx *gin.Context) {
+	var e res.Errors
+
+	atk, err := httputil.ExtractATK(ctx)
+	if err != nil {
+		e.Append(cons.ErrorAuth, err.Error())
+	}
+
+	var i layermodel.Layer
+	if err := ctx.BindJSON(&i); err != nil {
+		e.Append(cons.ErrorInternal, err.Error())
+	}
+
+	r, _err := h.LayerService.Create(
+		atk,
+		i,
+		layermodel.RootArgs{
+			WorkspaceKey: httputil.GetParam(ctx, rsc.WorkspaceKey),
+			ProjectKey:   httputil.GetParam(ctx, rsc.ProjectKey),
+		},
+	)
+	if !_err.IsEmpty() {
+		e.Extend(_err)
+	}
+
+	httputil.SendJSON(
+		ctx,
+		http.StatusCreated,
+		r,
+		http.StatusInternalServerError,
+		e,
+	)
+}
+
+func (h *APIHandler) getAPIHandler(ctx *gin.Context) {
+	var e res.Errors
+
+	atk, err := httputil.ExtractATK(ctx)
+	if err != nil {
+		e.Append(cons.ErrorAuth, err.Error())
+	}
+
+	r, _err := h.LayerService.Get(
+		atk,
+		layermodel.ResourceArgs{
+			WorkspaceKey: httputil.GetParam(ctx, rsc.WorkspaceKey),
+			ProjectKey:   httputil.GetParam(ctx, rsc.ProjectKey),
+			LayerKey:     httputil.GetParam(ctx, rsc.LayerKey),
+		},
+	)
+	if !_err.IsEmpty() {
+		e.Extend(_err)
+	}
+
+	httputil.SendJSON(
+		ctx,
+		http.StatusOK,
+		r,
+		http.StatusInternalServerError,
+		e,
+	)
+}
+
+func (h *APIHandler) updateAPIHandler(ctx *gin.Context) {
+	var e res.Errors
+	var i patch.Patch
+
+	atk, err := httputil.ExtractATK(ctx)
+	if err != nil {
+		e.Append(cons.ErrorAuth, err.Error())
+	}
+
+	if err := ctx.BindJSON(&i); err != nil {
+		e.Append(cons.ErrorInternal, err.Error())
+	}
+
+	r, _err := h.LayerService.Update(
+		atk,
+		i,
+		layermodel.ResourceArgs{
+			WorkspaceKey: httputil.GetParam(ctx, rsc.WorkspaceKey),
+			ProjectKey:   httputil.GetParam(ctx, rsc.ProjectKey),
+			LayerKey:     httputil.GetParam(ctx, rsc.LayerKey),
+		},
+	)
+	if !_err.IsEmpty() {
+		e.Extend(_err)
+	}
+
+	httputil.SendJSON(
+		ctx,
+		http.StatusOK,
+		r,
+		http.StatusInternalServerError,
+		e,
+	)
+}
+
+func (h *APIHandler) deleteAPIHandler(ctx *gin.Context) {
+	var e res.Errors
+
+	atk, err := httputil.ExtractATK(ctx)
+	if err != nil {
+		e.Append(cons.ErrorAuth, err.Error())
+	}
+
+	if err := h.LayerService.Delete(
+		atk,
+		layermodel.ResourceArgs{
+			WorkspaceKey: httputil.GetParam(ctx, rsc.WorkspaceKey),
+			ProjectKey:   httputil.GetParam(ctx, rsc.ProjectKey),
+			LayerKey:     httputil.GetParam(ctx, rsc.LayerKey),
+		},
+	); !err.IsEmpty() {
+		e.Extend(err)
+	}
+
+	httputil.SendJSON(
+		ctx,
+		http.StatusNoContent,
+		&res.Success{},
+		http.StatusInternalServerError,
+		e,
+	)
+}
+
+func (h *APIHandler) listFlagsAPIHandler(ctx *gin.Context) {
+	var e res.Errors
+
+	atk, err := httputil.ExtractATK(ctx)
+	if err != nil {
+		e.Append(cons.ErrorAuth, err.Error())
+	}
+
+	r, _err := h.LayerService.ListFlags(
+		atk,
+		layermodel.ResourceArgs{
+			WorkspaceKey: httputil.GetParam(ctx, rsc.WorkspaceKey),
+			ProjectKey:   httputil.GetParam(ctx, rsc.ProjectKey),
+			LayerKey:     httputil.GetParam(ctx, rsc.LayerKey),
+		},
+	)
+	if !_err.IsEmpty() {
+		e.Extend(_err)
+	}
+
+	httputil.SendJSON(
+		ctx,
+		http.StatusOK,
+		r,
+		http.StatusInternalServerError,
+		e,
+	)
+}
+
+func (h *APIHandler) addFlagAPIHandler(ctx *gin.Context) {
+	var e res.Errors
+
+	atk, err := httputil.ExtractATK(ctx)
+	if err != nil {
+		e.Append(cons.ErrorAuth, err.Error())
+	}
+
+	var i layermodel.LayerFlag
+	if err := ctx.BindJSON(&i); err != nil {
+		e.Append(cons.ErrorInternal, err.Error())
+	}
+
+	r, _err := h.LayerService.AddFlag(
+		atk,
+		i,
+		layermodel.FlagArgs{
+			WorkspaceKey: httputil.GetParam(ctx, rsc.WorkspaceKey),
+			ProjectKey:   httputil.GetParam(ctx, rsc.ProjectKey),
+			LayerKey:     httputil.GetParam(ctx, rsc.LayerKey),
+			FlagKey:      httputil.GetParam(ctx, rsc.FlagKey),
+		},
+	)
+	if !_err.IsEmpty() {
+		e.Extend(_err)
+	}
+
+	httputil.SendJSON(
+		ctx,
+		http.StatusOK,
+		r,
+		http.StatusInternalServerError,
+		e,
+	)
+}
+
+func (h *APIHandler) removeFlagAPIHandler(ctx *gin.Context) {
+	var e res.Errors
+
+	atk, err := httputil.ExtractATK(ctx)
+	if err != nil {
+		e.Append(cons.ErrorAuth, err.Error())
+	}
+
+	if err := h.LayerService.RemoveFlag(
+		atk,
+		layermodel.FlagArgs{
+			WorkspaceKey: httputil.GetParam(ctx, rsc.WorkspaceKey),
+			ProjectKey:   httputil.GetParam(ctx, rsc.ProjectKey),
+			LayerKey:     httputil.GetParam(ctx, rsc.LayerKey),
+			FlagKey:      httputil.GetParam(ctx, rsc.FlagKey),
+		},
+	); !err.IsEmpty() {
+		e.Extend(err)
+	}
+
+	httputil.SendJSON(
+		ctx,
+		http.StatusNoContent,
+		&res.Success{},
+		http.StatusInternalServerError,
+		e,
+	)
+}
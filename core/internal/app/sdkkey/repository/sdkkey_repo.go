@@ -9,7 +9,6 @@ import (
 	"errors"
 
 	"github.com/jackc/pgx/v4/pgxpool"
-	"github.com/lib/pq"
 )
 
 type Repo struct {
@@ -134,7 +133,7 @@ RETURNING
 			i.ExpiresAt,
 			i.Name,
 			i.Description,
-			pq.Array(i.Tags),
+			i.Tags,
 			a.WorkspaceKey,
 			a.ProjectKey,
 			a.EnvironmentKey,
@@ -212,7 +211,7 @@ WHERE id = $1`
 		i.ExpiresAt,
 		i.Name,
 		i.Description,
-		pq.Array(i.Tags),
+		i.Tags,
 	); err != nil {
 		return &i, dbutil.ParseError(
 			rsc.SDKKey.String(),
@@ -8,7 +8,6 @@ import (
 	"core/pkg/dbutil"
 
 	"github.com/jackc/pgx/v4/pgxpool"
-	"github.com/lib/pq"
 )
 
 type Repo struct {
@@ -114,7 +113,7 @@ RETURNING
 			i.Key,
 			i.Name,
 			i.Description,
-			pq.Array(i.Tags),
+			i.Tags,
 			a.WorkspaceKey,
 			a.ProjectKey,
 		).Scan(
@@ -188,7 +187,7 @@ WHERE id = $1`
 		i.Key,
 		i.Name,
 		i.Description,
-		pq.Array(i.Tags),
+		i.Tags,
 	); err != nil {
 		return &i, dbutil.ParseError(
 			rsc.Segment.String(),
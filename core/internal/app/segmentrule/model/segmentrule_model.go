@@ -7,10 +7,13 @@ import (
 
 // SegmentRule represents a condition used to filter identities for a particular segment
 type SegmentRule struct {
-	ID         string         `json:"id" jsonapi:"primary,segment_rule"`
-	Key        rsc.Key        `json:"key" jsonapi:"attr,key"`
-	TraitKey   string         `json:"traitKey" jsonapi:"attr,traitKey"`
-	TraitValue string         `json:"traitValue" jsonapi:"attr,traitValue"`
-	Operator   model.Operator `json:"operator" jsonapi:"attr,operator"`
-	Negate     bool           `json:"negate" jsonapi:"attr,negate"`
+	ID          string          `json:"id" jsonapi:"primary,segment_rule"`
+	Key         rsc.Key         `json:"key" jsonapi:"attr,key"`
+	TraitKey    string          `json:"traitKey" jsonapi:"attr,traitKey"`
+	TraitValue  string          `json:"traitValue" jsonapi:"attr,traitValue"`
+	TraitValues model.ValueSet  `json:"traitValues,omitempty" jsonapi:"attr,traitValues,omitempty"`
+	Operator    model.Operator  `json:"operator" jsonapi:"attr,operator"`
+	Negate      bool            `json:"negate" jsonapi:"attr,negate"`
+	Match       model.MatchType `json:"match,omitempty" jsonapi:"attr,match,omitempty"`
+	Clauses     []*model.Clause `json:"clauses,omitempty" jsonapi:"attr,clauses,omitempty"`
 }
@@ -6,7 +6,9 @@ import (
 	rsc "core/internal/pkg/resource"
 	"core/internal/pkg/srvenv"
 	"core/pkg/dbutil"
+	"core/pkg/model"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -31,8 +33,10 @@ SELECT
   sr.key,
   sr.trait_key,
   sr.trait_value,
-  sr.operator,
-  sr.negate
+  sr.trait_values,
+  COALESCE(sr.operator::text, ''),
+  sr.negate,
+  sr.match_type
 FROM segment_rule sr
 LEFT JOIN segment s
   ON s.id = sr.segment_id
@@ -59,27 +63,54 @@ WHERE w.key = $1
 	}
 	for rows.Next() {
 		var _o segmentrulemodel.SegmentRule
+		var traitValues []string
 		if err = rows.Scan(
 			&_o.ID,
 			&_o.Key,
 			&_o.TraitKey,
 			&_o.TraitValue,
+			&traitValues,
 			&_o.Operator,
 			&_o.Negate,
+			&_o.Match,
 		); err != nil {
 			return nil, err
 		}
+		_o.TraitValues = model.NewValueSet(traitValues...)
+
+		_o.Clauses, err = r.listClauses(ctx, _o.ID)
+		if err != nil {
+			return nil, err
+		}
 		o = append(o, &_o)
 	}
 	return o, nil
 }
 
+// Create creates the rule along with its clauses in a single transaction,
+// so the rule is never evaluated with only part of its clauses
 func (r *Repo) Create(
 	ctx context.Context,
 	i segmentrulemodel.SegmentRule,
 	a segmentrulemodel.RootArgs,
+) (*segmentrulemodel.SegmentRule, error) {
+	o := &i
+	err := r.DB.BeginFunc(ctx, func(tx pgx.Tx) error {
+		var err error
+		o, err = r.create(ctx, tx, i, a)
+		return err
+	})
+	return o, err
+}
+
+func (r *Repo) create(
+	ctx context.Context,
+	tx pgx.Tx,
+	i segmentrulemodel.SegmentRule,
+	a segmentrulemodel.RootArgs,
 ) (*segmentrulemodel.SegmentRule, error) {
 	var o segmentrulemodel.SegmentRule
+	var traitValues []string
 	sqlStatement := `
 INSERT INTO
   segment_rule(
@@ -88,6 +119,8 @@ INSERT INTO
     trait_value,
     operator,
     negate,
+    match_type,
+    trait_values,
     segment_id,
     environment_id
   )
@@ -96,8 +129,10 @@ VALUES
     $1,
     $2,
     $3,
-    $4,
+    NULLIF($4, '')::rule_operand,
     $5,
+    $10,
+    $11,
     (
       SELECT s.id
       FROM segment s
@@ -126,8 +161,10 @@ RETURNING
   key,
   trait_key,
   trait_value,
-  operator,
-  negate;`
+  trait_values,
+  COALESCE(operator::text, ''),
+  negate,
+  match_type;`
 	err := dbutil.ParseError(
 		rsc.SegmentRule.String(),
 		segmentrulemodel.ResourceArgs{
@@ -137,7 +174,7 @@ RETURNING
 			SegmentKey:     a.SegmentKey,
 			SegmentRuleKey: i.Key,
 		},
-		r.DB.QueryRow(
+		tx.QueryRow(
 			ctx,
 			sqlStatement,
 			i.Key,
@@ -149,16 +186,30 @@ RETURNING
 			a.ProjectKey,
 			a.EnvironmentKey,
 			a.SegmentKey,
+			i.Match,
+			i.TraitValues.Values(),
 		).Scan(
 			&o.ID,
 			&o.Key,
 			&o.TraitKey,
 			&o.TraitValue,
+			&traitValues,
 			&o.Operator,
 			&o.Negate,
+			&o.Match,
 		),
 	)
-	return &o, err
+	if err != nil {
+		return &o, err
+	}
+	o.TraitValues = model.NewValueSet(traitValues...)
+
+	if err := r.createClauses(ctx, tx, o.ID, i.Clauses, a); err != nil {
+		return &o, err
+	}
+	o.Clauses = i.Clauses
+
+	return &o, nil
 }
 
 func (r *Repo) Get(
@@ -166,14 +217,17 @@ func (r *Repo) Get(
 	a segmentrulemodel.ResourceArgs,
 ) (*segmentrulemodel.SegmentRule, error) {
 	var o segmentrulemodel.SegmentRule
+	var traitValues []string
 	sqlStatement := `
 SELECT
   sr.id,
   sr.key,
   sr.trait_key,
   sr.trait_value,
-  sr.operator,
-  sr.negate
+  sr.trait_values,
+  COALESCE(sr.operator::text, ''),
+  sr.negate,
+  sr.match_type
 FROM segment_rule sr
 LEFT JOIN segment s
   ON s.id = sr.segment_id
@@ -204,17 +258,41 @@ WHERE w.key = $1
 			&o.Key,
 			&o.TraitKey,
 			&o.TraitValue,
+			&traitValues,
 			&o.Operator,
 			&o.Negate,
+			&o.Match,
 		),
 	)
+	if err != nil {
+		return &o, err
+	}
+	o.TraitValues = model.NewValueSet(traitValues...)
+
+	o.Clauses, err = r.listClauses(ctx, o.ID)
 	return &o, err
 }
 
+// Update updates the rule along with its clauses in a single transaction
 func (r *Repo) Update(
 	ctx context.Context,
 	i segmentrulemodel.SegmentRule,
 	a segmentrulemodel.ResourceArgs,
+) (*segmentrulemodel.SegmentRule, error) {
+	o := &i
+	err := r.DB.BeginFunc(ctx, func(tx pgx.Tx) error {
+		var err error
+		o, err = r.update(ctx, tx, i, a)
+		return err
+	})
+	return o, err
+}
+
+func (r *Repo) update(
+	ctx context.Context,
+	tx pgx.Tx,
+	i segmentrulemodel.SegmentRule,
+	a segmentrulemodel.ResourceArgs,
 ) (*segmentrulemodel.SegmentRule, error) {
 	sqlStatement := `
 UPDATE segment_rule
@@ -222,10 +300,12 @@ SET
   key = $2,
   trait_key = $3,
   trait_value = $4,
-  operator = $5,
-  negate = $6
+  operator = NULLIF($5, '')::rule_operand,
+  negate = $6,
+  match_type = $7,
+  trait_values = $8
 WHERE id = $1`
-	if _, err := r.DB.Exec(
+	if _, err := tx.Exec(
 		ctx,
 		sqlStatement,
 		i.ID,
@@ -234,6 +314,8 @@ WHERE id = $1`
 		i.TraitValue,
 		i.Operator,
 		i.Negate,
+		i.Match,
+		i.TraitValues.Values(),
 	); err != nil {
 		return &i, dbutil.ParseError(
 			rsc.SegmentRule.String(),
@@ -241,6 +323,10 @@ WHERE id = $1`
 			err,
 		)
 	}
+
+	if err := r.replaceClauses(ctx, tx, i.ID, i.Clauses, a); err != nil {
+		return &i, err
+	}
 	return &i, nil
 }
 
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	rsc "core/internal/pkg/resource"
+	"core/pkg/dbutil"
+	"core/pkg/model"
+
+	"github.com/jackc/pgx/v4"
+)
+
+func (r *Repo) listClauses(
+	ctx context.Context,
+	ruleID string,
+) ([]*model.Clause, error) {
+	var o []*model.Clause
+	sqlStatement := `
+SELECT
+  src.trait_key,
+  COALESCE(src.trait_value, ''),
+  src.trait_values,
+  src.operator,
+  src.negate
+FROM segment_rule_clause src
+WHERE src.segment_rule_id = $1
+ORDER BY src.position`
+	rows, err := r.DB.Query(
+		ctx,
+		sqlStatement,
+		ruleID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var _o model.Clause
+		var traitValues []string
+		if err = rows.Scan(
+			&_o.TraitKey,
+			&_o.TraitValue,
+			&traitValues,
+			&_o.Operator,
+			&_o.Negate,
+		); err != nil {
+			return nil, err
+		}
+		_o.TraitValues = model.NewValueSet(traitValues...)
+		o = append(o, &_o)
+	}
+	return o, rows.Err()
+}
+
+func (r *Repo) createClauses(
+	ctx context.Context,
+	tx pgx.Tx,
+	ruleID string,
+	clauses []*model.Clause,
+	a interface{},
+) error {
+	for idx, c := range clauses {
+		sqlStatement := `
+INSERT INTO
+  segment_rule_clause(
+    position,
+    trait_key,
+    trait_value,
+    operator,
+    negate,
+    segment_rule_id,
+    trait_values
+  )
+VALUES
+  (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+  )`
+		if _, err := tx.Exec(
+			ctx,
+			sqlStatement,
+			idx,
+			c.TraitKey,
+			c.TraitValue,
+			c.Operator,
+			c.Negate,
+			ruleID,
+			c.TraitValues.Values(),
+		); err != nil {
+			return dbutil.ParseError(
+				rsc.SegmentRuleClause.String(),
+				a,
+				err,
+			)
+		}
+	}
+	return nil
+}
+
+func (r *Repo) replaceClauses(
+	ctx context.Context,
+	tx pgx.Tx,
+	ruleID string,
+	clauses []*model.Clause,
+	a interface{},
+) error {
+	sqlStatement := `
+DELETE FROM segment_rule_clause
+WHERE segment_rule_id = $1`
+	if _, err := tx.Exec(
+		ctx,
+		sqlStatement,
+		ruleID,
+	); err != nil {
+		return dbutil.ParseError(
+			rsc.SegmentRuleClause.String(),
+			a,
+			err,
+		)
+	}
+	return r.createClauses(ctx, tx, ruleID, clauses, a)
+}
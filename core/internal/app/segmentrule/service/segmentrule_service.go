@@ -8,6 +8,8 @@ import (
 	cons "core/internal/pkg/constants"
 	rsc "core/internal/pkg/resource"
 	"core/internal/pkg/srvenv"
+	"core/pkg/evaluator"
+	"core/pkg/model"
 	"core/pkg/patch"
 	res "core/pkg/response"
 )
@@ -67,6 +69,15 @@ func (s *Service) Create(
 		return nil, &e
 	}
 
+	if i.Match == "" {
+		i.Match = model.MatchAll
+	}
+
+	if err := evaluator.ValidateClauses(ruleClauses(i)...); err != nil {
+		e.Append(cons.ErrorInput, err.Error())
+		return nil, &e
+	}
+
 	r, err := s.SegmentRuleRepo.Create(ctx, i, a)
 	if err != nil {
 		e.Append(cons.ErrorInput, err.Error())
@@ -116,6 +127,11 @@ func (s *Service) Update(
 		cancel()
 	}
 
+	if err := evaluator.ValidateClauses(ruleClauses(o)...); err != nil {
+		e.Append(cons.ErrorInput, err.Error())
+		return r, &e
+	}
+
 	r, err = s.SegmentRuleRepo.Update(ctx, o, a)
 	if err != nil {
 		e.Append(cons.ErrorInternal, err.Error())
@@ -146,3 +162,12 @@ func (s *Service) Delete(
 
 	return &e
 }
+
+// ruleClauses the rule's own trait condition followed by its clauses
+func ruleClauses(i segmentrulemodel.SegmentRule) []*model.Clause {
+	return append([]*model.Clause{{
+		TraitKey:   i.TraitKey,
+		TraitValue: i.TraitValue,
+		Operator:   i.Operator,
+	}}, i.Clauses...)
+}
@@ -4,7 +4,12 @@ import "core/pkg/model"
 
 // Targeting represents a targeting configuration for a flag in a particular environment
 type Targeting struct {
-	ID                    string             `json:"id" jsonapi:"primary,targeting"`
-	Enabled               bool               `json:"enabled" jsonapi:"attr,enabled"`
-	FallthroughVariations []*model.Variation `json:"fallthroughVariations" jsonapi:"attr,fallthroughVariations"`
+	ID                    string                `json:"id" jsonapi:"primary,targeting"`
+	Enabled               bool                  `json:"enabled" jsonapi:"attr,enabled"`
+	OffVariationKey       string                `json:"offVariationKey" jsonapi:"attr,offVariationKey"`
+	BucketBy              string                `json:"bucketBy" jsonapi:"attr,bucketBy"`
+	Seed                  string                `json:"seed" jsonapi:"attr,seed"`
+	Sticky                bool                  `json:"sticky" jsonapi:"attr,sticky"`
+	FallthroughVariations []*model.Variation    `json:"fallthroughVariations" jsonapi:"attr,fallthroughVariations"`
+	Prerequisites         []*model.Prerequisite `json:"prerequisites,omitempty" jsonapi:"attr,prerequisites,omitempty"`
 }
@@ -7,10 +7,17 @@ import (
 	"core/internal/pkg/srvenv"
 	"core/pkg/dbutil"
 	"core/pkg/model"
+	"errors"
+	"fmt"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+// ErrVariationNotFound a variation referenced by the targeting (i.e. its off
+// variation or a prerequisite variation) isn't a variation of the flag
+var ErrVariationNotFound = errors.New("variation does not exist")
+
 type Repo struct {
 	DB *pgxpool.Pool
 }
@@ -21,22 +28,60 @@ func NewRepo(senv *srvenv.Env) *Repo {
 	}
 }
 
+// Create creates the targeting along with its fallthrough variations and prerequisites
+// in a single transaction, so the targeting is never evaluated with only part of them
 func (r *Repo) Create(
 	ctx context.Context,
 	i targetingmodel.Targeting,
 	a targetingmodel.RootArgs,
+) (*targetingmodel.Targeting, error) {
+	o := &i
+	err := r.DB.BeginFunc(ctx, func(tx pgx.Tx) error {
+		var err error
+		o, err = r.create(ctx, tx, i, a)
+		return err
+	})
+	return o, err
+}
+
+func (r *Repo) create(
+	ctx context.Context,
+	tx pgx.Tx,
+	i targetingmodel.Targeting,
+	a targetingmodel.RootArgs,
 ) (*targetingmodel.Targeting, error) {
 	var o targetingmodel.Targeting
 	sqlStatement := `
 INSERT INTO
   targeting(
     enabled,
+    bucket_by,
+    seed,
+    sticky,
+    off_variation_id,
     flag_id,
     environment_id
   )
 VALUES
   (
     $1,
+    $6,
+    $7,
+    $9,
+    (
+      SELECT v.id
+      FROM variation v
+      LEFT JOIN flag f
+        ON f.id = v.flag_id
+      LEFT JOIN project p
+        ON p.id = f.project_id
+      LEFT JOIN workspace w
+        ON w.id = p.workspace_id
+      WHERE w.key = $2
+        AND p.key = $3
+        AND f.key = $5
+        AND v.key = $8
+    ),
     (
       SELECT f.id
       FROM flag f
@@ -62,11 +107,16 @@ VALUES
   )
 RETURNING
   id,
-  enabled;`
+  enabled,
+  bucket_by,
+  seed,
+  sticky,
+  $8 = '' OR off_variation_id IS NOT NULL;`
+	var offVariationFound bool
 	if err := dbutil.ParseError(
 		rsc.Targeting.String(),
 		a,
-		r.DB.QueryRow(
+		tx.QueryRow(
 			ctx,
 			sqlStatement,
 			i.Enabled,
@@ -74,15 +124,26 @@ RETURNING
 			a.ProjectKey,
 			a.EnvironmentKey,
 			a.FlagKey,
+			i.BucketBy,
+			i.Seed,
+			i.OffVariationKey,
+			i.Sticky,
 		).Scan(
 			&o.ID,
 			&o.Enabled,
+			&o.BucketBy,
+			&o.Seed,
+			&o.Sticky,
+			&offVariationFound,
 		),
 	); err != nil {
 		return &o, err
 	}
+	if !offVariationFound {
+		return &o, fmt.Errorf("off variation %s: %w", i.OffVariationKey, ErrVariationNotFound)
+	}
+	o.OffVariationKey = i.OffVariationKey
 
-	var err error
 	for _, f := range i.FallthroughVariations {
 		sqlStatement = `
 INSERT INTO
@@ -112,10 +173,10 @@ VALUES
   )
 RETURNING
   weight`
-		err = dbutil.ParseError(
+		if err := dbutil.ParseError(
 			rsc.FallthroughVariation.String(),
 			a,
-			r.DB.QueryRow(
+			tx.QueryRow(
 				ctx,
 				sqlStatement,
 				f.Weight,
@@ -125,11 +186,20 @@ RETURNING
 				a.FlagKey,
 				f.VariationKey,
 			).Scan(&f.Weight),
-		)
+		); err != nil {
+			return &o, err
+		}
 		o.FallthroughVariations = append(o.FallthroughVariations, f)
 	}
 
-	return &o, err
+	if len(i.Prerequisites) > 0 {
+		if err := r.replacePrerequisites(ctx, tx, o.ID, i.Prerequisites, a); err != nil {
+			return &o, err
+		}
+		o.Prerequisites = i.Prerequisites
+	}
+
+	return &o, nil
 }
 
 func (r *Repo) Get(
@@ -140,10 +210,16 @@ func (r *Repo) Get(
 	sqlStatement := `
 SELECT
   t.id,
-  t.enabled
+  t.enabled,
+  t.bucket_by,
+  t.seed,
+  t.sticky,
+  COALESCE(ov.key, '')
 FROM targeting t
 LEFT JOIN flag f
   ON f.id = t.flag_id
+LEFT JOIN variation ov
+  ON ov.id = t.off_variation_id
 LEFT JOIN environment e
   ON e.id = t.environment_id
 LEFT JOIN project p
@@ -167,6 +243,10 @@ WHERE w.key = $1
 		).Scan(
 			&o.ID,
 			&o.Enabled,
+			&o.BucketBy,
+			&o.Seed,
+			&o.Sticky,
+			&o.OffVariationKey,
 		),
 	)
 	if err != nil {
@@ -217,31 +297,94 @@ WHERE w.key = $1
 		o.FallthroughVariations = append(o.FallthroughVariations, &_o)
 	}
 
+	o.Prerequisites, err = r.listPrerequisites(ctx, o.ID)
 	return &o, err
 }
 
+// Update updates the targeting along with its fallthrough variations and prerequisites
+// in a single transaction, so pollers never read the prerequisites while they're replaced
 func (r *Repo) Update(
 	ctx context.Context,
 	i targetingmodel.Targeting,
 	a targetingmodel.RootArgs,
+) (*targetingmodel.Targeting, error) {
+	o := &i
+	err := r.DB.BeginFunc(ctx, func(tx pgx.Tx) error {
+		var err error
+		o, err = r.update(ctx, tx, i, a)
+		return err
+	})
+	return o, err
+}
+
+func (r *Repo) update(
+	ctx context.Context,
+	tx pgx.Tx,
+	i targetingmodel.Targeting,
+	a targetingmodel.RootArgs,
 ) (*targetingmodel.Targeting, error) {
 	sqlStatement := `
 UPDATE targeting
 SET
-  enabled = $2
-WHERE id = $1`
-	if _, err := r.DB.Exec(
+  enabled = $2,
+  bucket_by = $3,
+  seed = $4,
+  sticky = $9,
+  off_variation_id = (
+    SELECT v.id
+    FROM variation v
+    LEFT JOIN flag f
+      ON f.id = v.flag_id
+    LEFT JOIN project p
+      ON p.id = f.project_id
+    LEFT JOIN workspace w
+      ON w.id = p.workspace_id
+    WHERE w.key = $5
+      AND p.key = $6
+      AND f.key = $7
+      AND v.key = $8
+  )
+WHERE id = $1
+  AND (
+    $8 = ''
+    OR EXISTS (
+      SELECT 1
+      FROM variation v
+      LEFT JOIN flag f
+        ON f.id = v.flag_id
+      LEFT JOIN project p
+        ON p.id = f.project_id
+      LEFT JOIN workspace w
+        ON w.id = p.workspace_id
+      WHERE w.key = $5
+        AND p.key = $6
+        AND f.key = $7
+        AND v.key = $8
+    )
+  )`
+	tag, err := tx.Exec(
 		ctx,
 		sqlStatement,
 		i.ID,
 		i.Enabled,
-	); err != nil {
+		i.BucketBy,
+		i.Seed,
+		a.WorkspaceKey,
+		a.ProjectKey,
+		a.FlagKey,
+		i.OffVariationKey,
+		i.Sticky,
+	)
+	if err != nil {
 		return &i, dbutil.ParseError(
 			rsc.Targeting.String(),
 			a,
 			err,
 		)
 	}
+	if tag.RowsAffected() == 0 {
+		return &i, fmt.Errorf("off variation %s: %w", i.OffVariationKey, ErrVariationNotFound)
+	}
 
 	for _, f := range i.FallthroughVariations {
 		sqlStatement := `
@@ -263,7 +406,7 @@ WHERE targeting_id = $1
       AND f.key = $5
       AND v.key = $6
   )`
-		if _, err := r.DB.Exec(
+		if _, err := tx.Exec(
 			ctx,
 			sqlStatement,
 			i.ID,
@@ -281,12 +424,27 @@ WHERE targeting_id = $1
 		}
 	}
 
+	if err := r.replacePrerequisites(ctx, tx, i.ID, i.Prerequisites, a); err != nil {
+		return &i, err
+	}
+
 	return &i, nil
 }
 
+// Delete deletes the targeting along with its fallthrough variations in a single transaction
 func (r *Repo) Delete(
 	ctx context.Context,
 	a targetingmodel.RootArgs,
+) error {
+	return r.DB.BeginFunc(ctx, func(tx pgx.Tx) error {
+		return r.delete(ctx, tx, a)
+	})
+}
+
+func (r *Repo) delete(
+	ctx context.Context,
+	tx pgx.Tx,
+	a targetingmodel.RootArgs,
 ) error {
 	sqlStatement := `
 DELETE FROM targeting_fallthrough_variation
@@ -306,7 +464,7 @@ WHERE targeting_id = (
     AND e.key = $3
     AND f.key = $4
 )`
-	if _, err := r.DB.Exec(
+	if _, err := tx.Exec(
 		ctx,
 		sqlStatement,
 		a.WorkspaceKey,
@@ -339,7 +497,7 @@ WHERE id = (
     AND e.key = $3
     AND f.key = $4
 )`
-	if _, err := r.DB.Exec(
+	if _, err := tx.Exec(
 		ctx,
 		sqlStatement,
 		a.WorkspaceKey,
@@ -356,3 +514,48 @@ WHERE id = (
 
 	return nil
 }
+
+// DeleteAssignments resets the variations recorded for identities by the sticky
+// targeting, only the identity's assignment is reset if an identity key is given
+func (r *Repo) DeleteAssignments(
+	ctx context.Context,
+	identityKey string,
+	a targetingmodel.RootArgs,
+) error {
+	sqlStatement := `
+DELETE FROM targeting_assignment
+WHERE targeting_id = (
+  SELECT t.id
+  FROM targeting t
+  LEFT JOIN flag f
+    ON f.id = t.flag_id
+  LEFT JOIN environment e
+    ON e.id = t.environment_id
+  LEFT JOIN project p
+    ON p.id = e.project_id
+  LEFT JOIN workspace w
+    ON w.id = p.workspace_id
+  WHERE w.key = $1
+    AND p.key = $2
+    AND e.key = $3
+    AND f.key = $4
+)
+  AND ($5 = '' OR identity_key = $5)`
+	if _, err := r.DB.Exec(
+		ctx,
+		sqlStatement,
+		a.WorkspaceKey,
+		a.ProjectKey,
+		a.EnvironmentKey,
+		a.FlagKey,
+		identityKey,
+	); err != nil {
+		return dbutil.ParseError(
+			rsc.TargetingAssignment.String(),
+			a,
+			err,
+		)
+	}
+
+	return nil
+}
@@ -5,17 +5,23 @@ import (
 	targetingmodel "core/internal/app/targeting/model"
 	rsc "core/internal/pkg/resource"
 	"core/pkg/dbutil"
+	"core/pkg/model"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
 )
 
+// CreateFallthroughVariations adds the variations to the targeting's fallthrough in a single
+// transaction, variations which are already part of the fallthrough are kept as is
 // TODO: targetingrepo should use this instead
 func (r *Repo) CreateFallthroughVariations(
 	ctx context.Context,
 	i targetingmodel.Targeting,
 	a targetingmodel.RootArgs,
 ) (*targetingmodel.Targeting, error) {
-	var err error
-	for _, f := range i.FallthroughVariations {
-		sqlStatement := `
+	err := r.DB.BeginFunc(ctx, func(tx pgx.Tx) error {
+		for _, f := range i.FallthroughVariations {
+			sqlStatement := `
 INSERT INTO
   targeting_fallthrough_variation(
     weight,
@@ -41,13 +47,8 @@ VALUES
         AND v.key = $6
     )
   )
-ON CONFLICT (targeting_id, variation_id) DO NOTHING
-RETURNING
-  weight`
-		err = dbutil.ParseError(
-			rsc.FallthroughVariation.String(),
-			a,
-			r.DB.QueryRow(
+ON CONFLICT (targeting_id, variation_id) DO NOTHING`
+			if _, err := tx.Exec(
 				ctx,
 				sqlStatement,
 				f.Weight,
@@ -56,10 +57,191 @@ RETURNING
 				a.ProjectKey,
 				a.FlagKey,
 				f.VariationKey,
-			).Scan(&f.Weight),
+			); err != nil {
+				return dbutil.ParseError(
+					rsc.FallthroughVariation.String(),
+					a,
+					err,
+				)
+			}
+		}
+		return nil
+	})
+
+	return &i, err
+}
+
+func (r *Repo) listPrerequisites(
+	ctx context.Context,
+	targetingID string,
+) ([]*model.Prerequisite, error) {
+	var o []*model.Prerequisite
+	sqlStatement := `
+SELECT
+  pf.key,
+  pv.key
+FROM targeting_prerequisite tp
+LEFT JOIN flag pf
+  ON pf.id = tp.prerequisite_flag_id
+LEFT JOIN variation pv
+  ON pv.id = tp.variation_id
+WHERE tp.targeting_id = $1
+ORDER BY pf.key, pv.key`
+	rows, err := r.DB.Query(
+		ctx,
+		sqlStatement,
+		targetingID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byFlag := make(map[string]*model.Prerequisite)
+	for rows.Next() {
+		var flagKey, variationKey string
+		if err = rows.Scan(
+			&flagKey,
+			&variationKey,
+		); err != nil {
+			return nil, err
+		}
+		p, ok := byFlag[flagKey]
+		if !ok {
+			p = &model.Prerequisite{FlagKey: flagKey}
+			byFlag[flagKey] = p
+			o = append(o, p)
+		}
+		p.VariationKeys = append(p.VariationKeys, variationKey)
+	}
+	return o, rows.Err()
+}
+
+// replacePrerequisites replaces the prerequisite flags of a targeting
+func (r *Repo) replacePrerequisites(
+	ctx context.Context,
+	tx pgx.Tx,
+	targetingID string,
+	prerequisites []*model.Prerequisite,
+	a targetingmodel.RootArgs,
+) error {
+	sqlStatement := `
+DELETE FROM targeting_prerequisite
+WHERE targeting_id = $1`
+	if _, err := tx.Exec(
+		ctx,
+		sqlStatement,
+		targetingID,
+	); err != nil {
+		return dbutil.ParseError(
+			rsc.TargetingPrerequisite.String(),
+			a,
+			err,
 		)
-		i.FallthroughVariations = append(i.FallthroughVariations, f)
 	}
 
-	return &i, err
+	for _, p := range prerequisites {
+		for _, variationKey := range p.VariationKeys {
+			sqlStatement := `
+INSERT INTO
+  targeting_prerequisite(
+    targeting_id,
+    prerequisite_flag_id,
+    variation_id
+  )
+SELECT
+  $1,
+  f.id,
+  v.id
+FROM variation v
+LEFT JOIN flag f
+  ON f.id = v.flag_id
+LEFT JOIN project p
+  ON p.id = f.project_id
+LEFT JOIN workspace w
+  ON w.id = p.workspace_id
+WHERE w.key = $2
+  AND p.key = $3
+  AND f.key = $4
+  AND v.key = $5
+ON CONFLICT (targeting_id, variation_id) DO NOTHING`
+			tag, err := tx.Exec(
+				ctx,
+				sqlStatement,
+				targetingID,
+				a.WorkspaceKey,
+				a.ProjectKey,
+				p.FlagKey,
+				variationKey,
+			)
+			if err != nil {
+				return dbutil.ParseError(
+					rsc.TargetingPrerequisite.String(),
+					a,
+					err,
+				)
+			}
+			if tag.RowsAffected() == 0 {
+				return fmt.Errorf(
+					"prerequisite variation %s/%s: %w",
+					p.FlagKey,
+					variationKey,
+					ErrVariationNotFound,
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ListPrerequisiteKeys returns the prerequisite flag keys of every flag in the environment
+func (r *Repo) ListPrerequisiteKeys(
+	ctx context.Context,
+	a targetingmodel.RootArgs,
+) (map[string][]string, error) {
+	o := make(map[string][]string)
+	sqlStatement := `
+SELECT DISTINCT
+  f.key,
+  pf.key
+FROM targeting_prerequisite tp
+LEFT JOIN targeting t
+  ON t.id = tp.targeting_id
+LEFT JOIN flag f
+  ON f.id = t.flag_id
+LEFT JOIN flag pf
+  ON pf.id = tp.prerequisite_flag_id
+LEFT JOIN environment e
+  ON e.id = t.environment_id
+LEFT JOIN project p
+  ON p.id = e.project_id
+LEFT JOIN workspace w
+  ON w.id = p.workspace_id
+WHERE w.key = $1
+  AND p.key = $2
+  AND e.key = $3`
+	rows, err := r.DB.Query(
+		ctx,
+		sqlStatement,
+		a.WorkspaceKey,
+		a.ProjectKey,
+		a.EnvironmentKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var flagKey, prerequisiteKey string
+		if err = rows.Scan(
+			&flagKey,
+			&prerequisiteKey,
+		); err != nil {
+			return nil, err
+		}
+		o[flagKey] = append(o[flagKey], prerequisiteKey)
+	}
+	return o, rows.Err()
 }
@@ -10,6 +10,7 @@ import (
 	"core/internal/pkg/srvenv"
 	"core/pkg/patch"
 	res "core/pkg/response"
+	"errors"
 )
 
 type Service struct {
@@ -42,6 +43,22 @@ func (s *Service) Create(
 		return nil, &e
 	}
 
+	defaultOffVariation(&i)
+	if err := validateOffVariation(i); !err.IsEmpty() {
+		e.Extend(err)
+		return nil, &e
+	}
+
+	if err := validateWeights(i); !err.IsEmpty() {
+		e.Extend(err)
+		return nil, &e
+	}
+
+	if err := s.validatePrerequisites(ctx, i.Prerequisites, a); !err.IsEmpty() {
+		e.Extend(err)
+		return nil, &e
+	}
+
 	r, err := s.TargetingRepo.Create(ctx, i, a)
 	if err != nil {
 		e.Append(cons.ErrorInput, err.Error())
@@ -91,8 +108,26 @@ func (s *Service) Update(
 		cancel()
 	}
 
+	defaultOffVariation(&o)
+	if err := validateOffVariation(o); !err.IsEmpty() {
+		e.Extend(err)
+		return r, &e
+	}
+
+	if err := validateWeights(o); !err.IsEmpty() {
+		e.Extend(err)
+		return r, &e
+	}
+
+	if err := s.validatePrerequisites(ctx, o.Prerequisites, a); !err.IsEmpty() {
+		e.Extend(err)
+		return r, &e
+	}
+
 	r, err = s.TargetingRepo.Update(ctx, o, a)
-	if err != nil {
+	if errors.Is(err, targetingrepo.ErrVariationNotFound) {
+		e.Append(cons.ErrorInput, err.Error())
+	} else if err != nil {
 		e.Append(cons.ErrorInternal, err.Error())
 	}
 
@@ -115,3 +150,34 @@ func (s *Service) Delete(
 
 	return &e
 }
+
+// ResetAssignments resets the variations recorded by a sticky targeting, so identities are
+// bucketed again on their next evaluation (only the identity's if an identity key is given)
+// (*) atk: access_type <= user
+func (s *Service) ResetAssignments(
+	atk rsc.Token,
+	identityKey rsc.Key,
+	a targetingmodel.RootArgs,
+) *res.Errors {
+	var e res.Errors
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Verify access is authorized
+	_, err := authutil.Authorize(s.Senv, atk)
+	if err != nil {
+		e.Append(cons.ErrorAuth, err.Error())
+		return &e
+	}
+
+	if _, err := s.TargetingRepo.Get(ctx, a); err != nil {
+		e.Append(cons.ErrorNotFound, err.Error())
+		return &e
+	}
+
+	if err := s.TargetingRepo.DeleteAssignments(ctx, identityKey.String(), a); err != nil {
+		e.Append(cons.ErrorInternal, err.Error())
+	}
+
+	return &e
+}
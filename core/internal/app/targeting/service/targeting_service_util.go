@@ -0,0 +1,80 @@
+package targeting
+
+import (
+	"context"
+	targetingmodel "core/internal/app/targeting/model"
+	cons "core/internal/pkg/constants"
+	"core/pkg/evaluator"
+	"core/pkg/model"
+	res "core/pkg/response"
+	"fmt"
+	"strings"
+)
+
+// validatePrerequisites checks the prerequisites of a flag, the flag's prerequisites
+// (incl. the prerequisites of other flags in the environment) can't form a cycle
+func (s *Service) validatePrerequisites(
+	ctx context.Context,
+	prerequisites []*model.Prerequisite,
+	a targetingmodel.RootArgs,
+) *res.Errors {
+	var e res.Errors
+	if len(prerequisites) == 0 {
+		return &e
+	}
+
+	deps, err := s.TargetingRepo.ListPrerequisiteKeys(ctx, a)
+	if err != nil {
+		e.Append(cons.ErrorInternal, err.Error())
+		return &e
+	}
+
+	flagKey := a.FlagKey.String()
+	deps[flagKey] = nil
+	for _, p := range prerequisites {
+		if len(p.VariationKeys) == 0 {
+			e.Append(cons.ErrorInput, fmt.Sprintf("prerequisite %s requires at least one variation", p.FlagKey))
+		}
+		deps[flagKey] = append(deps[flagKey], p.FlagKey)
+	}
+
+	if cycle := evaluator.DetectCycle(deps, flagKey); cycle != nil {
+		e.Append(cons.ErrorInput, fmt.Sprintf("prerequisite cycle detected (%s)", strings.Join(cycle, " -> ")))
+	}
+
+	return &e
+}
+
+// defaultOffVariation defaults an empty off variation to the control variation,
+// i.e. the first fallthrough variation (the variation served when the flag is off)
+func defaultOffVariation(i *targetingmodel.Targeting) {
+	if i.OffVariationKey == "" && len(i.FallthroughVariations) > 0 {
+		i.OffVariationKey = i.FallthroughVariations[0].VariationKey
+	}
+}
+
+// validateOffVariation checks if the off variation is one of the flag's variations
+func validateOffVariation(i targetingmodel.Targeting) *res.Errors {
+	var e res.Errors
+	if i.OffVariationKey == "" && len(i.FallthroughVariations) == 0 {
+		return &e
+	}
+
+	for _, v := range i.FallthroughVariations {
+		if v.VariationKey == i.OffVariationKey {
+			return &e
+		}
+	}
+
+	e.Append(cons.ErrorInput, fmt.Sprintf("off variation %s is not a variation of the flag", i.OffVariationKey))
+	return &e
+}
+
+// validateWeights checks if the fallthrough weights add up to 100%
+func validateWeights(i targetingmodel.Targeting) *res.Errors {
+	var e res.Errors
+	if err := evaluator.ValidateWeights(i.FallthroughVariations); err != nil {
+		e.Append(cons.ErrorInput, fmt.Sprintf("fallthrough variations: %s", err.Error()))
+	}
+	return &e
+}
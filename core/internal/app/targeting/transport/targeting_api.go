@@ -43,6 +43,10 @@ func ApplyRoutes(senv *srvenv.Env, r *gin.RouterGroup) {
 	routes.GET(rootPath, h.getAPIHandler)
 	routes.PATCH(rootPath, h.updateAPIHandler)
 	routes.DELETE(rootPath, h.deleteAPIHandler)
+
+	assignmentsPath := httputil.AppendRoute(rootPath, rsc.RouteAssignment)
+	routes.DELETE(assignmentsPath, h.resetAssignmentsAPIHandler)
+	routes.DELETE(httputil.AppendPath(assignmentsPath, rsc.IdentityKey), h.resetAssignmentsAPIHandler)
 	targetingruletransport.ApplyRoutes(senv, routes)
 }
 
@@ -176,3 +180,35 @@ func (h *APIHandler) deleteAPIHandler(ctx *gin.Context) {
 		e,
 	)
 }
+
+// resetAssignmentsAPIHandler resets every assignment of the targeting,
+// or only the identity's assignment if the path includes an identity key
+func (h *APIHandler) resetAssignmentsAPIHandler(ctx *gin.Context) {
+	var e res.Errors
+
+	atk, err := httputil.ExtractATK(ctx)
+	if err != nil {
+		e.Append(cons.ErrorAuth, err.Error())
+	}
+
+	if err := h.TargetingService.ResetAssignments(
+		atk,
+		httputil.GetParam(ctx, rsc.IdentityKey),
+		targetingmodel.RootArgs{
+			WorkspaceKey:   httputil.GetParam(ctx, rsc.WorkspaceKey),
+			ProjectKey:     httputil.GetParam(ctx, rsc.ProjectKey),
+			EnvironmentKey: httputil.GetParam(ctx, rsc.EnvironmentKey),
+			FlagKey:        httputil.GetParam(ctx, rsc.FlagKey),
+		},
+	); !err.IsEmpty() {
+		e.Extend(err)
+	}
+
+	httputil.SendJSON(
+		ctx,
+		http.StatusNoContent,
+		&res.Success{},
+		http.StatusInternalServerError,
+		e,
+	)
+}
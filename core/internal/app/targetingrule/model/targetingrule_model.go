@@ -15,8 +15,11 @@ type TargetingRule struct {
 	Type           string             `json:"type" jsonapi:"attr,type"`
 	TraitKey       string             `json:"traitKey,omitempty" jsonapi:"attr,traitKey,omitempty"`
 	TraitValue     string             `json:"traitValue,omitempty" jsonapi:"attr,traitValue,omitempty"`
+	TraitValues    model.ValueSet     `json:"traitValues,omitempty" jsonapi:"attr,traitValues,omitempty"`
 	Operator       model.Operator     `json:"operator,omitempty" jsonapi:"attr,operator,omitempty"`
 	Negate         bool               `json:"negate,omitempty" jsonapi:"attr,negate,omitempty"`
+	Match          model.MatchType    `json:"match,omitempty" jsonapi:"attr,match,omitempty"`
+	Clauses        []*model.Clause    `json:"clauses,omitempty" jsonapi:"attr,clauses,omitempty"`
 	RuleVariations []*model.Variation `json:"ruleVariations" jsonapi:"attr,ruleVariations"`
 	IdentityKey    rsc.Key            `json:"identityKey,omitempty" jsonapi:"attr,identityKey,omitempty"`
 	SegmentKey     rsc.Key            `json:"segmentKey,omitempty" jsonapi:"attr,segmentKey,omitempty"`
@@ -8,8 +8,8 @@ import (
 	"core/pkg/dbutil"
 	"core/pkg/model"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
-	"github.com/lib/pq"
 )
 
 type Repo struct {
@@ -37,8 +37,10 @@ SELECT
   tr.tags,
   tr.trait_key,
   tr.trait_value,
-  tr.operator,
+  tr.trait_values,
+  COALESCE(tr.operator::text, ''),
   tr.negate,
+  tr.match_type,
   COALESCE(s.key, '') as segmentKey,
   COALESCE(i.key, '') as identityKey
 FROM targeting_rule tr
@@ -47,7 +49,7 @@ LEFT JOIN targeting t
 LEFT JOIN segment s
   ON s.id = tr.segment_id
 LEFT JOIN identity i
-  ON i.id = tr.segment_id
+  ON i.id = tr.identity_id
 LEFT JOIN flag f
   ON f.id = t.flag_id
 LEFT JOIN environment e
@@ -73,6 +75,7 @@ WHERE w.key = $1
 	}
 	for rows.Next() {
 		var _o targetingrulemodel.TargetingRule
+		var traitValues []string
 		if err = rows.Scan(
 			&_o.ID,
 			&_o.Key,
@@ -82,13 +85,21 @@ WHERE w.key = $1
 			&_o.Tags,
 			&_o.TraitKey,
 			&_o.TraitValue,
+			&traitValues,
 			&_o.Operator,
 			&_o.Negate,
+			&_o.Match,
 			&_o.SegmentKey,
 			&_o.IdentityKey,
 		); err != nil {
 			return nil, err
 		}
+		_o.TraitValues = model.NewValueSet(traitValues...)
+
+		_o.Clauses, err = r.listClauses(ctx, _o.ID)
+		if err != nil {
+			return nil, err
+		}
 
 		sqlStatement := `
 SELECT
@@ -124,12 +135,30 @@ WHERE tr.id = $1`
 	return o, nil
 }
 
+// Create creates the rule along with its clauses and variations in a single
+// transaction, so the rule is never evaluated with only part of its clauses
 func (r *Repo) Create(
 	ctx context.Context,
 	i targetingrulemodel.TargetingRule,
 	a targetingrulemodel.RootArgs,
+) (*targetingrulemodel.TargetingRule, error) {
+	o := &i
+	err := r.DB.BeginFunc(ctx, func(tx pgx.Tx) error {
+		var err error
+		o, err = r.create(ctx, tx, i, a)
+		return err
+	})
+	return o, err
+}
+
+func (r *Repo) create(
+	ctx context.Context,
+	tx pgx.Tx,
+	i targetingrulemodel.TargetingRule,
+	a targetingrulemodel.RootArgs,
 ) (*targetingrulemodel.TargetingRule, error) {
 	var o targetingrulemodel.TargetingRule
+	var traitValues []string
 	sqlStatement := `
 INSERT INTO
   targeting_rule(
@@ -140,8 +169,10 @@ INSERT INTO
     tags,
     trait_key,
     trait_value,
+    trait_values,
     operator,
     negate,
+    match_type,
     identity_id,
     segment_id,
     targeting_id
@@ -155,8 +186,10 @@ VALUES
     $5,
     $6,
     $7,
-    $8,
+    $17,
+    NULLIF($8, '')::rule_operand,
     $9,
+    $16,
     (
       SELECT COALESCE(i.id, NULL)
       FROM identity i
@@ -208,19 +241,21 @@ RETURNING
   tags,
   trait_key,
   trait_value,
-  operator,
-  negate;`
+  trait_values,
+  COALESCE(operator::text, ''),
+  negate,
+  match_type;`
 	if err := dbutil.ParseError(
 		rsc.TargetingRule.String(),
 		a,
-		r.DB.QueryRow(
+		tx.QueryRow(
 			ctx,
 			sqlStatement,
 			i.Key,
 			i.Type,
 			i.Name,
 			i.Description,
-			pq.Array(i.Tags),
+			i.Tags,
 			i.TraitKey,
 			i.TraitValue,
 			i.Operator,
@@ -231,6 +266,8 @@ RETURNING
 			a.ProjectKey,
 			a.EnvironmentKey,
 			a.FlagKey,
+			i.Match,
+			i.TraitValues.Values(),
 		).Scan(
 			&o.ID,
 			&o.Key,
@@ -240,16 +277,23 @@ RETURNING
 			&o.Tags,
 			&o.TraitKey,
 			&o.TraitValue,
+			&traitValues,
 			&o.Operator,
 			&o.Negate,
+			&o.Match,
 		),
 	); err != nil {
 		return &o, err
 	}
+	o.TraitValues = model.NewValueSet(traitValues...)
 	o.SegmentKey = i.SegmentKey
 	o.IdentityKey = i.IdentityKey
 
-	var err error
+	if err := r.createClauses(ctx, tx, o.ID, i.Clauses, a); err != nil {
+		return &o, err
+	}
+	o.Clauses = i.Clauses
+
 	for _, f := range i.RuleVariations {
 		sqlStatement = `
 INSERT INTO
@@ -279,10 +323,10 @@ VALUES
   )
 RETURNING
   weight`
-		err = dbutil.ParseError(
+		if err := dbutil.ParseError(
 			rsc.RuleVariation.String(),
 			a,
-			r.DB.QueryRow(
+			tx.QueryRow(
 				ctx,
 				sqlStatement,
 				f.Weight,
@@ -292,11 +336,13 @@ RETURNING
 				a.FlagKey,
 				f.VariationKey,
 			).Scan(&f.Weight),
-		)
+		); err != nil {
+			return &o, err
+		}
 		o.RuleVariations = append(o.RuleVariations, f)
 	}
 
-	return &o, err
+	return &o, nil
 }
 
 func (r *Repo) Get(
@@ -304,6 +350,7 @@ func (r *Repo) Get(
 	a targetingrulemodel.ResourceArgs,
 ) (*targetingrulemodel.TargetingRule, error) {
 	var o targetingrulemodel.TargetingRule
+	var traitValues []string
 	sqlStatement := `
 SELECT
   tr.id,
@@ -314,8 +361,10 @@ SELECT
   tr.tags,
   tr.trait_key,
   tr.trait_value,
-  tr.operator,
-  tr.negate
+  tr.trait_values,
+  COALESCE(tr.operator::text, ''),
+  tr.negate,
+  tr.match_type
 FROM targeting_rule tr
 LEFT JOIN targeting t
   ON t.id = tr.targeting_id
@@ -352,13 +401,21 @@ WHERE w.key = $1
 			&o.Tags,
 			&o.TraitKey,
 			&o.TraitValue,
+			&traitValues,
 			&o.Operator,
 			&o.Negate,
+			&o.Match,
 		),
 	)
 	if err != nil {
 		return &o, err
 	}
+	o.TraitValues = model.NewValueSet(traitValues...)
+
+	o.Clauses, err = r.listClauses(ctx, o.ID)
+	if err != nil {
+		return &o, err
+	}
 
 	sqlStatement = `
 SELECT
@@ -407,10 +464,26 @@ WHERE w.key = $1
 	return &o, err
 }
 
+// Update updates the rule along with its clauses and variations in a single transaction
 func (r *Repo) Update(
 	ctx context.Context,
 	i targetingrulemodel.TargetingRule,
 	a targetingrulemodel.ResourceArgs,
+) (*targetingrulemodel.TargetingRule, error) {
+	o := &i
+	err := r.DB.BeginFunc(ctx, func(tx pgx.Tx) error {
+		var err error
+		o, err = r.update(ctx, tx, i, a)
+		return err
+	})
+	return o, err
+}
+
+func (r *Repo) update(
+	ctx context.Context,
+	tx pgx.Tx,
+	i targetingrulemodel.TargetingRule,
+	a targetingrulemodel.ResourceArgs,
 ) (*targetingrulemodel.TargetingRule, error) {
 	sqlStatement := `
 UPDATE targeting_rule
@@ -422,8 +495,10 @@ SET
   tags = $6,
   trait_key = $7,
   trait_value = $8,
-  operator = $9,
+  trait_values = $17,
+  operator = NULLIF($9, '')::rule_operand,
   negate = $10,
+  match_type = $16,
   identity_id = (
     SELECT COALESCE(i.id, NULL)
     FROM identity i
@@ -450,7 +525,7 @@ SET
       AND s.key = $12
   )
 WHERE id = $1`
-	if _, err := r.DB.Exec(
+	if _, err := tx.Exec(
 		ctx,
 		sqlStatement,
 		i.ID,
@@ -458,7 +533,7 @@ WHERE id = $1`
 		i.Type,
 		i.Name,
 		i.Description,
-		pq.Array(i.Tags),
+		i.Tags,
 		i.TraitKey,
 		i.TraitValue,
 		i.Operator,
@@ -468,6 +543,8 @@ WHERE id = $1`
 		a.WorkspaceKey,
 		a.ProjectKey,
 		a.EnvironmentKey,
+		i.Match,
+		i.TraitValues.Values(),
 	); err != nil {
 		return &i, dbutil.ParseError(
 			rsc.TargetingRule.String(),
@@ -476,6 +553,10 @@ WHERE id = $1`
 		)
 	}
 
+	if err := r.replaceClauses(ctx, tx, i.ID, i.Clauses, a); err != nil {
+		return &i, err
+	}
+
 	for _, f := range i.RuleVariations {
 		sqlStatement := `
 UPDATE targeting_rule_variation
@@ -496,7 +577,7 @@ WHERE targeting_rule_id = $1
       AND f.key = $5
       AND v.key = $6
   )`
-		if _, err := r.DB.Exec(
+		if _, err := tx.Exec(
 			ctx,
 			sqlStatement,
 			i.ID,
@@ -517,9 +598,20 @@ WHERE targeting_rule_id = $1
 	return &i, nil
 }
 
+// Delete deletes the rule along with its variations in a single transaction
 func (r *Repo) Delete(
 	ctx context.Context,
 	a targetingrulemodel.ResourceArgs,
+) error {
+	return r.DB.BeginFunc(ctx, func(tx pgx.Tx) error {
+		return r.delete(ctx, tx, a)
+	})
+}
+
+func (r *Repo) delete(
+	ctx context.Context,
+	tx pgx.Tx,
+	a targetingrulemodel.ResourceArgs,
 ) error {
 	sqlStatement := `
 DELETE FROM targeting_rule_variation
@@ -542,7 +634,7 @@ WHERE targeting_rule_id = (
     AND f.key = $4
     AND tr.key = $5
 )`
-	if _, err := r.DB.Exec(
+	if _, err := tx.Exec(
 		ctx,
 		sqlStatement,
 		a.WorkspaceKey,
@@ -579,7 +671,7 @@ WHERE id = (
     AND f.key = $4
     AND tr.key = $5
 )`
-	if _, err := r.DB.Exec(
+	if _, err := tx.Exec(
 		ctx,
 		sqlStatement,
 		a.WorkspaceKey,
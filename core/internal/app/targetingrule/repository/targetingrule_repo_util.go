@@ -5,17 +5,22 @@ import (
 	targetingrulemodel "core/internal/app/targetingrule/model"
 	rsc "core/internal/pkg/resource"
 	"core/pkg/dbutil"
+	"core/pkg/model"
+
+	"github.com/jackc/pgx/v4"
 )
 
+// CreateRuleVariations adds the variations to the rule in a single transaction,
+// variations which are already part of the rule are kept as is
 // TODO: targetingrulerepo should use this instead
 func (r *Repo) CreateRuleVariations(
 	ctx context.Context,
 	i targetingrulemodel.TargetingRule,
 	a targetingrulemodel.RootArgs,
 ) (*targetingrulemodel.TargetingRule, error) {
-	var err error
-	for _, rv := range i.RuleVariations {
-		sqlStatement := `
+	err := r.DB.BeginFunc(ctx, func(tx pgx.Tx) error {
+		for _, rv := range i.RuleVariations {
+			sqlStatement := `
 INSERT INTO
   targeting_rule_variation(
     weight,
@@ -41,13 +46,8 @@ VALUES
         AND v.key = $6
     )
   )
-ON CONFLICT (targeting_rule_id, variation_id) DO NOTHING
-RETURNING
-  weight`
-		err = dbutil.ParseError(
-			rsc.FallthroughVariation.String(),
-			a,
-			r.DB.QueryRow(
+ON CONFLICT (targeting_rule_id, variation_id) DO NOTHING`
+			if _, err := tx.Exec(
 				ctx,
 				sqlStatement,
 				rv.Weight,
@@ -56,10 +56,133 @@ RETURNING
 				a.ProjectKey,
 				a.FlagKey,
 				rv.VariationKey,
-			).Scan(&rv.Weight),
-		)
-		i.RuleVariations = append(i.RuleVariations, rv)
-	}
+			); err != nil {
+				return dbutil.ParseError(
+					rsc.RuleVariation.String(),
+					a,
+					err,
+				)
+			}
+		}
+		return nil
+	})
 
 	return &i, err
 }
+
+func (r *Repo) listClauses(
+	ctx context.Context,
+	ruleID string,
+) ([]*model.Clause, error) {
+	var o []*model.Clause
+	sqlStatement := `
+SELECT
+  trc.trait_key,
+  COALESCE(trc.trait_value, ''),
+  trc.trait_values,
+  trc.operator,
+  trc.negate
+FROM targeting_rule_clause trc
+WHERE trc.targeting_rule_id = $1
+ORDER BY trc.position`
+	rows, err := r.DB.Query(
+		ctx,
+		sqlStatement,
+		ruleID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var _o model.Clause
+		var traitValues []string
+		if err = rows.Scan(
+			&_o.TraitKey,
+			&_o.TraitValue,
+			&traitValues,
+			&_o.Operator,
+			&_o.Negate,
+		); err != nil {
+			return nil, err
+		}
+		_o.TraitValues = model.NewValueSet(traitValues...)
+		o = append(o, &_o)
+	}
+	return o, rows.Err()
+}
+
+func (r *Repo) createClauses(
+	ctx context.Context,
+	tx pgx.Tx,
+	ruleID string,
+	clauses []*model.Clause,
+	a interface{},
+) error {
+	for idx, c := range clauses {
+		sqlStatement := `
+INSERT INTO
+  targeting_rule_clause(
+    position,
+    trait_key,
+    trait_value,
+    operator,
+    negate,
+    targeting_rule_id,
+    trait_values
+  )
+VALUES
+  (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+  )`
+		if _, err := tx.Exec(
+			ctx,
+			sqlStatement,
+			idx,
+			c.TraitKey,
+			c.TraitValue,
+			c.Operator,
+			c.Negate,
+			ruleID,
+			c.TraitValues.Values(),
+		); err != nil {
+			return dbutil.ParseError(
+				rsc.TargetingRuleClause.String(),
+				a,
+				err,
+			)
+		}
+	}
+	return nil
+}
+
+func (r *Repo) replaceClauses(
+	ctx context.Context,
+	tx pgx.Tx,
+	ruleID string,
+	clauses []*model.Clause,
+	a interface{},
+) error {
+	sqlStatement := `
+DELETE FROM targeting_rule_clause
+WHERE targeting_rule_id = $1`
+	if _, err := tx.Exec(
+		ctx,
+		sqlStatement,
+		ruleID,
+	); err != nil {
+		return dbutil.ParseError(
+			rsc.TargetingRuleClause.String(),
+			a,
+			err,
+		)
+	}
+	return r.createClauses(ctx, tx, ruleID, clauses, a)
+}
@@ -8,8 +8,11 @@ import (
 	cons "core/internal/pkg/constants"
 	rsc "core/internal/pkg/resource"
 	"core/internal/pkg/srvenv"
+	"core/pkg/evaluator"
+	"core/pkg/model"
 	"core/pkg/patch"
 	res "core/pkg/response"
+	"fmt"
 )
 
 type Service struct {
@@ -67,6 +70,20 @@ func (s *Service) Create(
 		return nil, &e
 	}
 
+	if i.Match == "" {
+		i.Match = model.MatchAll
+	}
+
+	if err := evaluator.ValidateWeights(i.RuleVariations); err != nil {
+		e.Append(cons.ErrorInput, fmt.Sprintf("rule variations: %s", err.Error()))
+		return nil, &e
+	}
+
+	if err := evaluator.ValidateClauses(ruleClauses(i)...); err != nil {
+		e.Append(cons.ErrorInput, err.Error())
+		return nil, &e
+	}
+
 	r, err := s.TargetingRuleRepo.Create(ctx, i, a)
 	if err != nil {
 		e.Append(cons.ErrorInput, err.Error())
@@ -116,6 +133,16 @@ func (s *Service) Update(
 		cancel()
 	}
 
+	if err := evaluator.ValidateWeights(o.RuleVariations); err != nil {
+		e.Append(cons.ErrorInput, fmt.Sprintf("rule variations: %s", err.Error()))
+		return r, &e
+	}
+
+	if err := evaluator.ValidateClauses(ruleClauses(o)...); err != nil {
+		e.Append(cons.ErrorInput, err.Error())
+		return r, &e
+	}
+
 	r, err = s.TargetingRuleRepo.Update(ctx, o, a)
 	if err != nil {
 		e.Append(cons.ErrorInternal, err.Error())
@@ -140,3 +167,12 @@ func (s *Service) Delete(
 
 	return &e
 }
+
+// ruleClauses the rule's own trait condition followed by its clauses
+func ruleClauses(i targetingrulemodel.TargetingRule) []*model.Clause {
+	return append([]*model.Clause{{
+		TraitKey:   i.TraitKey,
+		TraitValue: i.TraitValue,
+		Operator:   i.Operator,
+	}}, i.Clauses...)
+}
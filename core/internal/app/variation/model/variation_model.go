@@ -1,6 +1,9 @@
 package model
 
-import rsc "core/internal/pkg/resource"
+import (
+	rsc "core/internal/pkg/resource"
+	"core/pkg/model"
+)
 
 // Variation represents a unique state of a feature flag.
 type Variation struct {
@@ -9,4 +12,5 @@ type Variation struct {
 	Name        rsc.Name        `json:"name,omitempty" jsonapi:"attr,name,omitempty"`
 	Description rsc.Description `json:"description,omitempty" jsonapi:"attr,description,omitempty"`
 	Tags        rsc.Tags        `json:"tags,omitempty" jsonapi:"attr,tags,omitempty"`
+	Value       model.Value     `json:"value,omitempty" jsonapi:"attr,value,omitempty"`
 }
@@ -8,7 +8,6 @@ import (
 	"core/pkg/dbutil"
 
 	"github.com/jackc/pgx/v4/pgxpool"
-	"github.com/lib/pq"
 )
 
 type Repo struct {
@@ -32,7 +31,8 @@ SELECT
   v.key,
   v.name,
   v.description,
-  v.tags
+  v.tags,
+  v.value
 FROM variation v
 LEFT JOIN flag f
   ON f.id = v.flag_id
@@ -61,6 +61,7 @@ WHERE w.key = $1
 			&_o.Name,
 			&_o.Description,
 			&_o.Tags,
+			(*[]byte)(&_o.Value),
 		); err != nil {
 			return nil, err
 		}
@@ -82,6 +83,7 @@ INSERT INTO
     name,
     description,
     tags,
+    value,
     flag_id
   )
 VALUES
@@ -90,6 +92,7 @@ VALUES
     $2,
     $3,
     $4,
+    $8,
     (
       SELECT f.id
       FROM flag f
@@ -107,7 +110,8 @@ RETURNING
   key,
   name,
   description,
-  tags;`
+  tags,
+  value;`
 	err := dbutil.ParseError(
 		rsc.Variation.String(),
 		variationmodel.ResourceArgs{
@@ -122,16 +126,18 @@ RETURNING
 			i.Key,
 			i.Name,
 			i.Description,
-			pq.Array(i.Tags),
+			i.Tags,
 			a.WorkspaceKey,
 			a.ProjectKey,
 			a.FlagKey,
+			[]byte(i.Value),
 		).Scan(
 			&o.ID,
 			&o.Key,
 			&o.Name,
 			&o.Description,
 			&o.Tags,
+			(*[]byte)(&o.Value),
 		),
 	)
 	return &o, err
@@ -148,7 +154,8 @@ SELECT
   v.key,
   v.name,
   v.description,
-  v.tags
+  v.tags,
+  v.value
 FROM variation v
 LEFT JOIN flag f
   ON f.id = v.flag_id
@@ -176,6 +183,7 @@ WHERE w.key = $1
 			&o.Name,
 			&o.Description,
 			&o.Tags,
+			(*[]byte)(&o.Value),
 		),
 	)
 	return &o, err
@@ -192,7 +200,8 @@ SET
   key = $2,
   name = $3,
   description = $4,
-  tags = $5
+  tags = $5,
+  value = $6
 WHERE id = $1`
 	if _, err := r.DB.Exec(
 		ctx,
@@ -201,7 +210,8 @@ WHERE id = $1`
 		i.Key,
 		i.Name,
 		i.Description,
-		pq.Array(i.Tags),
+		i.Tags,
+		[]byte(i.Value),
 	); err != nil {
 		return &i, dbutil.ParseError(
 			rsc.Variation.String(),
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	environmentrepo "core/internal/app/environment/repository"
+	flagrepo "core/internal/app/flag/repository"
 	targetingrepo "core/internal/app/targeting/repository"
 	targetingrulerepo "core/internal/app/targetingrule/repository"
 	variationmodel "core/internal/app/variation/model"
@@ -18,6 +19,7 @@ import (
 type Service struct {
 	Senv              *srvenv.Env
 	VariationRepo     *variationrepo.Repo
+	FlagRepo          *flagrepo.Repo
 	EnvironmentRepo   *environmentrepo.Repo
 	TargetingRepo     *targetingrepo.Repo
 	TargetingRuleRepo *targetingrulerepo.Repo
@@ -27,6 +29,7 @@ func NewService(senv *srvenv.Env) *Service {
 	return &Service{
 		Senv:              senv,
 		VariationRepo:     variationrepo.NewRepo(senv),
+		FlagRepo:          flagrepo.NewRepo(senv),
 		EnvironmentRepo:   environmentrepo.NewRepo(senv),
 		TargetingRepo:     targetingrepo.NewRepo(senv),
 		TargetingRuleRepo: targetingrulerepo.NewRepo(senv),
@@ -76,6 +79,11 @@ func (s *Service) Create(
 		return nil, &e
 	}
 
+	if err := s.validateValue(ctx, i.Value, a); !err.IsEmpty() {
+		e.Extend(err)
+		return nil, &e
+	}
+
 	r, err := s.VariationRepo.Create(ctx, i, a)
 	if err != nil {
 		e.Append(cons.ErrorInput, err.Error())
@@ -129,6 +137,15 @@ func (s *Service) Update(
 		cancel()
 	}
 
+	if err := s.validateValue(ctx, o.Value, variationmodel.RootArgs{
+		WorkspaceKey: a.WorkspaceKey,
+		ProjectKey:   a.ProjectKey,
+		FlagKey:      a.FlagKey,
+	}); !err.IsEmpty() {
+		e.Extend(err)
+		return r, &e
+	}
+
 	r, err = s.VariationRepo.Update(ctx, o, a)
 	if err != nil {
 		e.Append(cons.ErrorInternal, err.Error())
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	environment "core/internal/app/environment/model"
+	flagmodel "core/internal/app/flag/model"
 	targetingmodel "core/internal/app/targeting/model"
 	targetingrulemodel "core/internal/app/targetingrule/model"
 	variationmodel "core/internal/app/variation/model"
@@ -79,3 +80,28 @@ func (s *Service) createChildren(
 
 	return &e
 }
+
+// validateValue checks if the value can be served by the flag's value type
+func (s *Service) validateValue(
+	ctx context.Context,
+	v model.Value,
+	a variationmodel.RootArgs,
+) *res.Errors {
+	var e res.Errors
+
+	f, err := s.FlagRepo.Get(ctx, flagmodel.ResourceArgs{
+		WorkspaceKey: a.WorkspaceKey,
+		ProjectKey:   a.ProjectKey,
+		FlagKey:      a.FlagKey,
+	})
+	if err != nil {
+		e.Append(cons.ErrorNotFound, err.Error())
+		return &e
+	}
+
+	if err := f.ValueType.Validate(v); err != nil {
+		e.Append(cons.ErrorInput, err.Error())
+	}
+
+	return &e
+}
@@ -8,7 +8,6 @@ import (
 	"core/pkg/dbutil"
 
 	"github.com/jackc/pgx/v4/pgxpool"
-	"github.com/lib/pq"
 )
 
 type Repo struct {
@@ -92,7 +91,7 @@ RETURNING
 			i.Key,
 			i.Name,
 			i.Description,
-			pq.Array(i.Tags),
+			i.Tags,
 		).Scan(
 			&o.ID,
 			&o.Key,
@@ -156,7 +155,7 @@ WHERE key = $1`
 		i.Key,
 		i.Name,
 		i.Description,
-		pq.Array(i.Tags),
+		i.Tags,
 	); err != nil {
 		return &i, dbutil.ParseError(
 			rsc.Workspace.String(),
@@ -6,6 +6,7 @@ import (
 	flagtransport "core/internal/app/flag/transport"
 	healthchecktransport "core/internal/app/healthcheck/transport"
 	identitytransport "core/internal/app/identity/transport"
+	layertransport "core/internal/app/layer/transport"
 	projecttransport "core/internal/app/project/transport"
 	segmenttransport "core/internal/app/segment/transport"
 	targetingtransport "core/internal/app/targeting/transport"
@@ -26,6 +27,7 @@ func ApplyRoutes(senv *srvenv.Env, r *gin.Engine) {
 	evaluationtransport.ApplyRoutes(senv, root)
 	healthchecktransport.ApplyRoutes(senv, root)
 	identitytransport.ApplyRoutes(senv, root)
+	layertransport.ApplyRoutes(senv, root)
 	projecttransport.ApplyRoutes(senv, root)
 	targetingtransport.ApplyRoutes(senv, root)
 	traittransport.ApplyRoutes(senv, root)
@@ -7,16 +7,15 @@ import (
 	"log"
 
 	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/pgx"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
-	_ "github.com/lib/pq"
 )
 
 // Migrate runs the migrations on the provided database URL using the migrations embedded in the given directory.
 func Migrate(dbURL string, isUpwards bool) error {
 	log.Printf("running migrations...")
 
-	db, err := sql.Open("postgres", dbURL)
+	db, err := sql.Open("pgx", dbURL)
 	if err != nil {
 		return fmt.Errorf("failed to create database instance: %w", err)
 	}
@@ -26,7 +25,7 @@ func Migrate(dbURL string, isUpwards bool) error {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	dbDriver, err := postgres.WithInstance(db, &postgres.Config{})
+	dbDriver, err := pgx.WithInstance(db, &pgx.Config{})
 	if err != nil {
 		return fmt.Errorf("failed to create database driver: %w", err)
 	}
@@ -36,7 +35,7 @@ func Migrate(dbURL string, isUpwards bool) error {
 		return fmt.Errorf("failed to create source instance: %w", err)
 	}
 
-	m, err := migrate.NewWithInstance("iofs", sourceInstance, "postgres", dbDriver)
+	m, err := migrate.NewWithInstance("iofs", sourceInstance, "pgx", dbDriver)
 	if err != nil {
 		return fmt.Errorf("failed to create migration instance: %w", err)
 	}
@@ -2,6 +2,7 @@ package poller
 
 import (
 	"context"
+	evaluationservice "core/internal/app/evaluation/service"
 	rsc "core/internal/pkg/resource"
 	"core/internal/pkg/srvenv"
 	"core/pkg/model"
@@ -28,3 +29,11 @@ type CachedServiceArgs struct {
 	RootHeaders RootHeaders
 	CacheKey    string
 }
+
+// FlagsetCache caches the environments of SDK keys along with their flagsets (see Cache)
+type FlagsetCache interface {
+	Environment(a CachedServiceArgs, serverKey bool) (*Environment, error)
+	Version(a CachedServiceArgs, env *Environment) (*Version, error)
+	Flagset(a CachedServiceArgs, env *Environment) (*Flagset, error)
+	Loader(a CachedServiceArgs, env *Environment) evaluationservice.FlagsetLoader
+}
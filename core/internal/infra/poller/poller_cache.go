@@ -0,0 +1,365 @@
+package poller
+
+import (
+	"context"
+	evaluationmodel "core/internal/app/evaluation/model"
+	evaluationrepo "core/internal/app/evaluation/repository"
+	evaluationservice "core/internal/app/evaluation/service"
+	sdkkeymodel "core/internal/app/sdkkey/model"
+	sdkkeyservice "core/internal/app/sdkkey/service"
+	"core/internal/pkg/changefeed"
+	rsc "core/internal/pkg/resource"
+	"core/internal/pkg/srvenv"
+	"core/pkg/hashutil"
+	"core/pkg/model"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"golang.org/x/sync/singleflight"
+)
+
+// cachePrefix prefix of every key cached by the poller
+const cachePrefix = "poller"
+
+// Cache caches what every poll relies on (i.e. the environment of the SDK key along with the
+// environment's flagset) in redis. Cache keys include generations, which are incremented upon
+// configuration changes, so entries loaded before a change are never served after it.
+type Cache struct {
+	senv           *srvenv.Env
+	expiry         time.Duration
+	group          singleflight.Group
+	sdkKeyService  *sdkkeyservice.Service
+	evaluationRepo *evaluationrepo.Repo
+
+	// decoded flagsets by environment ID, flagsets are decoded (and compiled)
+	// once per ETag rather than once per evaluation
+	decodedMu sync.Mutex
+	decoded   map[string]*decodedFlagset
+}
+
+// decodedFlagset a decoded flagset along with the ETag it was decoded from
+type decodedFlagset struct {
+	etag    string
+	flagset *evaluationmodel.Flagset
+}
+
+// Environment the environment an SDK key belongs to
+type Environment struct {
+	RootArgs evaluationmodel.RootArgs `json:"rootArgs"`
+	Scope    evaluationmodel.Scope    `json:"scope"`
+}
+
+// Version identifies a response, i.e. its ETag along with the
+// revision of the environment's configuration it was derived from
+type Version struct {
+	ETag     string `json:"etag"`
+	Revision int64  `json:"revision"`
+}
+
+// Flagset an environment's raw flagset, serialized along with its version
+type Flagset struct {
+	Version
+	Flags json.RawMessage `json:"flags"`
+}
+
+// Decode decodes the flagset's flags
+func (f *Flagset) Decode() ([]*model.Flag, error) {
+	var o []*model.Flag
+	err := json.Unmarshal(f.Flags, &o)
+	return o, err
+}
+
+// NewCache creates a cache, cached entries expire after expiry
+func NewCache(senv *srvenv.Env, expiry time.Duration) *Cache {
+	c := &Cache{
+		senv:           senv,
+		expiry:         expiry,
+		sdkKeyService:  sdkkeyservice.NewService(senv),
+		evaluationRepo: evaluationrepo.NewRepo(senv),
+		decoded:        make(map[string]*decodedFlagset),
+	}
+	if senv.Changes != nil {
+		senv.Changes.Subscribe(c.invalidate)
+	}
+	return c
+}
+
+// Environment gets the environment of an SDK key, which has to
+// be a server key unless any type of SDK key is accepted
+func (c *Cache) Environment(a CachedServiceArgs, serverKey bool) (*Environment, error) {
+	kind := "sdk"
+	if serverKey {
+		kind = "server"
+	}
+
+	var o Environment
+	err := c.fetch(
+		a,
+		[]string{
+			generationKey(),
+			generationKey(rsc.SDKKey.String()),
+		},
+		&o,
+		func(ctx context.Context) (interface{}, error) {
+			var r *sdkkeymodel.RootArgs
+			var err error
+			if serverKey {
+				r, err = c.sdkKeyService.GetRootArgsFromServerKey(a.RootHeaders.SDKKey)
+			} else {
+				r, err = c.sdkKeyService.GetRootArgsFromSDKKey(a.RootHeaders.SDKKey)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			ra := evaluationmodel.RootArgs{
+				WorkspaceKey:   r.WorkspaceKey,
+				ProjectKey:     r.ProjectKey,
+				EnvironmentKey: r.EnvironmentKey,
+			}
+			scope, err := c.evaluationRepo.GetScope(ctx, ra)
+			if err != nil {
+				return nil, err
+			}
+
+			return &Environment{RootArgs: ra, Scope: *scope}, nil
+		},
+		rsc.SDKKey.String(),
+		kind,
+		// SDK keys are secrets, so they aren't used as is
+		hashutil.HashKeys(a.RootHeaders.SDKKey),
+	)
+	return &o, err
+}
+
+// Flagset gets the raw flagset of the environment
+func (c *Cache) Flagset(a CachedServiceArgs, env *Environment) (*Flagset, error) {
+	var o Flagset
+	err := c.fetch(
+		a,
+		[]string{
+			generationKey(),
+			generationKey(rsc.Project.String(), env.Scope.ProjectID),
+			generationKey(rsc.Environment.String(), env.Scope.EnvironmentID),
+		},
+		&o,
+		func(ctx context.Context) (interface{}, error) {
+			r, err := c.evaluationRepo.GetFlagset(ctx, env.RootArgs)
+			if err != nil {
+				return nil, err
+			}
+
+			rBytes, err := json.Marshal(r.Flags)
+			if err != nil {
+				return nil, err
+			}
+
+			return &Flagset{
+				Version: Version{
+					ETag:     hashutil.HashKeys(string(rBytes)),
+					Revision: r.Revision,
+				},
+				Flags: rBytes,
+			}, nil
+		},
+		"flagset",
+		env.Scope.EnvironmentID,
+	)
+	return &o, err
+}
+
+// Version gets the version of the environment's flagset, which is cached separately,
+// so checking whether clients are up to date doesn't require the flagset
+func (c *Cache) Version(a CachedServiceArgs, env *Environment) (*Version, error) {
+	var o Version
+	err := c.fetch(
+		a,
+		[]string{
+			generationKey(),
+			generationKey(rsc.Project.String(), env.Scope.ProjectID),
+			generationKey(rsc.Environment.String(), env.Scope.EnvironmentID),
+		},
+		&o,
+		func(ctx context.Context) (interface{}, error) {
+			r, err := c.Flagset(a, env)
+			if err != nil {
+				return nil, err
+			}
+			return &r.Version, nil
+		},
+		"version",
+		env.Scope.EnvironmentID,
+	)
+	return &o, err
+}
+
+// Loader serves the environment's cached flagset to the evaluation service
+func (c *Cache) Loader(a CachedServiceArgs, env *Environment) evaluationservice.FlagsetLoader {
+	return &flagsetLoader{cache: c, args: a, env: env}
+}
+
+// flagsetLoader loads the cached flagset of a single environment
+type flagsetLoader struct {
+	cache *Cache
+	args  CachedServiceArgs
+	env   *Environment
+}
+
+// GetFlagset gets the environment's flags along with its revision, the decoded flagset
+// is reused as long as the version of the environment's flagset doesn't change
+func (l *flagsetLoader) GetFlagset(
+	ctx context.Context,
+	a evaluationmodel.RootArgs,
+) (*evaluationmodel.Flagset, error) {
+	envID := l.env.Scope.EnvironmentID
+	v, err := l.cache.Version(l.args, l.env)
+	if err != nil {
+		return nil, err
+	}
+	if o := l.cache.getDecoded(envID, v.ETag); o != nil {
+		return o, nil
+	}
+
+	r, err := l.cache.Flagset(l.args, l.env)
+	if err != nil {
+		return nil, err
+	}
+	flags, err := r.Decode()
+	if err != nil {
+		return nil, err
+	}
+	o := &evaluationmodel.Flagset{
+		Revision: r.Revision,
+		Flags:    flags,
+	}
+	// the flagset is stored under the ETag it was decoded from,
+	// which might be newer than the version checked above
+	l.cache.setDecoded(envID, r.ETag, o)
+	return o, nil
+}
+
+// getDecoded gets the environment's decoded flagset if it was decoded from etag
+func (c *Cache) getDecoded(envID string, etag string) *evaluationmodel.Flagset {
+	c.decodedMu.Lock()
+	defer c.decodedMu.Unlock()
+	if d, ok := c.decoded[envID]; ok && d.etag == etag {
+		return d.flagset
+	}
+	return nil
+}
+
+// setDecoded replaces the environment's decoded flagset
+func (c *Cache) setDecoded(envID string, etag string, flagset *evaluationmodel.Flagset) {
+	c.decodedMu.Lock()
+	defer c.decodedMu.Unlock()
+	c.decoded[envID] = &decodedFlagset{etag: etag, flagset: flagset}
+}
+
+// fetch gets the cached entry, loading it once (no matter how many requests are waiting on it) if
+// it's missing. The entry is loaded directly if redis is unavailable, cache failures aren't fatal.
+func (c *Cache) fetch(
+	a CachedServiceArgs,
+	generationKeys []string,
+	o interface{},
+	load func(ctx context.Context) (interface{}, error),
+	name ...string,
+) error {
+	if a.Ctx == nil {
+		a.Ctx = context.Background()
+	}
+	a.CacheKey = strings.Join(append([]string{cachePrefix}, name...), ":")
+
+	gen, err := c.generation(generationKeys)
+	if err != nil {
+		c.senv.Log.Warn().Str("cacheKey", a.CacheKey).Msg(err.Error())
+	} else {
+		a.CacheKey = strings.Join([]string{a.CacheKey, gen}, ":")
+		b, err := c.senv.Cache.WithContext(a.Ctx).Get(a.CacheKey).Bytes()
+		if err == nil {
+			return json.Unmarshal(b, o)
+		}
+		if err != redis.Nil {
+			c.senv.Log.Warn().Str("cacheKey", a.CacheKey).Msg(err.Error())
+		}
+	}
+
+	r, err, _ := c.group.Do(a.CacheKey, func() (interface{}, error) {
+		// waiting requests shouldn't fail if the request loading the entry is cancelled
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		r, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		rBytes, err := json.Marshal(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if gen != "" {
+			if err := c.senv.Cache.WithContext(ctx).Set(a.CacheKey, rBytes, c.expiry).Err(); err != nil {
+				c.senv.Log.Warn().Str("cacheKey", a.CacheKey).Msg(err.Error())
+			}
+		}
+
+		return rBytes, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(r.([]byte), o)
+}
+
+// generation joins the generations of the keys (missing generations are 0)
+func (c *Cache) generation(keys []string) (string, error) {
+	r, err := c.senv.Cache.MGet(keys...).Result()
+	if err != nil {
+		return "", err
+	}
+	gens := make([]string, len(r))
+	for idx, _r := range r {
+		gens[idx] = "0"
+		if gen, ok := _r.(string); ok {
+			gens[idx] = gen
+		}
+	}
+	return strings.Join(gens, "."), nil
+}
+
+// invalidate increments the generations affected by the change, i.e. the generation of the
+// environment, its project, SDK keys or everything (when notifications were missed). Renaming a
+// workspace, project or environment also invalidates the environments of the SDK keys, which
+// are cached along with their keys.
+func (c *Cache) invalidate(change *changefeed.Change) {
+	var keys []string
+	switch {
+	case change.Resync():
+		keys = append(keys, generationKey())
+	case change.Table == rsc.SDKKey.String():
+		keys = append(keys, generationKey(rsc.SDKKey.String()))
+	case change.EnvironmentID != "":
+		keys = append(keys, generationKey(rsc.Environment.String(), change.EnvironmentID))
+	default:
+		keys = append(keys, generationKey(rsc.Project.String(), change.ProjectID))
+	}
+	switch change.Table {
+	case rsc.Workspace.String(), rsc.Project.String(), rsc.Environment.String():
+		keys = append(keys, generationKey(rsc.SDKKey.String()))
+	}
+
+	for _, key := range keys {
+		if err := c.senv.Cache.Incr(key).Err(); err != nil {
+			c.senv.Log.Error().Str("cacheKey", key).Msg(err.Error())
+		}
+	}
+}
+
+func generationKey(name ...string) string {
+	return strings.Join(append([]string{cachePrefix, "generation"}, name...), ":")
+}
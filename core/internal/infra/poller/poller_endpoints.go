@@ -1,8 +1,10 @@
 package poller
 
 import (
+	evaluationmodel "core/internal/app/evaluation/model"
 	cons "core/internal/pkg/constants"
 	"core/internal/pkg/httputil"
+	rsc "core/internal/pkg/resource"
 	"core/internal/pkg/srvenv"
 	"core/pkg/model"
 	res "core/pkg/response"
@@ -11,24 +13,43 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// APIHandler poller handler context
+type APIHandler struct {
+	Senv  *srvenv.Env
+	Cache FlagsetCache
+}
+
 // ApplyRoutes applies route from all packages to root handler
-func ApplyRoutes(senv *srvenv.Env, r *gin.Engine) {
+func ApplyRoutes(senv *srvenv.Env, r *gin.Engine, cache FlagsetCache) {
 	// https://flagbase.atlassian.net/browse/OSS-125
 	// httpmetrics.ApplyMetrics(r, "poller")
+	h := &APIHandler{
+		Senv:  senv,
+		Cache: cache,
+	}
 	rootPath := ""
 	routes := r.Group(rootPath)
-	routes.GET(rootPath, httputil.Handler(senv, getEvaluationAPIHandler))
-	routes.POST(rootPath, httputil.Handler(senv, evaluateAPIHandler))
+	routes.GET(rootPath, h.getEvaluationAPIHandler)
+	routes.POST(rootPath, h.evaluateAPIHandler)
+	routes.POST(
+		httputil.AppendPath(rootPath, rsc.FlagKey),
+		h.evaluateFlagAPIHandler,
+	)
+	routes.POST(
+		httputil.AppendRoute(rootPath, rsc.RouteBatch),
+		h.evaluateBatchAPIHandler,
+	)
 }
 
-func getEvaluationAPIHandler(senv *srvenv.Env, ctx *gin.Context) {
+func (h *APIHandler) getEvaluationAPIHandler(ctx *gin.Context) {
 	var e res.Errors
 
-	etag := ctx.Request.Header.Get("ETag")
+	etag := httputil.IfNoneMatch(ctx)
 
-	r, retag, _e := Get(
-		senv,
-		httputil.SecureOverideATK(senv),
+	r, v, _e := Get(
+		h.Senv,
+		h.Cache,
+		httputil.SecureOverideATK(h.Senv),
 		etag,
 		RootHeaders{
 			SDKKey: ctx.Request.Header.Get("x-sdk-key"),
@@ -38,43 +59,45 @@ func getEvaluationAPIHandler(senv *srvenv.Env, ctx *gin.Context) {
 		e.Extend(_e)
 	}
 
-	ctx.Header("ETag", retag)
-	statusCode := http.StatusOK
-	if e.IsEmpty() && retag == etag {
-		statusCode = http.StatusNotModified
-		httputil.Send(
-			ctx,
-			statusCode,
-			nil,
-			http.StatusInternalServerError,
-			e,
-		)
+	if e.IsEmpty() {
+		ctx.Header("ETag", httputil.StrongETag(v.ETag))
+		httputil.SetRevision(ctx, v.Revision)
+		httputil.SetBucketSize(ctx)
+		if httputil.ETagMatches(etag, v.ETag) {
+			httputil.SendNotModified(ctx)
+			return
+		}
 	}
 
 	httputil.SendJSON(
 		ctx,
-		statusCode,
+		http.StatusOK,
 		r,
 		http.StatusInternalServerError,
 		e,
 	)
 }
 
-func evaluateAPIHandler(senv *srvenv.Env, ctx *gin.Context) {
+func (h *APIHandler) evaluateAPIHandler(ctx *gin.Context) {
 	var e res.Errors
 
-	etag := ctx.Request.Header.Get("ETag")
+	etag := httputil.IfNoneMatch(ctx)
 
-	var ectx model.Context
-	if err := ctx.BindJSON(&ectx); err != nil {
+	var i evaluationmodel.EvaluateInput
+	if err := ctx.BindJSON(&i); err != nil {
 		e.Append(cons.ErrorInternal, err.Error())
 	}
 
-	r, retag, _e := Evaluate(
-		senv,
-		httputil.SecureOverideATK(senv),
+	r, v, _e := Evaluate(
+		h.Senv,
+		h.Cache,
+		httputil.SecureOverideATK(h.Senv),
 		etag,
-		ectx,
+		i.Context,
+		evaluationmodel.Options{
+			Explain:  httputil.GetQueryBool(ctx, "explain"),
+			FlagKeys: i.FlagKeys,
+		},
 		RootHeaders{
 			SDKKey: ctx.Request.Header.Get("x-sdk-key"),
 		},
@@ -83,24 +106,88 @@ func evaluateAPIHandler(senv *srvenv.Env, ctx *gin.Context) {
 		e.Extend(_e)
 	}
 
-	ctx.Header("ETag", retag)
-	statusCode := http.StatusOK
-	if e.IsEmpty() && retag == etag {
-		statusCode = http.StatusNotModified
-		httputil.Send(
-			ctx,
-			statusCode,
-			nil,
-			http.StatusInternalServerError,
-			e,
-		)
+	if e.IsEmpty() {
+		ctx.Header("ETag", httputil.StrongETag(v.ETag))
+		httputil.SetRevision(ctx, v.Revision)
+		if httputil.ETagMatches(etag, v.ETag) {
+			httputil.SendNotModified(ctx)
+			return
+		}
 	}
 
 	httputil.SendJSON(
 		ctx,
-		statusCode,
+		http.StatusOK,
 		*r,
 		http.StatusInternalServerError,
 		e,
 	)
 }
+
+func (h *APIHandler) evaluateFlagAPIHandler(ctx *gin.Context) {
+	var e res.Errors
+
+	etag := httputil.IfNoneMatch(ctx)
+
+	var i evaluationmodel.EvaluateFlagInput
+	if err := ctx.BindJSON(&i); err != nil {
+		e.Append(cons.ErrorInternal, err.Error())
+	}
+
+	r, v, _e := EvaluateFlag(
+		h.Senv,
+		h.Cache,
+		httputil.SecureOverideATK(h.Senv),
+		etag,
+		i,
+		evaluationmodel.Options{
+			Explain: httputil.GetQueryBool(ctx, "explain"),
+		},
+		httputil.GetParam(ctx, rsc.FlagKey),
+		RootHeaders{
+			SDKKey: ctx.Request.Header.Get("x-sdk-key"),
+		},
+	)
+	if !_e.IsEmpty() {
+		e.Extend(_e)
+	}
+
+	if e.IsEmpty() {
+		ctx.Header("ETag", httputil.StrongETag(v.ETag))
+		httputil.SetRevision(ctx, v.Revision)
+		if httputil.ETagMatches(etag, v.ETag) {
+			httputil.SendNotModified(ctx)
+			return
+		}
+	}
+
+	httputil.SendJSON(
+		ctx,
+		http.StatusOK,
+		*r,
+		http.StatusInternalServerError,
+		e,
+	)
+}
+
+func (h *APIHandler) evaluateBatchAPIHandler(ctx *gin.Context) {
+	var e res.Errors
+
+	httputil.SendBatch(ctx, &e, func(
+		contexts []model.Context,
+		opts evaluationmodel.Options,
+		emit func(o *model.BatchEvaluation) error,
+	) *res.Errors {
+		return EvaluateBatch(
+			h.Senv,
+			h.Cache,
+			httputil.SecureOverideATK(h.Senv),
+			contexts,
+			opts,
+			RootHeaders{
+				SDKKey: ctx.Request.Header.Get("x-sdk-key"),
+			},
+			emit,
+		)
+	})
+}
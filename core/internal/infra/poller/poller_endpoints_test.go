@@ -0,0 +1,285 @@
+package poller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	evaluationmodel "core/internal/app/evaluation/model"
+	evaluationservice "core/internal/app/evaluation/service"
+	cons "core/internal/pkg/constants"
+	"core/internal/pkg/httputil"
+	"core/internal/pkg/srvenv"
+	"core/pkg/logger"
+	"core/pkg/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var testVersion = Version{ETag: "c0ffee", Revision: 42}
+
+// fakeCache serves a single environment's flagset, counting how often the flagset is loaded
+type fakeCache struct {
+	flagsetLoads int
+}
+
+func (c *fakeCache) Environment(a CachedServiceArgs, serverKey bool) (*Environment, error) {
+	if a.RootHeaders.SDKKey != "sdk-server-key" {
+		return nil, errors.New("cannot find SDK key")
+	}
+	return &Environment{
+		RootArgs: evaluationmodel.RootArgs{
+			WorkspaceKey:   "workspace",
+			ProjectKey:     "project",
+			EnvironmentKey: "environment",
+		},
+	}, nil
+}
+
+func (c *fakeCache) Version(a CachedServiceArgs, env *Environment) (*Version, error) {
+	return &testVersion, nil
+}
+
+func (c *fakeCache) Flagset(a CachedServiceArgs, env *Environment) (*Flagset, error) {
+	c.flagsetLoads++
+	flags, err := json.Marshal(testFlags)
+	return &Flagset{Version: testVersion, Flags: flags}, err
+}
+
+func (c *fakeCache) Loader(a CachedServiceArgs, env *Environment) evaluationservice.FlagsetLoader {
+	return c
+}
+
+func (c *fakeCache) GetFlagset(ctx context.Context, a evaluationmodel.RootArgs) (*evaluationmodel.Flagset, error) {
+	c.flagsetLoads++
+	return &evaluationmodel.Flagset{Revision: testVersion.Revision, Flags: testFlags}, nil
+}
+
+var testFlags = []*model.Flag{
+	{
+		ID:             "flag-id",
+		FlagKey:        "flag",
+		UseFallthrough: true,
+		FallthroughVariations: []*model.Variation{
+			{VariationKey: "on", Weight: 100},
+		},
+	},
+}
+
+func newTestRouter(cache FlagsetCache) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	senv := &srvenv.Env{
+		Log: logger.New(logger.Config{}),
+	}
+	r := gin.New()
+	ApplyRoutes(senv, r, cache)
+	return r
+}
+
+func TestGetEvaluationConditional(t *testing.T) {
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		expectedCode int
+	}{
+		{
+			name:         "no validator",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "strong match",
+			headers:      map[string]string{"If-None-Match": `"c0ffee"`},
+			expectedCode: http.StatusNotModified,
+		},
+		{
+			name:         "weak match",
+			headers:      map[string]string{"If-None-Match": `W/"c0ffee"`},
+			expectedCode: http.StatusNotModified,
+		},
+		{
+			name:         "match in list",
+			headers:      map[string]string{"If-None-Match": `"stale", W/"c0ffee"`},
+			expectedCode: http.StatusNotModified,
+		},
+		{
+			name:         "wildcard",
+			headers:      map[string]string{"If-None-Match": "*"},
+			expectedCode: http.StatusNotModified,
+		},
+		{
+			name:         "mismatch",
+			headers:      map[string]string{"If-None-Match": `"stale"`},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "unquoted legacy etag header",
+			headers:      map[string]string{"ETag": "c0ffee"},
+			expectedCode: http.StatusNotModified,
+		},
+		{
+			name:         "if-none-match takes precedence",
+			headers:      map[string]string{"If-None-Match": `"stale"`, "ETag": "c0ffee"},
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cache := &fakeCache{}
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("x-sdk-key", "sdk-server-key")
+			for k, v := range test.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			newTestRouter(cache).ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+			assert.Equal(t, `"c0ffee"`, w.Header().Get("ETag"))
+			assert.Equal(t, "42", w.Header().Get(httputil.RevisionHeader))
+			assert.Equal(t, "100000", w.Header().Get(httputil.BucketSizeHeader))
+			if test.expectedCode == http.StatusNotModified {
+				assert.Empty(t, w.Body.String())
+				assert.Equal(t, 0, cache.flagsetLoads, "unchanged flagsets shouldn't be loaded")
+			} else {
+				assert.Contains(t, w.Body.String(), `"flag"`)
+				assert.Equal(t, 1, cache.flagsetLoads)
+			}
+		})
+	}
+}
+
+func TestGetEvaluationUnknownSDKKey(t *testing.T) {
+	cache := &fakeCache{}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("x-sdk-key", "unknown")
+	req.Header.Set("If-None-Match", "*")
+	w := httptest.NewRecorder()
+	newTestRouter(cache).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+	assert.Empty(t, w.Header().Get(httputil.RevisionHeader))
+	assert.Contains(t, w.Body.String(), "cannot find SDK key")
+}
+
+func TestEvaluateConditional(t *testing.T) {
+	evaluate := func(headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"identifier":"identity"}`))
+		req.Header.Set("x-sdk-key", "sdk-server-key")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		newTestRouter(&fakeCache{}).ServeHTTP(w, req)
+		return w
+	}
+
+	w := evaluate(nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"on"`)
+	etag := w.Header().Get("ETag")
+	assert.True(t, strings.HasPrefix(etag, `"`) && strings.HasSuffix(etag, `"`), etag)
+	assert.Equal(t, "42", w.Header().Get(httputil.RevisionHeader))
+
+	w = evaluate(map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+	assert.Empty(t, w.Body.String())
+
+	w = evaluate(map[string]string{"If-None-Match": `"stale"`})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"on"`)
+}
+
+func TestEvaluateBatchRevision(t *testing.T) {
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/batch",
+		strings.NewReader(`{"contexts":[{"identifier":"a"},{"identifier":"b"}]}`),
+	)
+	req.Header.Set("x-sdk-key", "sdk-server-key")
+	req.Header.Set("Accept", "application/x-ndjson")
+	w := httptest.NewRecorder()
+	newTestRouter(&fakeCache{}).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "42", w.Header().Get(httputil.RevisionHeader))
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, 2)
+	for _, line := range lines {
+		var o model.BatchEvaluation
+		assert.NoError(t, json.Unmarshal([]byte(line), &o))
+		assert.Equal(t, int64(42), o.Revision)
+	}
+}
+
+func TestEvaluateBatchCollected(t *testing.T) {
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/batch",
+		strings.NewReader(`{"contexts":[{"identifier":"a"},{"identifier":"b"}]}`),
+	)
+	req.Header.Set("x-sdk-key", "sdk-server-key")
+	w := httptest.NewRecorder()
+	newTestRouter(&fakeCache{}).ServeHTTP(w, req)
+
+	// evaluations are reused between contexts, collected ones mustn't change
+	assert.Equal(t, http.StatusOK, w.Code)
+	var o struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &o))
+	if assert.Len(t, o.Data, 2) {
+		assert.Equal(t, "a", o.Data[0].ID)
+		assert.Equal(t, "b", o.Data[1].ID)
+	}
+}
+
+func TestEvaluateBatchDuplicateIdentifiers(t *testing.T) {
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/batch",
+		strings.NewReader(`{"contexts":[{"identifier":""},{"identifier":""},{"identifier":"a"},{"identifier":"a"}]}`),
+	)
+	req.Header.Set("x-sdk-key", "sdk-server-key")
+	req.Header.Set("Accept", "application/x-ndjson")
+	w := httptest.NewRecorder()
+	newTestRouter(&fakeCache{}).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if assert.Len(t, lines, 4) {
+		for idx, identifier := range []string{"", "", "a", "a"} {
+			var o model.BatchEvaluation
+			assert.NoError(t, json.Unmarshal([]byte(lines[idx]), &o))
+			assert.Equal(t, identifier, o.Identifier)
+		}
+	}
+}
+
+func TestEvaluateBatchTooLarge(t *testing.T) {
+	evaluationservice.SetMaxBatchSize(1)
+	defer evaluationservice.SetMaxBatchSize(cons.DefaultMaxBatchSize)
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/batch",
+		strings.NewReader(`{"contexts":[{"identifier":"a"},{"identifier":"b"}]}`),
+	)
+	req.Header.Set("x-sdk-key", "sdk-server-key")
+	req.Header.Set("Accept", "application/x-ndjson")
+	w := httptest.NewRecorder()
+	fc := &fakeCache{}
+	newTestRouter(fc).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, 0, fc.flagsetLoads)
+}
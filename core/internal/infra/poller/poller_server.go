@@ -3,6 +3,9 @@ package poller
 import (
 	"core/internal/pkg/httpserver"
 	"core/internal/pkg/srvenv"
+	"time"
+
+	"github.com/gin-gonic/gin"
 )
 
 // Config polling server configuration
@@ -10,13 +13,17 @@ type Config struct {
 	Host        string
 	PollingPort int
 	Verbose     bool
+	CacheExpiry time.Duration
 }
 
 // New initialize a new HTTP server for polling
 func New(senv *srvenv.Env, cfg Config) {
+	cache := NewCache(senv, cfg.CacheExpiry)
 	httpserver.New(senv, httpserver.Config{
 		Host:     cfg.Host,
 		HTTPPort: cfg.PollingPort,
 		Verbose:  cfg.Verbose,
-	}, ApplyRoutes)
+	}, func(senv *srvenv.Env, r *gin.Engine) {
+		ApplyRoutes(senv, r, cache)
+	})
 }
@@ -3,8 +3,8 @@ package poller
 import (
 	evaluationmodel "core/internal/app/evaluation/model"
 	evaluationservice "core/internal/app/evaluation/service"
-	sdkkeyservice "core/internal/app/sdkkey/service"
 	cons "core/internal/pkg/constants"
+	"core/internal/pkg/httputil"
 	rsc "core/internal/pkg/resource"
 	"core/internal/pkg/srvenv"
 	"core/pkg/hashutil"
@@ -13,86 +13,201 @@ import (
 	"encoding/json"
 )
 
-// Get returns a set raw (non-evaluated) flagsets
+// Get returns a set raw (non-evaluated) flagsets, along with its version. If the client's flagset
+// (i.e. etag) is up to date, only the version is returned (without loading the flagset).
 // (*) atk: access_type <= service
 func Get(
 	senv *srvenv.Env,
+	cache FlagsetCache,
 	atk rsc.Token,
 	etag string,
 	a RootHeaders,
-) ([]*model.Flag, string, *res.Errors) {
+) ([]*model.Flag, *Version, *res.Errors) {
 	var e res.Errors
 
-	evalservice := evaluationservice.NewService(senv)
-	sks := sdkkeyservice.NewService(senv)
+	ca := CachedServiceArgs{
+		Senv:        senv,
+		Atk:         atk,
+		RootHeaders: a,
+	}
+
+	env, _err := cache.Environment(ca, true)
+	if _err != nil {
+		e.Append(cons.ErrorInternal, _err.Error())
+		return nil, &Version{}, &e
+	}
+
+	v, _err := cache.Version(ca, env)
+	if _err != nil {
+		e.Append(cons.ErrorNotFound, _err.Error())
+		return nil, &Version{}, &e
+	}
+	if httputil.ETagMatches(etag, v.ETag) {
+		return nil, v, &e
+	}
+
+	f, _err := cache.Flagset(ca, env)
+	if _err != nil {
+		e.Append(cons.ErrorNotFound, _err.Error())
+		return nil, &Version{}, &e
+	}
+
+	r, _err := f.Decode()
+	if _err != nil {
+		e.Append(cons.ErrorInternal, _err.Error())
+	}
 
-	sksArgs, _err := sks.GetRootArgsFromServerKey(a.SDKKey)
+	return r, &f.Version, &e
+}
+
+// Evaluate returns an evaluated flagset given the user context, along with its version
+// (*) atk: access_type <= service
+func Evaluate(
+	senv *srvenv.Env,
+	cache FlagsetCache,
+	atk rsc.Token,
+	etag string,
+	ectx model.Context,
+	opts evaluationmodel.Options,
+	a RootHeaders,
+) (*model.Evaluations, *Version, *res.Errors) {
+	var e res.Errors
+
+	ca := CachedServiceArgs{
+		Senv:        senv,
+		Atk:         atk,
+		Ectx:        ectx,
+		RootHeaders: a,
+	}
+
+	env, _err := cache.Environment(ca, false)
 	if _err != nil {
 		e.Append(cons.ErrorInternal, _err.Error())
+		return &model.Evaluations{}, &Version{}, &e
 	}
 
-	r, err := evalservice.Get(
+	evalservice := evaluationservice.NewService(senv)
+	evalservice.Flagsets = cache.Loader(ca, env)
+
+	r, revision, err := evalservice.Evaluate(
 		atk,
-		evaluationmodel.RootArgs{
-			WorkspaceKey:   sksArgs.WorkspaceKey,
-			ProjectKey:     sksArgs.ProjectKey,
-			EnvironmentKey: sksArgs.EnvironmentKey,
-		},
+		ectx,
+		opts,
+		env.RootArgs,
 	)
 	if !err.IsEmpty() {
 		e.Extend(err)
 	}
 
-	oBytes, _err := json.Marshal(r)
+	rBytes, _err := json.Marshal(*r)
 	if _err != nil {
 		e.Append(cons.ErrorInternal, _err.Error())
 	}
-	retag := hashutil.HashKeys(
-		string(oBytes),
-	)
+	v := &Version{
+		ETag:     hashutil.HashKeys(string(rBytes)),
+		Revision: revision,
+	}
 
-	return r, retag, &e
+	return r, v, &e
 }
 
-// Evaluate returns an evaluated flagset given the user context
+// EvaluateFlag returns a single evaluated flag given the user context (along with
+// its version), the caller's default is served if the flag doesn't exist
 // (*) atk: access_type <= service
-func Evaluate(
+func EvaluateFlag(
 	senv *srvenv.Env,
+	cache FlagsetCache,
 	atk rsc.Token,
 	etag string,
-	ectx model.Context,
+	i evaluationmodel.EvaluateFlagInput,
+	opts evaluationmodel.Options,
+	flagKey rsc.Key,
 	a RootHeaders,
-) (*model.Evaluations, string, *res.Errors) {
+) (*model.Evaluation, *Version, *res.Errors) {
 	var e res.Errors
 
-	evalservice := evaluationservice.NewService(senv)
-	sks := sdkkeyservice.NewService(senv)
+	ca := CachedServiceArgs{
+		Senv:        senv,
+		Atk:         atk,
+		Ectx:        i.Context,
+		RootHeaders: a,
+	}
 
-	sksArgs, _err := sks.GetRootArgsFromSDKKey(a.SDKKey)
+	env, _err := cache.Environment(ca, false)
 	if _err != nil {
 		e.Append(cons.ErrorInternal, _err.Error())
+		return &model.Evaluation{}, &Version{}, &e
 	}
 
-	r, err := evalservice.Evaluate(
+	evalservice := evaluationservice.NewService(senv)
+	evalservice.Flagsets = cache.Loader(ca, env)
+
+	r, revision, err := evalservice.EvaluateFlag(
 		atk,
-		ectx,
-		evaluationmodel.RootArgs{
-			WorkspaceKey:   sksArgs.WorkspaceKey,
-			ProjectKey:     sksArgs.ProjectKey,
-			EnvironmentKey: sksArgs.EnvironmentKey,
+		i,
+		opts,
+		evaluationmodel.FlagArgs{
+			WorkspaceKey:   env.RootArgs.WorkspaceKey,
+			ProjectKey:     env.RootArgs.ProjectKey,
+			EnvironmentKey: env.RootArgs.EnvironmentKey,
+			FlagKey:        flagKey,
 		},
 	)
 	if !err.IsEmpty() {
 		e.Extend(err)
 	}
 
-	rBytes, _err := json.Marshal(*r)
+	rBytes, _err := json.Marshal(r)
 	if _err != nil {
 		e.Append(cons.ErrorInternal, _err.Error())
 	}
-	retag := hashutil.HashKeys(
-		string(rBytes),
+	v := &Version{
+		ETag:     hashutil.HashKeys(string(rBytes)),
+		Revision: revision,
+	}
+
+	return r, v, &e
+}
+
+// EvaluateBatch evaluates the flagset for every context, the
+// evaluations of each context are passed to emit in order
+// (*) atk: access_type <= service
+func EvaluateBatch(
+	senv *srvenv.Env,
+	cache FlagsetCache,
+	atk rsc.Token,
+	contexts []model.Context,
+	opts evaluationmodel.Options,
+	a RootHeaders,
+	emit func(*model.BatchEvaluation) error,
+) *res.Errors {
+	var e res.Errors
+
+	ca := CachedServiceArgs{
+		Senv:        senv,
+		Atk:         atk,
+		RootHeaders: a,
+	}
+
+	env, _err := cache.Environment(ca, false)
+	if _err != nil {
+		e.Append(cons.ErrorInternal, _err.Error())
+		return &e
+	}
+
+	evalservice := evaluationservice.NewService(senv)
+	evalservice.Flagsets = cache.Loader(ca, env)
+
+	err := evalservice.EvaluateBatch(
+		atk,
+		contexts,
+		opts,
+		env.RootArgs,
+		emit,
 	)
+	if !err.IsEmpty() {
+		e.Extend(err)
+	}
 
-	return r, retag, &e
+	return &e
 }
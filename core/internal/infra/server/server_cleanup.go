@@ -4,6 +4,7 @@ import "core/internal/pkg/srvenv"
 
 // Cleanup close active server connections
 func Cleanup(senv *srvenv.Env) {
+	senv.Changes.Close()
 	senv.DB.Close()
 	senv.Cache.Close()
 }
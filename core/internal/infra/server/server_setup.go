@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	"core/internal/pkg/changefeed"
 	"core/internal/pkg/policy"
 	"core/internal/pkg/srvenv"
 	"core/pkg/cache"
@@ -62,8 +63,13 @@ func Setup(cfg Config) (*srvenv.Env, error) {
 		return nil, errors.New("unable to connect to redis")
 	}
 
+	// setup change feed
+	changesInst := changefeed.New(dbInst, logInst)
+	changesInst.Start(cfg.Ctx)
+
 	return &srvenv.Env{
 		Cache:             cacheInst,
+		Changes:           changesInst,
 		DB:                dbInst,
 		Log:               logInst,
 		Policy:            policyInst,
@@ -0,0 +1,31 @@
+package streamer
+
+import (
+	"core/pkg/model"
+)
+
+const (
+	// EventFlagset the environment's full (raw) flagset
+	EventFlagset = "flagset"
+	// EventPatch the flags changed (or deleted) since the previous event
+	EventPatch = "patch"
+)
+
+// RootHeaders arguments for selecting root resource
+type RootHeaders struct {
+	SDKKey      string
+	LastEventID string
+}
+
+// Event server-sent event pushed to the clients of an environment
+type Event struct {
+	ID   string
+	Name string
+	Data []byte
+}
+
+// Patch incremental flagset update, changed flags are sent in full
+type Patch struct {
+	Flags           []*model.Flag `json:"flags"`
+	DeletedFlagKeys []string      `json:"deletedFlagKeys"`
+}
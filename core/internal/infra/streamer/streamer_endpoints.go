@@ -0,0 +1,92 @@
+package streamer
+
+import (
+	"core/internal/pkg/httputil"
+	"core/internal/pkg/srvenv"
+	res "core/pkg/response"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reconnectDelay how long clients wait before reconnecting once the stream is closed
+const reconnectDelay = 3 * time.Second
+
+// APIHandler streamer handler context
+type APIHandler struct {
+	Senv              *srvenv.Env
+	Hub               *Hub
+	HeartbeatInterval time.Duration
+}
+
+// ApplyRoutes applies route from all packages to root handler
+func ApplyRoutes(senv *srvenv.Env, r *gin.Engine, hub *Hub, heartbeatInterval time.Duration) {
+	h := &APIHandler{
+		Senv:              senv,
+		Hub:               hub,
+		HeartbeatInterval: heartbeatInterval,
+	}
+	rootPath := ""
+	routes := r.Group(rootPath)
+	routes.GET(rootPath, h.streamAPIHandler)
+}
+
+// streamAPIHandler streams the flagset of the server key's environment, i.e. the full flagset
+// on connect (or the events missed since Last-Event-ID) followed by patches as flags change
+func (h *APIHandler) streamAPIHandler(ctx *gin.Context) {
+	var e res.Errors
+
+	sub, _e := Subscribe(
+		h.Senv,
+		h.Hub,
+		RootHeaders{
+			SDKKey:      ctx.Request.Header.Get("x-sdk-key"),
+			LastEventID: ctx.Request.Header.Get("Last-Event-ID"),
+		},
+	)
+	if !_e.IsEmpty() {
+		e.Extend(_e)
+		httputil.Send(
+			ctx,
+			http.StatusOK,
+			nil,
+			httputil.ErrorStatus(e),
+			e,
+		)
+		return
+	}
+	defer sub.Close()
+
+	httputil.SetBucketSize(ctx)
+	w := httputil.NewSSEWriter(ctx)
+	if err := w.Retry(reconnectDelay); err != nil {
+		return
+	}
+	for _, ev := range sub.Initial {
+		if err := w.Event(ev.ID, ev.Name, ev.Data); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(h.HeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case ev, ok := <-sub.Events:
+			if !ok {
+				// the client fell behind, it resumes from its last event once it reconnects
+				return
+			}
+			if err := w.Event(ev.ID, ev.Name, ev.Data); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := w.Comment("heartbeat"); err != nil {
+				return
+			}
+		}
+	}
+}
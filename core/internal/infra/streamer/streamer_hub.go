@@ -0,0 +1,347 @@
+package streamer
+
+import (
+	"bytes"
+	"context"
+	evaluationmodel "core/internal/app/evaluation/model"
+	evaluationrepo "core/internal/app/evaluation/repository"
+	"core/internal/pkg/changefeed"
+	"core/internal/pkg/srvenv"
+	"core/pkg/model"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// historySize number of patches kept per environment, so
+	// clients resuming a stream can be sent the events they missed
+	historySize = 100
+	// subscriberBuffer number of events buffered per client, clients
+	// falling further behind are disconnected (and resume once they reconnect)
+	subscriberBuffer = 16
+)
+
+// Hub keeps track of the flagsets of environments with connected clients. Each environment's flagset is
+// refreshed whenever it's notified of a change (and periodically), changes are pushed to its clients as patches.
+type Hub struct {
+	senv            *srvenv.Env
+	evaluationRepo  *evaluationrepo.Repo
+	refreshInterval time.Duration
+
+	mu      sync.Mutex
+	streams map[evaluationmodel.RootArgs]*stream
+}
+
+// Subscription a client's stream of events, Initial events are sent
+// before any of the events received on Events
+type Subscription struct {
+	Initial []*Event
+	Events  <-chan *Event
+	close   func()
+}
+
+// Close unsubscribes the client
+func (s *Subscription) Close() {
+	s.close()
+}
+
+// stream the flagset of a single environment along with its clients
+type stream struct {
+	hub  *Hub
+	args evaluationmodel.RootArgs
+	// refs number of subscriptions, the stream stops once there are none left
+	refs int
+	stop chan struct{}
+	// wake refreshes the flagset ahead of the next tick
+	wake chan struct{}
+	// refreshMu serializes refreshes, so flagsets are applied in the order they were loaded
+	refreshMu sync.Mutex
+
+	mu sync.Mutex
+	// epoch identifies the stream, event IDs of other streams (e.g.
+	// before the server restarted) can't be resumed
+	epoch       string
+	scope       *evaluationmodel.Scope
+	seq         uint64
+	loaded      bool
+	flags       []*model.Flag
+	encoded     map[string][]byte
+	history     []*Event
+	subscribers map[chan *Event]struct{}
+}
+
+// NewHub creates a hub refreshing flagsets upon changes and every refreshInterval
+func NewHub(senv *srvenv.Env, refreshInterval time.Duration) *Hub {
+	h := &Hub{
+		senv:            senv,
+		evaluationRepo:  evaluationrepo.NewRepo(senv),
+		refreshInterval: refreshInterval,
+		streams:         make(map[evaluationmodel.RootArgs]*stream),
+	}
+	if senv.Changes != nil {
+		senv.Changes.Subscribe(h.notify)
+	}
+	return h
+}
+
+// notify wakes the streams of the environments affected by the change
+func (h *Hub) notify(c *changefeed.Change) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, s := range h.streams {
+		s.mu.Lock()
+		affected := s.scope == nil || c.Affects(s.scope.ProjectID, s.scope.EnvironmentID)
+		s.mu.Unlock()
+		if !affected {
+			continue
+		}
+		select {
+		case s.wake <- struct{}{}:
+		default:
+			// a refresh is already pending
+		}
+	}
+}
+
+// Subscribe subscribes to the environment's flagset. New clients are sent the full flagset, clients
+// resuming a stream of the environment are only sent the patches after their last event ID.
+func (h *Hub) Subscribe(
+	a evaluationmodel.RootArgs,
+	lastEventID string,
+) (*Subscription, error) {
+	h.mu.Lock()
+	s, ok := h.streams[a]
+	if !ok {
+		s = &stream{
+			hub:         h,
+			args:        a,
+			stop:        make(chan struct{}),
+			wake:        make(chan struct{}, 1),
+			epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+			subscribers: make(map[chan *Event]struct{}),
+		}
+		h.streams[a] = s
+		go s.run()
+	}
+	s.refs++
+	h.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		h.release(s)
+		return nil, err
+	}
+
+	ch := make(chan *Event, subscriberBuffer)
+	initial, err := s.subscribe(ch, lastEventID)
+	if err != nil {
+		h.release(s)
+		return nil, err
+	}
+
+	var once sync.Once
+	return &Subscription{
+		Initial: initial,
+		Events:  ch,
+		close: func() {
+			once.Do(func() {
+				s.unsubscribe(ch)
+				h.release(s)
+			})
+		},
+	}, nil
+}
+
+// release drops a reference to the stream, stopping it once it has no subscriptions left
+func (h *Hub) release(s *stream) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s.refs--
+	if s.refs == 0 {
+		delete(h.streams, s.args)
+		close(s.stop)
+	}
+}
+
+// run refreshes the flagset until the stream is stopped
+func (s *stream) run() {
+	ticker := time.NewTicker(s.hub.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+		if err := s.refresh(); err != nil {
+			s.hub.senv.Log.Error().Str("environmentKey", s.args.EnvironmentKey.String()).Msg(err.Error())
+		}
+	}
+}
+
+// load loads the flagset if it hasn't been loaded yet
+func (s *stream) load() error {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	s.mu.Lock()
+	loaded := s.loaded
+	s.mu.Unlock()
+	if loaded {
+		return nil
+	}
+	return s.reload()
+}
+
+// refresh reloads the flagset, pushing a patch of the changed flags to every subscriber
+func (s *stream) refresh() error {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	return s.reload()
+}
+
+func (s *stream) reload() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s.mu.Lock()
+	scope := s.scope
+	s.mu.Unlock()
+	if scope == nil {
+		var err error
+		if scope, err = s.hub.evaluationRepo.GetScope(ctx, s.args); err != nil {
+			return err
+		}
+	}
+
+	flags, err := s.hub.evaluationRepo.List(ctx, s.args)
+	if err != nil {
+		return err
+	}
+	if flags == nil {
+		flags = []*model.Flag{}
+	}
+
+	encoded := make(map[string][]byte, len(flags))
+	for _, f := range flags {
+		b, err := json.Marshal(f)
+		if err != nil {
+			return err
+		}
+		encoded[f.FlagKey] = b
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scope = scope
+	if !s.loaded {
+		s.flags, s.encoded, s.loaded = flags, encoded, true
+		return nil
+	}
+
+	patch := diffFlagsets(s.encoded, flags, encoded)
+	s.flags, s.encoded = flags, encoded
+	if len(patch.Flags) == 0 && len(patch.DeletedFlagKeys) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	s.seq++
+	ev := &Event{ID: s.eventID(s.seq), Name: EventPatch, Data: data}
+	s.history = append(s.history, ev)
+	if len(s.history) > historySize {
+		s.history = s.history[len(s.history)-historySize:]
+	}
+
+	for ch := range s.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			// the client fell behind, it's disconnected rather than blocking the other clients
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return nil
+}
+
+// subscribe registers the subscriber, returning the events it has to be sent first
+func (s *stream) subscribe(ch chan *Event, lastEventID string) ([]*Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subscribers[ch] = struct{}{}
+
+	if seq, ok := s.resumable(lastEventID); ok {
+		// the history holds the patches (s.seq - len(s.history), s.seq]
+		return s.history[len(s.history)-int(s.seq-seq):], nil
+	}
+
+	data, err := json.Marshal(s.flags)
+	if err != nil {
+		delete(s.subscribers, ch)
+		return nil, err
+	}
+	return []*Event{{ID: s.eventID(s.seq), Name: EventFlagset, Data: data}}, nil
+}
+
+// resumable checks if the client can be sent the patches after its last event
+func (s *stream) resumable(lastEventID string) (uint64, bool) {
+	epoch, rawSeq, ok := strings.Cut(lastEventID, "-")
+	if !ok || epoch != s.epoch {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(rawSeq, 10, 64)
+	if err != nil || seq > s.seq || s.seq-seq > uint64(len(s.history)) {
+		return 0, false
+	}
+	return seq, true
+}
+
+func (s *stream) unsubscribe(ch chan *Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subscribers[ch]; ok {
+		delete(s.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (s *stream) eventID(seq uint64) string {
+	return fmt.Sprintf("%s-%d", s.epoch, seq)
+}
+
+// diffFlagsets lists the flags which were added or changed (in flagset order), along
+// with the keys of the flags which were deleted (flags are compared by their encoding)
+func diffFlagsets(
+	previous map[string][]byte,
+	flags []*model.Flag,
+	encoded map[string][]byte,
+) *Patch {
+	o := &Patch{
+		Flags:           []*model.Flag{},
+		DeletedFlagKeys: []string{},
+	}
+	for _, f := range flags {
+		if b, ok := previous[f.FlagKey]; !ok || !bytes.Equal(b, encoded[f.FlagKey]) {
+			o.Flags = append(o.Flags, f)
+		}
+	}
+	for flagKey := range previous {
+		if _, ok := encoded[flagKey]; !ok {
+			o.DeletedFlagKeys = append(o.DeletedFlagKeys, flagKey)
+		}
+	}
+	sort.Strings(o.DeletedFlagKeys)
+	return o
+}
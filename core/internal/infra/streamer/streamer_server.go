@@ -0,0 +1,30 @@
+package streamer
+
+import (
+	"core/internal/pkg/httpserver"
+	"core/internal/pkg/srvenv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Config streamer server configuration
+type Config struct {
+	Host              string
+	StreamerPort      int
+	Verbose           bool
+	RefreshInterval   time.Duration
+	HeartbeatInterval time.Duration
+}
+
+// New initialize a new HTTP server for streaming
+func New(senv *srvenv.Env, cfg Config) {
+	hub := NewHub(senv, cfg.RefreshInterval)
+	httpserver.New(senv, httpserver.Config{
+		Host:     cfg.Host,
+		HTTPPort: cfg.StreamerPort,
+		Verbose:  cfg.Verbose,
+	}, func(senv *srvenv.Env, r *gin.Engine) {
+		ApplyRoutes(senv, r, hub, cfg.HeartbeatInterval)
+	})
+}
@@ -0,0 +1,51 @@
+package streamer
+
+import (
+	evaluationmodel "core/internal/app/evaluation/model"
+	sdkkeyservice "core/internal/app/sdkkey/service"
+	cons "core/internal/pkg/constants"
+	"core/internal/pkg/srvenv"
+	res "core/pkg/response"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// Subscribe subscribes to the flagset of the server key's environment, clients resuming
+// a stream (i.e. with a last event ID) are only sent the events they missed
+// (*) atk: access_type <= service
+func Subscribe(
+	senv *srvenv.Env,
+	hub *Hub,
+	a RootHeaders,
+) (*Subscription, *res.Errors) {
+	var e res.Errors
+
+	sks := sdkkeyservice.NewService(senv)
+
+	sksArgs, err := sks.GetRootArgsFromServerKey(a.SDKKey)
+	if err != nil {
+		e.Append(cons.ErrorAuth, err.Error())
+		return nil, &e
+	}
+
+	sub, err := hub.Subscribe(
+		evaluationmodel.RootArgs{
+			WorkspaceKey:   sksArgs.WorkspaceKey,
+			ProjectKey:     sksArgs.ProjectKey,
+			EnvironmentKey: sksArgs.EnvironmentKey,
+		},
+		a.LastEventID,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// the environment was deleted after the SDK key was looked up
+		e.Append(cons.ErrorNotFound, err.Error())
+		return nil, &e
+	}
+	if err != nil {
+		e.Append(cons.ErrorInternal, err.Error())
+		return nil, &e
+	}
+
+	return sub, &e
+}
@@ -0,0 +1,155 @@
+package changefeed
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"core/pkg/logger"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	// Channel postgres channel notified on writes affecting evaluation (see migration 000029)
+	Channel = "flagbase_change"
+	// reconnectDelay how long to wait before listening again once the connection is lost
+	reconnectDelay = 5 * time.Second
+)
+
+// Change a write affecting the evaluation of flags
+type Change struct {
+	Table         string `json:"table"`
+	Op            string `json:"op"`
+	ProjectID     string `json:"projectId"`
+	EnvironmentID string `json:"environmentId"`
+}
+
+// Resync whether notifications may have been missed (i.e. upon (re)connecting),
+// in which case anything derived from the configuration should be invalidated
+func (c *Change) Resync() bool {
+	return c.Table == ""
+}
+
+// Affects checks whether the change affects the environment (or every environment, when resyncing)
+func (c *Change) Affects(projectID, environmentID string) bool {
+	if c.Resync() {
+		return true
+	}
+	return c.ProjectID == projectID &&
+		(c.EnvironmentID == "" || c.EnvironmentID == environmentID)
+}
+
+// Feed listens for change notifications, passing them on to subscribers
+type Feed struct {
+	pool   *pgxpool.Pool
+	log    *logger.Logger
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu       sync.Mutex
+	nextID   int
+	handlers map[int]func(*Change)
+}
+
+// New creates a change feed, which listens once started
+func New(pool *pgxpool.Pool, log *logger.Logger) *Feed {
+	return &Feed{
+		pool:     pool,
+		log:      log,
+		handlers: make(map[int]func(*Change)),
+	}
+}
+
+// Start listens for change notifications until the feed is closed
+func (f *Feed) Start(ctx context.Context) {
+	ctx, f.cancel = context.WithCancel(ctx)
+	f.done = make(chan struct{})
+	go f.run(ctx)
+}
+
+// Close stops listening for change notifications
+func (f *Feed) Close() {
+	if f == nil || f.cancel == nil {
+		return
+	}
+	f.cancel()
+	<-f.done
+}
+
+// Subscribe calls fn with every change (including resyncs), until unsubscribed.
+// Handlers are called from the listening goroutine, so they shouldn't block.
+func (f *Feed) Subscribe(fn func(*Change)) (unsubscribe func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := f.nextID
+	f.nextID++
+	f.handlers[id] = fn
+	return func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		delete(f.handlers, id)
+	}
+}
+
+func (f *Feed) run(ctx context.Context) {
+	defer close(f.done)
+	for {
+		err := f.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		f.log.Error().Str("channel", Channel).Msg(err.Error())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// listen holds on to a connection, publishing notifications until the connection is lost
+func (f *Feed) listen(ctx context.Context) error {
+	conn, err := f.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// the connection returns to the pool, so it shouldn't be notified anymore
+		_, _ = conn.Exec(context.Background(), `UNLISTEN *`)
+		conn.Release()
+	}()
+
+	if _, err := conn.Exec(ctx, `LISTEN `+Channel); err != nil {
+		return err
+	}
+	f.publish(&Change{})
+
+	for {
+		n, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var c Change
+		if err := json.Unmarshal([]byte(n.Payload), &c); err != nil || c.Resync() {
+			f.log.Warn().Str("payload", n.Payload).Msg("Unable to parse change notification")
+			continue
+		}
+		f.publish(&c)
+	}
+}
+
+func (f *Feed) publish(c *Change) {
+	f.mu.Lock()
+	handlers := make([]func(*Change), 0, len(f.handlers))
+	for _, fn := range f.handlers {
+		handlers = append(handlers, fn)
+	}
+	f.mu.Unlock()
+
+	for _, fn := range handlers {
+		fn(c)
+	}
+}
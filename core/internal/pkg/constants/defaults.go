@@ -19,6 +19,13 @@ const (
 	DefaultAPIPort = 5051
 	// DefaultStreamerPort default streamer server port
 	DefaultStreamerPort = 7051
+	// DefaultStreamerRefreshInterval how often the streamer checks the flagsets of connected environments for
+	// changes, in case change notifications were missed (flagsets are refreshed upon every notification)
+	DefaultStreamerRefreshInterval time.Duration = time.Minute
+	// DefaultStreamerHeartbeatInterval how often the streamer sends heartbeats to idle clients
+	DefaultStreamerHeartbeatInterval time.Duration = 15 * time.Second
+	// DefaultMaxBatchSize default max number of contexts evaluated per batch
+	DefaultMaxBatchSize = 1000
 	// DefaultPollingPort default polling server port
 	DefaultPollingPort = 9051
 	// DefaultVerbose should log verbosely by default
@@ -0,0 +1,60 @@
+package httpmetrics
+
+import (
+	"core/pkg/evaluator"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	matcherCacheHits = prometheus.NewDesc(
+		"evaluator_matcher_cache_hits_total",
+		"Number of rule values found in the matcher cache",
+		[]string{"cache"}, nil,
+	)
+	matcherCacheMisses = prometheus.NewDesc(
+		"evaluator_matcher_cache_misses_total",
+		"Number of rule values parsed since they were missing from the matcher cache",
+		[]string{"cache"}, nil,
+	)
+	matcherCacheEvictions = prometheus.NewDesc(
+		"evaluator_matcher_cache_evictions_total",
+		"Number of rule values evicted from the matcher cache",
+		[]string{"cache"}, nil,
+	)
+	matcherCacheSize = prometheus.NewDesc(
+		"evaluator_matcher_cache_size",
+		"Number of rule values held by the matcher cache",
+		[]string{"cache"}, nil,
+	)
+)
+
+var registerEvaluatorMetrics sync.Once
+
+// matcherCacheCollector collects the evaluator's matcher cache stats
+type matcherCacheCollector struct{}
+
+func (matcherCacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- matcherCacheHits
+	ch <- matcherCacheMisses
+	ch <- matcherCacheEvictions
+	ch <- matcherCacheSize
+}
+
+func (matcherCacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for cache, stats := range evaluator.MatcherCacheStats() {
+		ch <- prometheus.MustNewConstMetric(matcherCacheHits, prometheus.CounterValue, float64(stats.Hits), cache)
+		ch <- prometheus.MustNewConstMetric(matcherCacheMisses, prometheus.CounterValue, float64(stats.Misses), cache)
+		ch <- prometheus.MustNewConstMetric(matcherCacheEvictions, prometheus.CounterValue, float64(stats.Evictions), cache)
+		ch <- prometheus.MustNewConstMetric(matcherCacheSize, prometheus.GaugeValue, float64(stats.Size), cache)
+	}
+}
+
+// ApplyEvaluatorMetrics registers the evaluator's matcher cache metrics (hits, misses,
+// evictions & size per cache), these are served along with the HTTP metrics
+func ApplyEvaluatorMetrics() {
+	registerEvaluatorMetrics.Do(func() {
+		prometheus.MustRegister(matcherCacheCollector{})
+	})
+}
@@ -0,0 +1,17 @@
+package httputil
+
+import (
+	"core/pkg/evaluator"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BucketSizeHeader the total the weights of a flag's variations add up to (i.e. 100%),
+// so SDKs bucketing raw flagsets can tell which unit weights are expressed in
+const BucketSizeHeader = "X-Flagbase-Bucket-Size"
+
+// SetBucketSize sets the bucket size header, which has to be set before the response is written
+func SetBucketSize(ctx *gin.Context) {
+	ctx.Header(BucketSizeHeader, strconv.FormatUint(uint64(evaluator.BucketSize), 10))
+}
@@ -0,0 +1,76 @@
+package httputil
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StrongETag formats an opaque tag as a strong entity tag
+func StrongETag(tag string) string {
+	return `"` + tag + `"`
+}
+
+// WeakETag formats an opaque tag as a weak entity tag
+func WeakETag(tag string) string {
+	return `W/"` + tag + `"`
+}
+
+// IfNoneMatch gets the entity tags of the representations cached by the client, older
+// SDKs send the tag they were last sent as an ETag header rather than If-None-Match
+func IfNoneMatch(ctx *gin.Context) string {
+	if v := ctx.Request.Header.Get("If-None-Match"); v != "" {
+		return v
+	}
+	return ctx.Request.Header.Get("ETag")
+}
+
+// ETagMatches checks if any of the client's entity tags (i.e. If-None-Match) match the opaque tag,
+// using weak comparison (https://www.rfc-editor.org/rfc/rfc9110#section-13.1.2), so both weak
+// and strong entity tags match. Unquoted tags (sent by older SDKs) are compared as is.
+func ETagMatches(ifNoneMatch string, tag string) bool {
+	if tag == "" {
+		return false
+	}
+	for _, t := range splitETags(ifNoneMatch) {
+		if t == "*" || t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// splitETags splits a list of entity tags into their opaque tags
+func splitETags(v string) []string {
+	var o []string
+	for {
+		v = strings.TrimLeft(v, " \t,")
+		if v == "" {
+			return o
+		}
+		v = strings.TrimPrefix(v, "W/")
+
+		var t string
+		if strings.HasPrefix(v, `"`) {
+			// opaque tags are quoted, so they may contain commas
+			end := strings.IndexByte(v[1:], '"')
+			if end < 0 {
+				return o
+			}
+			t, v = v[1:end+1], v[end+2:]
+		} else {
+			end := strings.IndexAny(v, " \t,")
+			if end < 0 {
+				end = len(v)
+			}
+			t, v = v[:end], v[end:]
+		}
+		o = append(o, t)
+	}
+}
+
+// SendNotModified tells the client its cached representation is up to date
+func SendNotModified(ctx *gin.Context) {
+	ctx.Status(http.StatusNotModified)
+}
@@ -0,0 +1,33 @@
+package httputil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		tag         string
+		matches     bool
+	}{
+		{"Strong", `"c0ffee"`, "c0ffee", true},
+		{"Weak", `W/"c0ffee"`, "c0ffee", true},
+		{"WeakInList", `"stale", W/"c0ffee"`, "c0ffee", true},
+		{"WeakMismatch", `W/"stale"`, "c0ffee", false},
+		{"CommaInTag", `"c0,ffee", "stale"`, "c0,ffee", true},
+		{"Wildcard", "*", "c0ffee", true},
+		{"Unquoted", "c0ffee", "c0ffee", true},
+		{"Empty", "", "c0ffee", false},
+		{"NoTag", `""`, "", false},
+		{"Unterminated", `W/"c0ffee`, "c0ffee", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.matches, ETagMatches(tt.ifNoneMatch, tt.tag))
+		})
+	}
+}
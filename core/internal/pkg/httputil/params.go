@@ -2,6 +2,7 @@ package httputil
 
 import (
 	rsc "core/internal/pkg/resource"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,6 +12,13 @@ func GetParam(ctx *gin.Context, pathParam rsc.Key) rsc.Key {
 	return rsc.Key(ctx.Param(pathParam.String()))
 }
 
+// GetQueryBool retrieves a boolean query param from a route context,
+// missing or malformed values are treated as false
+func GetQueryBool(ctx *gin.Context, key string) bool {
+	v, err := strconv.ParseBool(ctx.Query(key))
+	return err == nil && v
+}
+
 // BuildPath constructs a path given the respective param keys
 func BuildPath(params ...rsc.Key) string {
 	if len(params) == 1 {
@@ -0,0 +1,15 @@
+package httputil
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RevisionHeader revision of the environment's configuration the response was evaluated against
+const RevisionHeader = "X-Flagbase-Revision"
+
+// SetRevision sets the revision header, which has to be set before the response is written
+func SetRevision(ctx *gin.Context, revision int64) {
+	ctx.Header(RevisionHeader, strconv.FormatInt(revision, 10))
+}
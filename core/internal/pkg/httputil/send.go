@@ -1,7 +1,9 @@
 package httputil
 
 import (
+	cons "core/internal/pkg/constants"
 	res "core/pkg/response"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,3 +22,19 @@ func Send(
 	}
 	ctx.JSON(successCode, data)
 }
+
+// ErrorStatus maps the errors to a status code using the code of the first error,
+// i.e. 401 for auth errors, 404 for missing resources and 500 otherwise
+func ErrorStatus(err res.Errors) int {
+	if err.IsEmpty() {
+		return http.StatusInternalServerError
+	}
+	switch err.Errors[0].Code {
+	case cons.ErrorAuth:
+		return http.StatusUnauthorized
+	case cons.ErrorNotFound:
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,90 @@
+package httputil
+
+import (
+	evaluationmodel "core/internal/app/evaluation/model"
+	evaluationservice "core/internal/app/evaluation/service"
+	cons "core/internal/pkg/constants"
+	"core/pkg/model"
+	res "core/pkg/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BatchEvaluator evaluates every context of the batch, emitting each evaluation as soon as it's derived
+type BatchEvaluator func(
+	contexts []model.Context,
+	opts evaluationmodel.Options,
+	emit func(o *model.BatchEvaluation) error,
+) *res.Errors
+
+// SendBatch binds the batch of contexts then sends their evaluations, streamed as NDJSON
+// if the client accepts it. Oversized batches are rejected (400) before anything is evaluated,
+// the batch isn't evaluated at all if e already contains errors (e.g. the request wasn't authorized).
+func SendBatch(ctx *gin.Context, e *res.Errors, evaluate BatchEvaluator) {
+	var i evaluationmodel.EvaluateBatchInput
+	if err := ctx.BindJSON(&i); err != nil {
+		e.Append(cons.ErrorInternal, err.Error())
+	}
+
+	// oversized batches are rejected before anything is evaluated
+	errorCode := http.StatusInternalServerError
+	if e.IsEmpty() {
+		if _e := evaluationservice.ValidateBatchSize(i.Contexts); !_e.IsEmpty() {
+			e.Extend(_e)
+			errorCode = http.StatusBadRequest
+		}
+	}
+
+	// large batches are streamed as NDJSON if the client accepts it
+	stream := AcceptsNDJSON(ctx)
+	w := NewNDJSONWriter(ctx)
+	var r []*model.BatchEvaluation
+	var revisionSet bool
+
+	if e.IsEmpty() {
+		_e := evaluate(
+			i.Contexts,
+			evaluationmodel.Options{
+				Explain:  GetQueryBool(ctx, "explain"),
+				FlagKeys: i.FlagKeys,
+			},
+			func(o *model.BatchEvaluation) error {
+				// every context is evaluated against the same revision
+				if !revisionSet {
+					SetRevision(ctx, o.Revision)
+					revisionSet = true
+				}
+				if stream {
+					return w.Write(o)
+				}
+				// evaluations are reused for the next context
+				r = append(r, o.Clone())
+				return nil
+			},
+		)
+		if !_e.IsEmpty() {
+			e.Extend(_e)
+		}
+	}
+
+	// the status can't be changed once the stream has started,
+	// so errors are sent as the stream's final line instead
+	if stream && w.Started() {
+		if !e.IsEmpty() {
+			w.Write(e)
+		}
+		return
+	}
+	if stream && e.IsEmpty() {
+		return
+	}
+
+	SendJSON(
+		ctx,
+		http.StatusOK,
+		r,
+		errorCode,
+		*e,
+	)
+}
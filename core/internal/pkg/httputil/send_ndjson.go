@@ -0,0 +1,52 @@
+package httputil
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NDJSONMediaType media type of newline delimited JSON responses
+const NDJSONMediaType = "application/x-ndjson"
+
+// AcceptsNDJSON checks if the client asked for a newline delimited JSON response
+func AcceptsNDJSON(ctx *gin.Context) bool {
+	return strings.Contains(ctx.GetHeader("Accept"), NDJSONMediaType)
+}
+
+// NDJSONWriter streams values as newline delimited JSON, the response
+// (i.e. status & headers) is only sent once the first value is written
+type NDJSONWriter struct {
+	ctx     *gin.Context
+	enc     *json.Encoder
+	started bool
+}
+
+// NewNDJSONWriter creates a writer streaming to the response of the request
+func NewNDJSONWriter(ctx *gin.Context) *NDJSONWriter {
+	return &NDJSONWriter{
+		ctx: ctx,
+		enc: json.NewEncoder(ctx.Writer),
+	}
+}
+
+// Write encodes the value as a single line then flushes it to the client
+func (w *NDJSONWriter) Write(v interface{}) error {
+	if !w.started {
+		w.ctx.Header("Content-Type", NDJSONMediaType)
+		w.ctx.Status(http.StatusOK)
+		w.started = true
+	}
+	if err := w.enc.Encode(v); err != nil {
+		return err
+	}
+	w.ctx.Writer.Flush()
+	return nil
+}
+
+// Started checks if the response has been sent
+func (w *NDJSONWriter) Started() bool {
+	return w.started
+}
@@ -0,0 +1,66 @@
+package httputil
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SSEMediaType media type of server-sent event streams
+const SSEMediaType = "text/event-stream"
+
+// SSEWriter streams server-sent events, every event is flushed to the client as it's written
+type SSEWriter struct {
+	ctx *gin.Context
+}
+
+// NewSSEWriter sends the response headers of an event stream
+func NewSSEWriter(ctx *gin.Context) *SSEWriter {
+	ctx.Header("Content-Type", SSEMediaType)
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	// proxies (e.g. nginx) would otherwise buffer the stream
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+	return &SSEWriter{ctx: ctx}
+}
+
+// Event writes an event, multi-line data is split into multiple data fields
+func (w *SSEWriter) Event(id, name string, data []byte) error {
+	var buf bytes.Buffer
+	if id != "" {
+		fmt.Fprintf(&buf, "id: %s\n", id)
+	}
+	if name != "" {
+		fmt.Fprintf(&buf, "event: %s\n", name)
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	return w.write(buf.Bytes())
+}
+
+// Comment writes a comment, which clients ignore (e.g. heartbeats keeping the connection alive)
+func (w *SSEWriter) Comment(text string) error {
+	return w.write([]byte(": " + text + "\n\n"))
+}
+
+// Retry sets how long clients wait before reconnecting once the stream is closed
+func (w *SSEWriter) Retry(d time.Duration) error {
+	return w.write([]byte(fmt.Sprintf("retry: %d\n\n", d.Milliseconds())))
+}
+
+func (w *SSEWriter) write(b []byte) error {
+	if _, err := w.ctx.Writer.Write(b); err != nil {
+		return err
+	}
+	w.ctx.Writer.Flush()
+	return nil
+}
@@ -0,0 +1,31 @@
+package httputil
+
+import (
+	cons "core/internal/pkg/constants"
+	res "core/pkg/response"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		code   string
+		status int
+	}{
+		{"Auth", cons.ErrorAuth, http.StatusUnauthorized},
+		{"NotFound", cons.ErrorNotFound, http.StatusNotFound},
+		{"Internal", cons.ErrorInternal, http.StatusInternalServerError},
+		{"Input", cons.ErrorInput, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e res.Errors
+			e.Append(tt.code, "some error")
+			assert.Equal(t, tt.status, ErrorStatus(e))
+		})
+	}
+}
@@ -28,6 +28,8 @@ const (
 	TraitKey Key = "traitKey"
 	// AccessKey represents a access key
 	AccessKey Key = "accessKey"
+	// LayerKey represents a layer key
+	LayerKey Key = "layerKey"
 	// ResourceID represents a generic resource identifier (hacky)
 	ResourceID Key = "id"
 )
@@ -11,6 +11,8 @@ const (
 	RouteAccess string = "access"
 	// RouteFlag points to the flag resource
 	RouteFlag string = "flags"
+	// RouteKillSwitch points to the kill switch of a flag
+	RouteKillSwitch string = "kill-switch"
 	// RouteVariation points to the variation resource
 	RouteVariation string = "variations"
 	// RouteSDKKey points to an SDK key resource
@@ -27,8 +29,14 @@ const (
 	RouteTargeting string = "targeting"
 	// RouteTargetingRule points to the targeting rule resource
 	RouteTargetingRule string = "targeting-rules"
+	// RouteAssignment points to the assignments of a sticky targeting
+	RouteAssignment string = "assignments"
 	// RouteRule points to the rule resource
 	RouteRule string = "rules"
+	// RouteLayer points to the layer resource
+	RouteLayer string = "layers"
 	// RouteEvaluation points to the evaluation resource
 	RouteEvaluation string = "evaluation"
+	// RouteBatch points to a batch of evaluations
+	RouteBatch string = "batch"
 )
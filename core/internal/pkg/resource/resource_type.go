@@ -38,4 +38,16 @@ const (
 	TargetingRule Type = "targeting_rule"
 	// RuleVariation represents a targeting rule variation
 	RuleVariation Type = "targeting_rule_variation"
+	// TargetingRuleClause represents a single condition of a targeting rule
+	TargetingRuleClause Type = "targeting_rule_clause"
+	// SegmentRuleClause represents a single condition of a segment rule
+	SegmentRuleClause Type = "segment_rule_clause"
+	// TargetingAssignment represents the variation an identity was first bucketed into by a sticky targeting
+	TargetingAssignment Type = "targeting_assignment"
+	// TargetingPrerequisite represents a prerequisite flag of a targeting resource
+	TargetingPrerequisite Type = "targeting_prerequisite"
+	// Layer represents a layer of mutually exclusive experiments
+	Layer Type = "layer"
+	// LayerFlag represents a flag which is part of a layer
+	LayerFlag Type = "layer_flag"
 )
@@ -1,6 +1,7 @@
 package srvenv
 
 import (
+	"core/internal/pkg/changefeed"
 	"core/internal/pkg/policy"
 	"core/pkg/logger"
 
@@ -11,6 +12,7 @@ import (
 // Env primary app context structure
 type Env struct {
 	Cache             *redis.Client
+	Changes           *changefeed.Feed
 	DB                *pgxpool.Pool
 	Log               *logger.Logger
 	Policy            *policy.Policy
@@ -0,0 +1,15 @@
+BEGIN;
+
+DROP TABLE IF EXISTS segment_rule_clause;
+
+DROP TABLE IF EXISTS targeting_rule_clause;
+
+ALTER TABLE segment_rule
+DROP COLUMN IF EXISTS match_type;
+
+ALTER TABLE targeting_rule
+DROP COLUMN IF EXISTS match_type;
+
+DROP TYPE IF EXISTS rule_match_type;
+
+END;
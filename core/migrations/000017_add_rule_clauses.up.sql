@@ -0,0 +1,50 @@
+BEGIN;
+
+-- --------------------------
+-- Compound Rule Conditions
+-- --------------------------
+-- match_type (all, any)
+--  all -> every clause has to match the user context
+--  any -> at least one clause has to match the user context
+-- rules without clauses fall back to their own trait condition
+--
+CREATE TYPE rule_match_type AS ENUM (
+  'all',
+  'any'
+);
+
+ALTER TABLE targeting_rule
+ADD COLUMN match_type rule_match_type NOT NULL DEFAULT 'all';
+
+ALTER TABLE segment_rule
+ADD COLUMN match_type rule_match_type NOT NULL DEFAULT 'all';
+
+CREATE TABLE targeting_rule_clause (
+  id resource_id_default PRIMARY KEY,
+  -- attributes
+  position INT DEFAULT 0 NOT NULL,
+  trait_key VARCHAR(40) NOT NULL,
+  trait_value VARCHAR(40),
+  operator rule_operand NOT NULL,
+  negate BOOLEAN DEFAULT FALSE NOT NULL,
+  -- references
+  targeting_rule_id resource_id REFERENCES targeting_rule (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE segment_rule_clause (
+  id resource_id_default PRIMARY KEY,
+  -- attributes
+  position INT DEFAULT 0 NOT NULL,
+  trait_key VARCHAR(40) NOT NULL,
+  trait_value VARCHAR(40),
+  operator rule_operand NOT NULL,
+  negate BOOLEAN DEFAULT FALSE NOT NULL,
+  -- references
+  segment_rule_id resource_id REFERENCES segment_rule (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX targeting_rule_clause_rule_idx ON targeting_rule_clause (targeting_rule_id);
+
+CREATE INDEX segment_rule_clause_rule_idx ON segment_rule_clause (segment_rule_id);
+
+END;
@@ -0,0 +1,13 @@
+-- Postgres is unable to drop values from an enum type. Rules using the
+-- semver operands are removed, the operand values are left in place.
+DELETE FROM targeting_rule_clause
+WHERE operator::text LIKE 'semver_%';
+
+DELETE FROM segment_rule_clause
+WHERE operator::text LIKE 'semver_%';
+
+DELETE FROM targeting_rule
+WHERE operator::text LIKE 'semver_%';
+
+DELETE FROM segment_rule
+WHERE operator::text LIKE 'semver_%';
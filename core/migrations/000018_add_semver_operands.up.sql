@@ -0,0 +1,6 @@
+-- semantic version comparisons (https://semver.org/spec/v2.0.0.html)
+ALTER TYPE rule_operand ADD VALUE IF NOT EXISTS 'semver_equal';
+ALTER TYPE rule_operand ADD VALUE IF NOT EXISTS 'semver_greater_than';
+ALTER TYPE rule_operand ADD VALUE IF NOT EXISTS 'semver_greater_than_or_equal';
+ALTER TYPE rule_operand ADD VALUE IF NOT EXISTS 'semver_less_than';
+ALTER TYPE rule_operand ADD VALUE IF NOT EXISTS 'semver_less_than_or_equal';
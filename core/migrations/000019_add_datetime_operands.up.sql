@@ -0,0 +1,3 @@
+-- date & time comparisons (RFC3339 strings, epoch milliseconds or relative to now)
+ALTER TYPE rule_operand ADD VALUE IF NOT EXISTS 'before';
+ALTER TYPE rule_operand ADD VALUE IF NOT EXISTS 'after';
@@ -0,0 +1,25 @@
+-- Postgres is unable to drop values from an enum type, the operand values are
+-- left in place. Rules using the list operands have to be removed (or changed)
+-- before migrating down, they are never deleted here.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM targeting_rule WHERE operator::text IN ('in', 'not_in'))
+        OR EXISTS (SELECT 1 FROM segment_rule WHERE operator::text IN ('in', 'not_in'))
+        OR EXISTS (SELECT 1 FROM targeting_rule_clause WHERE operator::text IN ('in', 'not_in'))
+        OR EXISTS (SELECT 1 FROM segment_rule_clause WHERE operator::text IN ('in', 'not_in')) THEN
+        RAISE EXCEPTION 'rules using the in / not_in operators exist, remove them before migrating down';
+    END IF;
+END
+$$;
+
+ALTER TABLE segment_rule_clause
+DROP COLUMN IF EXISTS trait_values;
+
+ALTER TABLE targeting_rule_clause
+DROP COLUMN IF EXISTS trait_values;
+
+ALTER TABLE segment_rule
+DROP COLUMN IF EXISTS trait_values;
+
+ALTER TABLE targeting_rule
+DROP COLUMN IF EXISTS trait_values;
@@ -0,0 +1,15 @@
+-- list membership (values are stored in trait_values, since trait_value is limited to 40 characters)
+ALTER TYPE rule_operand ADD VALUE IF NOT EXISTS 'in';
+ALTER TYPE rule_operand ADD VALUE IF NOT EXISTS 'not_in';
+
+ALTER TABLE targeting_rule
+ADD COLUMN trait_values TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE segment_rule
+ADD COLUMN trait_values TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE targeting_rule_clause
+ADD COLUMN trait_values TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE segment_rule_clause
+ADD COLUMN trait_values TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,13 @@
+-- Postgres is unable to drop values from an enum type. Rules using the
+-- comparison operands are removed, the operand values are left in place.
+DELETE FROM targeting_rule_clause
+WHERE operator::text IN ('not_equal', 'equal_ignore_case', 'less_than', 'less_than_or_equal', 'starts_with', 'ends_with');
+
+DELETE FROM segment_rule_clause
+WHERE operator::text IN ('not_equal', 'equal_ignore_case', 'less_than', 'less_than_or_equal', 'starts_with', 'ends_with');
+
+DELETE FROM targeting_rule
+WHERE operator::text IN ('not_equal', 'equal_ignore_case', 'less_than', 'less_than_or_equal', 'starts_with', 'ends_with');
+
+DELETE FROM segment_rule
+WHERE operator::text IN ('not_equal', 'equal_ignore_case', 'less_than', 'less_than_or_equal', 'starts_with', 'ends_with');
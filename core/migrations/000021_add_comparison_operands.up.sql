@@ -0,0 +1,6 @@
+ALTER TYPE rule_operand ADD VALUE IF NOT EXISTS 'not_equal';
+ALTER TYPE rule_operand ADD VALUE IF NOT EXISTS 'equal_ignore_case';
+ALTER TYPE rule_operand ADD VALUE IF NOT EXISTS 'less_than';
+ALTER TYPE rule_operand ADD VALUE IF NOT EXISTS 'less_than_or_equal';
+ALTER TYPE rule_operand ADD VALUE IF NOT EXISTS 'starts_with';
+ALTER TYPE rule_operand ADD VALUE IF NOT EXISTS 'ends_with';
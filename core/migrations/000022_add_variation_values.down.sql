@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE variation
+DROP COLUMN IF EXISTS value;
+
+ALTER TABLE flag
+DROP COLUMN IF EXISTS value_type;
+
+DROP TYPE IF EXISTS flag_value_type;
+
+END;
@@ -0,0 +1,32 @@
+BEGIN;
+
+-- --------------------------
+-- Typed Variation Values
+-- --------------------------
+-- value_type (boolean, string, number, json)
+--  every variation value of a flag has to be of the flag's value type
+--  (validated on write), variations without a value are served by key only
+--
+CREATE TYPE flag_value_type AS ENUM (
+  'boolean',
+  'string',
+  'number',
+  'json'
+);
+
+ALTER TABLE flag
+ADD COLUMN value_type flag_value_type NOT NULL DEFAULT 'boolean';
+
+ALTER TABLE variation
+ADD COLUMN value JSONB;
+
+-- existing flags are boolean, generated variations receive the default values
+UPDATE variation
+SET value = 'false'::jsonb
+WHERE key = 'control';
+
+UPDATE variation
+SET value = 'true'::jsonb
+WHERE key = 'treatment';
+
+END;
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS targeting_prerequisite;
+
+END;
@@ -0,0 +1,22 @@
+BEGIN;
+
+-- --------------------------
+-- Prerequisite Flags
+-- --------------------------
+-- a flag is only evaluated (per environment) when each prerequisite flag
+-- serves one of its required variations, otherwise the fallthrough
+-- variation is served (reason PREREQUISITE_FAILED)
+--  one row per required variation of a prerequisite flag
+--  cycles are rejected when writing the targeting
+--
+CREATE TABLE targeting_prerequisite (
+  PRIMARY KEY (targeting_id, variation_id),
+  -- references
+  targeting_id UUID REFERENCES targeting (id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+  prerequisite_flag_id UUID REFERENCES flag (id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+  variation_id UUID REFERENCES variation (id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL
+);
+
+CREATE INDEX targeting_prerequisite_flag_idx ON targeting_prerequisite (prerequisite_flag_id);
+
+END;
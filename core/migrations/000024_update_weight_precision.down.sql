@@ -0,0 +1,24 @@
+BEGIN;
+
+-- weights below a percent are rounded down
+ALTER TABLE targeting_rule_variation
+DROP CONSTRAINT IF EXISTS targeting_rule_variation_weight_check;
+
+UPDATE targeting_rule_variation
+SET weight = weight / 1000;
+
+ALTER TABLE targeting_rule_variation
+ALTER COLUMN weight SET DEFAULT 100,
+ADD CONSTRAINT targeting_rule_variation_weight_check CHECK (weight BETWEEN 0 AND 100);
+
+ALTER TABLE targeting_fallthrough_variation
+DROP CONSTRAINT IF EXISTS targeting_fallthrough_variation_weight_check;
+
+UPDATE targeting_fallthrough_variation
+SET weight = weight / 1000;
+
+ALTER TABLE targeting_fallthrough_variation
+ALTER COLUMN weight SET DEFAULT 100,
+ADD CONSTRAINT targeting_fallthrough_variation_weight_check CHECK (weight BETWEEN 0 AND 100);
+
+END;
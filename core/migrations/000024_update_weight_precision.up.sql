@@ -0,0 +1,60 @@
+BEGIN;
+
+-- --------------------------
+-- High Precision Weights
+-- --------------------------
+-- weights are expressed in thousandths of a percent (i.e. 100000 = 100%),
+-- existing percentages are scaled by 1000 so identities keep their variation.
+-- Weights have to add up to 100%, legacy weights are normalized the same way
+-- they were served: buckets beyond 100% were never reached & the remainder
+-- was served to the last variation (in the order the variations are read).
+--
+ALTER TABLE targeting_fallthrough_variation
+DROP CONSTRAINT IF EXISTS targeting_fallthrough_variation_weight_check;
+
+UPDATE targeting_fallthrough_variation tfv
+SET weight = n.weight
+FROM (
+  SELECT
+    variation_id,
+    targeting_id,
+    CASE
+      WHEN row_number() OVER w = count(*) OVER (PARTITION BY targeting_id)
+      THEN 100000 - LEAST(sum(weight) OVER w - weight, 100) * 1000
+      ELSE (LEAST(sum(weight) OVER w, 100) - LEAST(sum(weight) OVER w - weight, 100)) * 1000
+    END AS weight
+  FROM targeting_fallthrough_variation
+  WINDOW w AS (PARTITION BY targeting_id ORDER BY ctid ROWS UNBOUNDED PRECEDING)
+) n
+WHERE tfv.variation_id = n.variation_id
+  AND tfv.targeting_id = n.targeting_id;
+
+ALTER TABLE targeting_fallthrough_variation
+ALTER COLUMN weight SET DEFAULT 100000,
+ADD CONSTRAINT targeting_fallthrough_variation_weight_check CHECK (weight BETWEEN 0 AND 100000);
+
+ALTER TABLE targeting_rule_variation
+DROP CONSTRAINT IF EXISTS targeting_rule_variation_weight_check;
+
+UPDATE targeting_rule_variation trv
+SET weight = n.weight
+FROM (
+  SELECT
+    variation_id,
+    targeting_rule_id,
+    CASE
+      WHEN row_number() OVER w = count(*) OVER (PARTITION BY targeting_rule_id)
+      THEN 100000 - LEAST(sum(weight) OVER w - weight, 100) * 1000
+      ELSE (LEAST(sum(weight) OVER w, 100) - LEAST(sum(weight) OVER w - weight, 100)) * 1000
+    END AS weight
+  FROM targeting_rule_variation
+  WINDOW w AS (PARTITION BY targeting_rule_id ORDER BY ctid ROWS UNBOUNDED PRECEDING)
+) n
+WHERE trv.variation_id = n.variation_id
+  AND trv.targeting_rule_id = n.targeting_rule_id;
+
+ALTER TABLE targeting_rule_variation
+ALTER COLUMN weight SET DEFAULT 100000,
+ADD CONSTRAINT targeting_rule_variation_weight_check CHECK (weight BETWEEN 0 AND 100000);
+
+END;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE targeting
+DROP COLUMN IF EXISTS seed,
+DROP COLUMN IF EXISTS bucket_by;
+
+END;
@@ -0,0 +1,14 @@
+BEGIN;
+
+-- --------------------------
+-- Bucketing Attribute & Seed
+-- --------------------------
+-- bucket_by -> trait used to bucket identities (falls back to the identifier)
+-- seed      -> mixed into the salt, rotating it reshuffles every identity
+--  an empty seed keeps the original salt (flag key + bucketing value)
+--
+ALTER TABLE targeting
+ADD COLUMN bucket_by VARCHAR(40) NOT NULL DEFAULT '',
+ADD COLUMN seed VARCHAR(64) NOT NULL DEFAULT '';
+
+END;
@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE flag
+DROP COLUMN IF EXISTS killed;
+
+ALTER TABLE targeting
+DROP COLUMN IF EXISTS off_variation_id;
+
+END;
@@ -0,0 +1,39 @@
+BEGIN;
+
+-- -------------
+-- Off Variation
+-- -------------
+-- off_variation_id -> variation served when targeting is disabled
+--  (defaults to the flag's control variation, otherwise the first fallthrough variation)
+--
+-- variations served as an off variation can't be deleted, the constraint is only
+-- checked at commit so deleting the flag (or environment) still cascades
+ALTER TABLE targeting
+ADD COLUMN off_variation_id UUID REFERENCES variation (id)
+  ON DELETE NO ACTION ON UPDATE CASCADE DEFERRABLE INITIALLY DEFERRED;
+
+UPDATE targeting t
+SET off_variation_id = v.id
+FROM variation v
+WHERE v.flag_id = t.flag_id
+  AND v.key = 'control';
+
+UPDATE targeting t
+SET off_variation_id = (
+  SELECT tfv.variation_id
+  FROM targeting_fallthrough_variation tfv
+  WHERE tfv.targeting_id = t.id
+  ORDER BY tfv.ctid
+  LIMIT 1
+)
+WHERE t.off_variation_id IS NULL;
+
+-- -----------
+-- Kill Switch
+-- -----------
+-- killed -> serves the off variation in every environment of the project
+--
+ALTER TABLE flag
+ADD COLUMN killed BOOLEAN NOT NULL DEFAULT FALSE;
+
+END;
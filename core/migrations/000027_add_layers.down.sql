@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS layer_flag;
+DROP TABLE IF EXISTS layer;
+DROP EXTENSION IF EXISTS btree_gist;
+
+END;
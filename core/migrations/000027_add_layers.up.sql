@@ -0,0 +1,52 @@
+BEGIN;
+
+-- required to exclude overlapping ranges per layer (i.e. "=" on UUIDs in a gist index)
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- --------------------------
+-- Experiment Layers
+-- --------------------------
+-- the layer's bucket space (0-100000) is split between its flags, so an
+-- identity is part of at most one of the layer's experiments
+--  holdout -> the first holdout buckets never enter any experiment (i.e. control)
+--
+CREATE TABLE layer (
+  id resource_id_default PRIMARY KEY,
+  -- attributes
+  key resource_key,
+  holdout INTEGER NOT NULL DEFAULT 0 CHECK (holdout BETWEEN 0 AND 100000),
+  -- meta-data
+  name resource_name,
+  description resource_description,
+  tags resource_tags,
+  -- references
+  project_id resource_id REFERENCES project (id) ON DELETE CASCADE ON UPDATE CASCADE,
+  -- contraints
+  CONSTRAINT layer_key UNIQUE(key, project_id)
+);
+
+-- --------------------------
+-- Layer Flags
+-- --------------------------
+-- a flag belongs to at most one layer, owning the buckets [bucket_start, bucket_end)
+-- of the layer's bucket space (ranges are allocated when the flag joins the layer)
+--
+CREATE TABLE layer_flag (
+  PRIMARY KEY (flag_id),
+  -- attributes
+  bucket_start INTEGER NOT NULL CHECK (bucket_start BETWEEN 0 AND 100000),
+  bucket_end INTEGER NOT NULL CHECK (bucket_end BETWEEN 0 AND 100000),
+  -- references
+  layer_id UUID REFERENCES layer (id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+  flag_id UUID REFERENCES flag (id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+  -- contraints
+  CONSTRAINT layer_flag_bucket_range CHECK (bucket_start < bucket_end),
+  CONSTRAINT layer_flag_bucket_overlap EXCLUDE USING gist (
+    layer_id WITH =,
+    int4range(bucket_start, bucket_end) WITH &&
+  )
+);
+
+CREATE INDEX layer_flag_layer_idx ON layer_flag (layer_id);
+
+END;
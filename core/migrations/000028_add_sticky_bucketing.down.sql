@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE IF EXISTS targeting_assignment;
+
+ALTER TABLE targeting
+DROP COLUMN IF EXISTS sticky;
+
+END;
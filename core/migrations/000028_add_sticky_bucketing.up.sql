@@ -0,0 +1,30 @@
+BEGIN;
+
+-- ----------------
+-- Sticky Bucketing
+-- ----------------
+-- sticky -> identities keep the variation they were first bucketed into,
+--  even after the variation weights change
+--
+ALTER TABLE targeting
+ADD COLUMN sticky BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- ---------------------
+-- Targeting Assignments
+-- ---------------------
+-- the variation an identity was first bucketed into by a sticky targeting,
+-- assignments are kept until they are reset (or the variation is deleted)
+--  identity_key -> the evaluation context's identifier (identities aren't
+--   created on evaluation, so this doesn't reference the identity table)
+--
+CREATE TABLE targeting_assignment (
+  PRIMARY KEY (targeting_id, identity_key),
+  -- attributes
+  identity_key TEXT NOT NULL,
+  assigned_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  -- references
+  targeting_id UUID REFERENCES targeting (id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+  variation_id UUID REFERENCES variation (id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL
+);
+
+END;
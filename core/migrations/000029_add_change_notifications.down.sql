@@ -0,0 +1,20 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS sdk_key_notify_change ON sdk_key;
+DROP TRIGGER IF EXISTS identity_notify_change ON identity;
+DROP TRIGGER IF EXISTS segment_rule_clause_notify_change ON segment_rule_clause;
+DROP TRIGGER IF EXISTS segment_rule_notify_change ON segment_rule;
+DROP TRIGGER IF EXISTS segment_notify_change ON segment;
+DROP TRIGGER IF EXISTS targeting_rule_clause_notify_change ON targeting_rule_clause;
+DROP TRIGGER IF EXISTS targeting_rule_variation_notify_change ON targeting_rule_variation;
+DROP TRIGGER IF EXISTS targeting_rule_notify_change ON targeting_rule;
+DROP TRIGGER IF EXISTS targeting_prerequisite_notify_change ON targeting_prerequisite;
+DROP TRIGGER IF EXISTS targeting_fallthrough_variation_notify_change ON targeting_fallthrough_variation;
+DROP TRIGGER IF EXISTS targeting_notify_change ON targeting;
+DROP TRIGGER IF EXISTS layer_flag_notify_change ON layer_flag;
+DROP TRIGGER IF EXISTS layer_notify_change ON layer;
+DROP TRIGGER IF EXISTS variation_notify_change ON variation;
+DROP TRIGGER IF EXISTS flag_notify_change ON flag;
+DROP FUNCTION IF EXISTS notify_change;
+
+END;
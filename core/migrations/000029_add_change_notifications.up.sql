@@ -0,0 +1,89 @@
+BEGIN;
+
+-- --------------------------
+-- Change Notifications
+-- --------------------------
+-- writes affecting evaluation notify listeners of the 'flagbase_change' channel,
+-- i.e. {"table": ..., "op": ..., "projectId": ..., "environmentId": ...}
+--  environmentId -> null if every environment of the project is affected (e.g. flags)
+-- identical notifications are only delivered once per transaction
+--
+CREATE FUNCTION notify_change() RETURNS TRIGGER AS $$
+DECLARE
+  r RECORD;
+  _project_id UUID;
+  _environment_id UUID;
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    r := OLD;
+  ELSE
+    r := NEW;
+  END IF;
+
+  CASE TG_TABLE_NAME
+    WHEN 'flag', 'layer', 'segment' THEN
+      _project_id := r.project_id;
+    WHEN 'variation', 'layer_flag' THEN
+      SELECT f.project_id INTO _project_id FROM flag f WHERE f.id = r.flag_id;
+    WHEN 'targeting', 'segment_rule', 'identity', 'sdk_key' THEN
+      _environment_id := r.environment_id;
+    WHEN 'targeting_fallthrough_variation', 'targeting_rule', 'targeting_prerequisite' THEN
+      SELECT t.environment_id INTO _environment_id FROM targeting t WHERE t.id = r.targeting_id;
+    WHEN 'targeting_rule_variation', 'targeting_rule_clause' THEN
+      SELECT t.environment_id INTO _environment_id
+      FROM targeting_rule tr JOIN targeting t ON t.id = tr.targeting_id
+      WHERE tr.id = r.targeting_rule_id;
+    WHEN 'segment_rule_clause' THEN
+      SELECT sr.environment_id INTO _environment_id FROM segment_rule sr WHERE sr.id = r.segment_rule_id;
+  END CASE;
+
+  IF _environment_id IS NOT NULL THEN
+    SELECT e.project_id INTO _project_id FROM environment e WHERE e.id = _environment_id;
+  END IF;
+
+  -- rows deleted along with their parent (i.e. cascades) are covered by the parent's notification
+  IF _project_id IS NOT NULL THEN
+    PERFORM pg_notify('flagbase_change', json_build_object(
+      'table', TG_TABLE_NAME,
+      'op', TG_OP,
+      'projectId', _project_id,
+      'environmentId', _environment_id
+    )::TEXT);
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER flag_notify_change AFTER INSERT OR UPDATE OR DELETE ON flag
+  FOR EACH ROW EXECUTE FUNCTION notify_change();
+CREATE TRIGGER variation_notify_change AFTER INSERT OR UPDATE OR DELETE ON variation
+  FOR EACH ROW EXECUTE FUNCTION notify_change();
+CREATE TRIGGER layer_notify_change AFTER INSERT OR UPDATE OR DELETE ON layer
+  FOR EACH ROW EXECUTE FUNCTION notify_change();
+CREATE TRIGGER layer_flag_notify_change AFTER INSERT OR UPDATE OR DELETE ON layer_flag
+  FOR EACH ROW EXECUTE FUNCTION notify_change();
+CREATE TRIGGER targeting_notify_change AFTER INSERT OR UPDATE OR DELETE ON targeting
+  FOR EACH ROW EXECUTE FUNCTION notify_change();
+CREATE TRIGGER targeting_fallthrough_variation_notify_change AFTER INSERT OR UPDATE OR DELETE ON targeting_fallthrough_variation
+  FOR EACH ROW EXECUTE FUNCTION notify_change();
+CREATE TRIGGER targeting_prerequisite_notify_change AFTER INSERT OR UPDATE OR DELETE ON targeting_prerequisite
+  FOR EACH ROW EXECUTE FUNCTION notify_change();
+CREATE TRIGGER targeting_rule_notify_change AFTER INSERT OR UPDATE OR DELETE ON targeting_rule
+  FOR EACH ROW EXECUTE FUNCTION notify_change();
+CREATE TRIGGER targeting_rule_variation_notify_change AFTER INSERT OR UPDATE OR DELETE ON targeting_rule_variation
+  FOR EACH ROW EXECUTE FUNCTION notify_change();
+CREATE TRIGGER targeting_rule_clause_notify_change AFTER INSERT OR UPDATE OR DELETE ON targeting_rule_clause
+  FOR EACH ROW EXECUTE FUNCTION notify_change();
+CREATE TRIGGER segment_notify_change AFTER INSERT OR UPDATE OR DELETE ON segment
+  FOR EACH ROW EXECUTE FUNCTION notify_change();
+CREATE TRIGGER segment_rule_notify_change AFTER INSERT OR UPDATE OR DELETE ON segment_rule
+  FOR EACH ROW EXECUTE FUNCTION notify_change();
+CREATE TRIGGER segment_rule_clause_notify_change AFTER INSERT OR UPDATE OR DELETE ON segment_rule_clause
+  FOR EACH ROW EXECUTE FUNCTION notify_change();
+CREATE TRIGGER identity_notify_change AFTER INSERT OR UPDATE OR DELETE ON identity
+  FOR EACH ROW EXECUTE FUNCTION notify_change();
+CREATE TRIGGER sdk_key_notify_change AFTER INSERT OR UPDATE OR DELETE ON sdk_key
+  FOR EACH ROW EXECUTE FUNCTION notify_change();
+
+END;
@@ -0,0 +1,53 @@
+BEGIN;
+
+CREATE OR REPLACE FUNCTION notify_change() RETURNS TRIGGER AS $$
+DECLARE
+  r RECORD;
+  _project_id UUID;
+  _environment_id UUID;
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    r := OLD;
+  ELSE
+    r := NEW;
+  END IF;
+
+  CASE TG_TABLE_NAME
+    WHEN 'flag', 'layer', 'segment' THEN
+      _project_id := r.project_id;
+    WHEN 'variation', 'layer_flag' THEN
+      SELECT f.project_id INTO _project_id FROM flag f WHERE f.id = r.flag_id;
+    WHEN 'targeting', 'segment_rule', 'identity', 'sdk_key' THEN
+      _environment_id := r.environment_id;
+    WHEN 'targeting_fallthrough_variation', 'targeting_rule', 'targeting_prerequisite' THEN
+      SELECT t.environment_id INTO _environment_id FROM targeting t WHERE t.id = r.targeting_id;
+    WHEN 'targeting_rule_variation', 'targeting_rule_clause' THEN
+      SELECT t.environment_id INTO _environment_id
+      FROM targeting_rule tr JOIN targeting t ON t.id = tr.targeting_id
+      WHERE tr.id = r.targeting_rule_id;
+    WHEN 'segment_rule_clause' THEN
+      SELECT sr.environment_id INTO _environment_id FROM segment_rule sr WHERE sr.id = r.segment_rule_id;
+  END CASE;
+
+  IF _environment_id IS NOT NULL THEN
+    SELECT e.project_id INTO _project_id FROM environment e WHERE e.id = _environment_id;
+  END IF;
+
+  -- rows deleted along with their parent (i.e. cascades) are covered by the parent's notification
+  IF _project_id IS NOT NULL THEN
+    PERFORM pg_notify('flagbase_change', json_build_object(
+      'table', TG_TABLE_NAME,
+      'op', TG_OP,
+      'projectId', _project_id,
+      'environmentId', _environment_id
+    )::TEXT);
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE environment
+DROP COLUMN IF EXISTS revision;
+
+END;
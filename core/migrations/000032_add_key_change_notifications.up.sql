@@ -0,0 +1,89 @@
+BEGIN;
+
+-- --------------------------
+-- Key Change Notifications
+-- --------------------------
+-- renaming a workspace, project or environment notifies listeners, as anything
+-- cached by key (e.g. the environment an SDK key belongs to) has to be reloaded.
+-- The triggers only fire when the key is set, so bumping the revision doesn't fire them again.
+--
+CREATE OR REPLACE FUNCTION notify_change() RETURNS TRIGGER AS $$
+DECLARE
+  r RECORD;
+  _project_id UUID;
+  _environment_id UUID;
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    r := OLD;
+  ELSE
+    r := NEW;
+  END IF;
+
+  CASE TG_TABLE_NAME
+    WHEN 'workspace' THEN
+      -- every project of the workspace is affected
+      FOR _project_id IN SELECT p.id FROM project p WHERE p.workspace_id = r.id LOOP
+        PERFORM pg_notify('flagbase_change', json_build_object(
+          'table', TG_TABLE_NAME,
+          'op', TG_OP,
+          'projectId', _project_id,
+          'environmentId', NULL
+        )::TEXT);
+      END LOOP;
+      RETURN NULL;
+    WHEN 'project' THEN
+      _project_id := r.id;
+    WHEN 'flag', 'layer', 'segment' THEN
+      _project_id := r.project_id;
+    WHEN 'variation', 'layer_flag' THEN
+      SELECT f.project_id INTO _project_id FROM flag f WHERE f.id = r.flag_id;
+    WHEN 'environment' THEN
+      _environment_id := r.id;
+    WHEN 'targeting', 'segment_rule', 'identity', 'sdk_key' THEN
+      _environment_id := r.environment_id;
+    WHEN 'targeting_fallthrough_variation', 'targeting_rule', 'targeting_prerequisite' THEN
+      SELECT t.environment_id INTO _environment_id FROM targeting t WHERE t.id = r.targeting_id;
+    WHEN 'targeting_rule_variation', 'targeting_rule_clause' THEN
+      SELECT t.environment_id INTO _environment_id
+      FROM targeting_rule tr JOIN targeting t ON t.id = tr.targeting_id
+      WHERE tr.id = r.targeting_rule_id;
+    WHEN 'segment_rule_clause' THEN
+      SELECT sr.environment_id INTO _environment_id FROM segment_rule sr WHERE sr.id = r.segment_rule_id;
+  END CASE;
+
+  IF _environment_id IS NOT NULL THEN
+    SELECT e.project_id INTO _project_id FROM environment e WHERE e.id = _environment_id;
+  END IF;
+
+  -- SDK keys & renamed projects don't affect evaluation
+  IF TG_TABLE_NAME NOT IN ('sdk_key', 'project') THEN
+    IF _environment_id IS NOT NULL THEN
+      UPDATE environment SET revision = revision + 1 WHERE id = _environment_id;
+    ELSIF _project_id IS NOT NULL THEN
+      UPDATE environment SET revision = revision + 1 WHERE project_id = _project_id;
+    END IF;
+  END IF;
+
+  -- rows deleted along with their parent (i.e. cascades) are covered by the parent's notification
+  IF _project_id IS NOT NULL THEN
+    PERFORM pg_notify('flagbase_change', json_build_object(
+      'table', TG_TABLE_NAME,
+      'op', TG_OP,
+      'projectId', _project_id,
+      'environmentId', _environment_id
+    )::TEXT);
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS environment_notify_change ON environment;
+CREATE TRIGGER environment_notify_change AFTER UPDATE OF key, holdout ON environment
+  FOR EACH ROW EXECUTE FUNCTION notify_change();
+CREATE TRIGGER project_notify_change AFTER UPDATE OF key ON project
+  FOR EACH ROW EXECUTE FUNCTION notify_change();
+CREATE TRIGGER workspace_notify_change AFTER UPDATE OF key ON workspace
+  FOR EACH ROW EXECUTE FUNCTION notify_change();
+
+END;
@@ -0,0 +1,161 @@
+// Package conformance generates the evaluation conformance corpus, i.e. flagsets, contexts
+// and the evaluations the Go evaluator serves for them. SDKs evaluating flags locally replay
+// the corpus to check they bucket & match contexts exactly the same way as pkg/evaluator.
+package conformance
+
+import (
+	"bytes"
+	"core/pkg/evaluator"
+	"core/pkg/model"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+)
+
+// Version corpus format version, bumped whenever the format changes
+// or the evaluator intentionally serves different variations
+const Version = 2
+
+// suites the flagsets & contexts the corpus is generated from
+//
+//go:embed suites.json
+var suites []byte
+
+// Corpus versioned set of conformance suites
+type Corpus struct {
+	Version int      `json:"version"`
+	Suites  []*Suite `json:"suites"`
+}
+
+// Suite flagset along with the contexts it's evaluated against
+type Suite struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Flags       []*model.Flag `json:"flags"`
+	Cases       []*Case       `json:"cases"`
+}
+
+// Case the evaluations expected for a single context,
+// listed in the same order as the suite's flags
+type Case struct {
+	Context     model.Context `json:"context"`
+	Evaluations []*Expected   `json:"evaluations"`
+}
+
+// Expected the evaluation expected for a single flag
+type Expected struct {
+	FlagKey      string       `json:"flagKey"`
+	VariationKey string       `json:"variationKey"`
+	Reason       model.Reason `json:"reason"`
+	Value        model.Value  `json:"value,omitempty"`
+	// Bucket the context's bucket compared against the weight ranges,
+	// omitted if no weight ranges were checked (e.g. off variation)
+	Bucket *uint32 `json:"bucket,omitempty"`
+}
+
+// suiteInput the suites are generated from, cases are derived for the listed
+// contexts followed by Generate contexts (see newContext)
+type suiteInput struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Generate    int             `json:"generate"`
+	Flags       []*model.Flag   `json:"flags"`
+	Contexts    []model.Context `json:"contexts"`
+}
+
+// Generate evaluates every suite's contexts using the Go evaluator
+func Generate() (*Corpus, error) {
+	var inputs []*suiteInput
+	if err := json.Unmarshal(suites, &inputs); err != nil {
+		return nil, fmt.Errorf("unable to parse suites: %w", err)
+	}
+
+	o := &Corpus{Version: Version}
+	for _, i := range inputs {
+		s := &Suite{
+			Name:        i.Name,
+			Description: i.Description,
+			Flags:       i.Flags,
+		}
+		contexts := i.Contexts
+		for n := 0; n < i.Generate; n++ {
+			contexts = append(contexts, newContext(n))
+		}
+		for _, ectx := range contexts {
+			s.Cases = append(s.Cases, &Case{
+				Context:     ectx,
+				Evaluations: Evaluate(s.Flags, ectx),
+			})
+		}
+		o.Suites = append(o.Suites, s)
+	}
+
+	return o, nil
+}
+
+// Evaluate evaluates the flagset in dependency order, the same way as the evaluation service
+// in explain mode. Evaluations are listed in the same order as the flags.
+func Evaluate(flags []*model.Flag, ectx model.Context) []*Expected {
+	evaluated := make(map[string]*model.Evaluation, len(flags))
+	for _, level := range evaluator.DependencyLevels(flags) {
+		explained := make([]*model.Evaluation, 0, len(level))
+		for _, flag := range level {
+			salt := evaluator.DeriveSalt(*flag, ectx)
+			explained = append(explained, evaluator.ExplainWithPrerequisites(*flag, salt, ectx, evaluated))
+		}
+		for _, eval := range explained {
+			evaluated[eval.FlagKey] = eval
+		}
+	}
+
+	o := make([]*Expected, len(flags))
+	for idx, flag := range flags {
+		eval := evaluated[flag.FlagKey]
+		o[idx] = &Expected{
+			FlagKey:      eval.FlagKey,
+			VariationKey: eval.VariationKey,
+			Reason:       eval.Reason,
+			Value:        eval.Value,
+			Bucket:       eval.Trace.Bucket,
+		}
+	}
+	return o
+}
+
+// Marshal encodes the corpus the way it's committed (i.e. indented, ending with a newline)
+func Marshal(c *Corpus) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(c); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// newContext deterministic context covering every trait type used by the suites
+func newContext(n int) model.Context {
+	traits := map[string]interface{}{
+		"plan":    []string{"free", "team", "enterprise", "starter"}[n%4],
+		"email":   fmt.Sprintf("identity-%d@%s", n, []string{"flagbase.com", "example.com"}[n%2]),
+		"country": []string{"NZ", "US", "nz", "AU"}[n%4],
+		"version": fmt.Sprintf("%d.%d.0", n%4, n%10),
+		"signup":  fmt.Sprintf("2023-%02d-%02d", n%12+1, n%28+1),
+		"beta":    n%5 == 0,
+		"score":   float64(n%10) / 10,
+	}
+	if n%3 != 0 {
+		traits["age"] = float64(n % 90)
+	}
+	if n%4 != 0 {
+		traits["company"] = fmt.Sprintf("company-%d", n%50)
+	}
+	if n%7 == 0 {
+		traits["plan"] = float64(n)
+	}
+	return model.Context{
+		Identifier: fmt.Sprintf("identity-%d", n),
+		Traits:     traits,
+	}
+}
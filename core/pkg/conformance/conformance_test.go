@@ -0,0 +1,64 @@
+package conformance
+
+import (
+	"core/pkg/evaluator"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const corpusPath = "testdata/corpus.v2.json"
+
+func loadCorpus(t *testing.T) ([]byte, *Corpus) {
+	b, err := os.ReadFile(corpusPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c Corpus
+	if err := json.Unmarshal(b, &c); err != nil {
+		t.Fatal(err)
+	}
+	return b, &c
+}
+
+func TestCorpusUpToDate(t *testing.T) {
+	committed, _ := loadCorpus(t)
+
+	c, err := Generate()
+	assert.NoError(t, err)
+	generated, err := Marshal(c)
+	assert.NoError(t, err)
+
+	assert.True(
+		t,
+		string(committed) == string(generated),
+		"corpus is out of date, regenerate it using: go run ./cmd/flagbased conformance generate --out pkg/conformance/%s",
+		corpusPath,
+	)
+}
+
+func TestReplayCorpus(t *testing.T) {
+	_, c := loadCorpus(t)
+	assert.Equal(t, Version, c.Version)
+
+	for _, s := range c.Suites {
+		t.Run(s.Name, func(t *testing.T) {
+			compiled := evaluator.Compile(s.Flags)
+			for idx, cs := range s.Cases {
+				name := fmt.Sprintf("case %d (%s)", idx, cs.Context.Identifier)
+				assert.Equal(t, cs.Evaluations, Evaluate(s.Flags, cs.Context), name)
+
+				evals := compiled.Evaluate(cs.Context)
+				for i, expected := range cs.Evaluations {
+					assert.Equal(t, expected.FlagKey, evals[i].FlagKey, name)
+					assert.Equal(t, expected.VariationKey, evals[i].VariationKey, name)
+					assert.Equal(t, expected.Reason, evals[i].Reason, name)
+					assert.Equal(t, expected.Value, evals[i].Value, name)
+				}
+			}
+		})
+	}
+}
@@ -9,6 +9,20 @@ import (
 	"github.com/jackc/pgx/v4"
 )
 
+// notFoundError human-readable error for a missing resource,
+// which still matches pgx.ErrNoRows (see errors.Is)
+type notFoundError struct {
+	msg string
+}
+
+func (e *notFoundError) Error() string {
+	return e.msg
+}
+
+func (e *notFoundError) Unwrap() error {
+	return pgx.ErrNoRows
+}
+
 // ParseError get human-readable DB error for resource
 func ParseError(rscName string, i interface{}, err error) error {
 	if err == nil {
@@ -18,7 +32,7 @@ func ParseError(rscName string, i interface{}, err error) error {
 	rscString := stringutil.StringifyInterface(i)
 	switch err {
 	case pgx.ErrNoRows:
-		return fmt.Errorf("unable to find %s, where %s", rscName, rscString)
+		return &notFoundError{fmt.Sprintf("unable to find %s, where %s", rscName, rscString)}
 	case pgx.ErrTxCommitRollback:
 		return fmt.Errorf("rolled back operation on %s, where %s", rscName, rscString)
 	case pgx.ErrTxClosed:
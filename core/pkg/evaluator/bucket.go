@@ -2,40 +2,86 @@ package evaluator
 
 import (
 	"core/pkg/model"
+	"fmt"
 
 	"github.com/cespare/xxhash/v2"
 )
 
-// BucketSize bucket size represents the max weights variations can add up to
-const BucketSize uint16 = 100
+// BucketSize bucket size represents the max weights variations can add up to,
+// weights are expressed in thousandths of a percent (i.e. 100000 = 100%, 100 = 0.1%)
+const BucketSize uint32 = 100000
 
-func precalculateAccumulatedWeights(variations []*model.Variation) []int8 {
-	accumulatedWeights := make([]int8, len(variations))
-	var currentBucket int8 = 0
+// legacyBucketSize bucket size used before weights were expressed in thousandths of a percent
+const legacyBucketSize uint64 = 100
+
+// ValidateWeights checks if the weights of the variations add up to BucketSize (i.e. 100%),
+// the weights of flags configured before weights were validated are normalized when
+// migrating them to thousandths of a percent (the remainder is added to the last variation)
+func ValidateWeights(variations []*model.Variation) error {
+	if len(variations) == 0 {
+		return nil
+	}
+
+	var total int64
+	for _, v := range variations {
+		if v.Weight < 0 || uint32(v.Weight) > BucketSize {
+			return fmt.Errorf("weight of variation %s must be between 0 and %d", v.VariationKey, BucketSize)
+		}
+		total += int64(v.Weight)
+	}
+	if total != int64(BucketSize) {
+		return fmt.Errorf("weights must add up to %d (i.e. 100%%), got %d", BucketSize, total)
+	}
+	return nil
+}
+
+func precalculateAccumulatedWeights(variations []*model.Variation) []uint32 {
+	accumulatedWeights := make([]uint32, len(variations))
+	var currentBucket uint32 = 0
 	for i, v := range variations {
-		currentBucket += v.Weight
+		if v.Weight > 0 {
+			currentBucket += uint32(v.Weight)
+		}
 		accumulatedWeights[i] = currentBucket
 	}
 	return accumulatedWeights
 }
 
+// deriveBucket maps the salt onto one of the BucketSize slots. The legacy bucket
+// (hash % 100) selects the percent, while the next digits of the hash select the
+// slot within that percent. So identities stay in the same percent they were
+// assigned to before, and weights that are multiples of 1000 serve the same variations.
+func deriveBucket(salt string) uint32 {
+	hash := xxhash.New()
+	_, _ = hash.Write([]byte(salt))
+	return bucketFromHash(hash.Sum64())
+}
+
+// bucketFromHash maps the salt's hash onto one of the BucketSize slots (see deriveBucket)
+func bucketFromHash(hashVal uint64) uint32 {
+	slotsPerPercent := uint64(BucketSize) / legacyBucketSize
+	percent := hashVal % legacyBucketSize
+	slot := (hashVal / legacyBucketSize) % slotsPerPercent
+	return uint32(percent*slotsPerPercent + slot)
+}
+
 func deriveVariationWithAccumulatedWeights(
 	salt string,
 	variations []*model.Variation,
-	accumulatedWeights []int8,
+	accumulatedWeights []uint32,
 ) string {
-	hash := xxhash.New()
-	_, _ = hash.Write([]byte(salt))
-	hashVal := hash.Sum64()
-	userBucket := int8(hashVal % uint64(BucketSize))
+	return variations[variationIndex(deriveBucket(salt), accumulatedWeights)].VariationKey
+}
 
+// variationIndex selects the variation the bucket falls into, buckets beyond
+// the accumulated weights (i.e. the remainder) fall into the last variation
+func variationIndex(bucket uint32, accumulatedWeights []uint32) int {
 	for i, currentBucket := range accumulatedWeights {
-		if userBucket < currentBucket {
-			return variations[i].VariationKey
+		if bucket < currentBucket {
+			return i
 		}
 	}
-
-	return variations[len(variations)-1].VariationKey
+	return len(accumulatedWeights) - 1
 }
 
 func deriveVariation(
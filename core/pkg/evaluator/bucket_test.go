@@ -5,13 +5,14 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/cespare/xxhash/v2"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestDeriveVariationSingleIteration(t *testing.T) {
 	variations := []*model.Variation{
-		{VariationKey: "A", Weight: 50},
-		{VariationKey: "B", Weight: 50},
+		{VariationKey: "A", Weight: 50000},
+		{VariationKey: "B", Weight: 50000},
 	}
 
 	derivedVariation := deriveVariation("some_salt", variations)
@@ -21,12 +22,12 @@ func TestDeriveVariationSingleIteration(t *testing.T) {
 
 func TestDeriveVariationMultipleIterations(t *testing.T) {
 	variations := []*model.Variation{
-		{VariationKey: "A", Weight: 16},
-		{VariationKey: "B", Weight: 16},
-		{VariationKey: "C", Weight: 16},
-		{VariationKey: "D", Weight: 16},
-		{VariationKey: "E", Weight: 16},
-		{VariationKey: "F", Weight: 16},
+		{VariationKey: "A", Weight: 16000},
+		{VariationKey: "B", Weight: 16000},
+		{VariationKey: "C", Weight: 16000},
+		{VariationKey: "D", Weight: 16000},
+		{VariationKey: "E", Weight: 16000},
+		{VariationKey: "F", Weight: 16000},
 	}
 
 	exposures := make(map[string]int)
@@ -45,3 +46,95 @@ func TestDeriveVariationMultipleIterations(t *testing.T) {
 	assert.Equal(t, exposures["E"], 15998878)
 	assert.Equal(t, exposures["F"], 19994827)
 }
+
+func TestDeriveVariationFineGrainedWeights(t *testing.T) {
+	// 0.1% canary
+	variations := []*model.Variation{
+		{VariationKey: "canary", Weight: 100},
+		{VariationKey: "stable", Weight: 99900},
+	}
+
+	exposures := make(map[string]int)
+	for i := 1; i <= 1000000; i++ {
+		exposures[deriveVariation(strconv.Itoa(i), variations)] += 1
+	}
+
+	assert.InDelta(t, 1000, exposures["canary"], 100)
+	assert.Equal(t, 1000000, exposures["canary"]+exposures["stable"])
+}
+
+func TestDeriveVariationZeroWeights(t *testing.T) {
+	variations := []*model.Variation{
+		{VariationKey: "A", Weight: 0},
+		{VariationKey: "B", Weight: 0},
+	}
+
+	assert.Equal(t, "B", deriveVariation("some_salt", variations))
+}
+
+// legacyDeriveVariation bucketing used when BucketSize was 100 and weights were percentages
+func legacyDeriveVariation(salt string, variations []*model.Variation) string {
+	hash := xxhash.New()
+	_, _ = hash.Write([]byte(salt))
+	userBucket := int32(hash.Sum64() % 100)
+
+	var currentBucket int32
+	for _, v := range variations {
+		currentBucket += v.Weight
+		if userBucket < currentBucket {
+			return v.VariationKey
+		}
+	}
+	return variations[len(variations)-1].VariationKey
+}
+
+func TestDeriveVariationLegacyAssignments(t *testing.T) {
+	weights := [][]int32{
+		{50, 50},
+		{10, 90},
+		{1, 99},
+		{33, 33, 34},
+		{0, 100},
+		{25, 25, 25, 25},
+	}
+
+	for _, w := range weights {
+		legacy := make([]*model.Variation, len(w))
+		migrated := make([]*model.Variation, len(w))
+		for i := range w {
+			key := strconv.Itoa(i)
+			legacy[i] = &model.Variation{VariationKey: key, Weight: w[i]}
+			// weights are migrated from percentages to thousandths of a percent
+			migrated[i] = &model.Variation{VariationKey: key, Weight: w[i] * 1000}
+		}
+
+		for i := 1; i <= 100000; i++ {
+			salt := strconv.Itoa(i)
+			if !assert.Equal(t, legacyDeriveVariation(salt, legacy), deriveVariation(salt, migrated), w) {
+				return
+			}
+		}
+	}
+}
+
+func TestValidateWeights(t *testing.T) {
+	tests := []struct {
+		name       string
+		variations []*model.Variation
+		valid      bool
+	}{
+		{"NoVariations", nil, true},
+		{"Single", []*model.Variation{{VariationKey: "A", Weight: 100000}}, true},
+		{"Split", []*model.Variation{{VariationKey: "A", Weight: 99900}, {VariationKey: "B", Weight: 100}}, true},
+		{"LegacyPercent", []*model.Variation{{VariationKey: "A", Weight: 50}, {VariationKey: "B", Weight: 50}}, false},
+		{"Over", []*model.Variation{{VariationKey: "A", Weight: 60000}, {VariationKey: "B", Weight: 50000}}, false},
+		{"Negative", []*model.Variation{{VariationKey: "A", Weight: 110000}, {VariationKey: "B", Weight: -10000}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateWeights(tt.variations)
+			assert.Equal(t, tt.valid, err == nil, err)
+		})
+	}
+}
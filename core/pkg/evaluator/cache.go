@@ -0,0 +1,112 @@
+package evaluator
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// cacheShards number of shards per cache, evaluations only contend
+// with each other when they look up values stored in the same shard
+const cacheShards = 16
+
+// CacheStats hit / miss counters of a matcher cache
+type CacheStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Size      int    `json:"size"`
+}
+
+// lruCache bounded cache split into shards, each shard evicts its least recently used entries
+type lruCache[V any] struct {
+	shards    [cacheShards]*lruShard[V]
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+type lruShard[V any] struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+}
+
+type lruEntry[V any] struct {
+	key   string
+	value V
+}
+
+// newLRUCache creates a cache holding up to size entries (at least one per shard)
+func newLRUCache[V any](size int) *lruCache[V] {
+	capacity := size / cacheShards
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	c := &lruCache[V]{}
+	for i := range c.shards {
+		c.shards[i] = &lruShard[V]{
+			capacity: capacity,
+			items:    make(map[string]*list.Element),
+			order:    list.New(),
+		}
+	}
+	return c
+}
+
+func (c *lruCache[V]) shard(key string) *lruShard[V] {
+	return c.shards[xxhash.Sum64String(key)%cacheShards]
+}
+
+// get looks up the key, computing (and storing) the value on a miss
+func (c *lruCache[V]) get(key string, compute func(key string) V) V {
+	s := c.shard(key)
+
+	s.mu.Lock()
+	if el, ok := s.items[key]; ok {
+		s.order.MoveToFront(el)
+		v := el.Value.(*lruEntry[V]).value
+		s.mu.Unlock()
+		c.hits.Add(1)
+		return v
+	}
+	s.mu.Unlock()
+
+	// values are computed outside of the lock, concurrent misses
+	// for the same key may compute the value more than once
+	c.misses.Add(1)
+	v := compute(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.items[key]; ok {
+		s.order.MoveToFront(el)
+		return v
+	}
+	s.items[key] = s.order.PushFront(&lruEntry[V]{key: key, value: v})
+	if s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.items, oldest.Value.(*lruEntry[V]).key)
+		c.evictions.Add(1)
+	}
+	return v
+}
+
+func (c *lruCache[V]) stats() CacheStats {
+	o := CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+	for _, s := range c.shards {
+		s.mu.Lock()
+		o.Size += s.order.Len()
+		s.mu.Unlock()
+	}
+	return o
+}
@@ -0,0 +1,110 @@
+package evaluator
+
+import (
+	"core/pkg/model"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCache(t *testing.T) {
+	c := newLRUCache[int](cacheShards)
+	computed := 0
+	compute := func(key string) int {
+		computed++
+		return len(key)
+	}
+
+	assert.Equal(t, 3, c.get("abc", compute))
+	assert.Equal(t, 3, c.get("abc", compute))
+	assert.Equal(t, 1, computed)
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1, Size: 1}, c.stats())
+}
+
+func TestLRUCacheEviction(t *testing.T) {
+	c := newLRUCache[string](cacheShards * 2)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprint(i)
+		c.get(key, func(key string) string { return key })
+	}
+
+	stats := c.stats()
+	assert.LessOrEqual(t, stats.Size, cacheShards*2)
+	assert.Equal(t, uint64(1000-stats.Size), stats.Evictions)
+
+	// the most recently used key is kept
+	c.get("999", func(key string) string { return "recomputed" })
+	assert.Equal(t, stats.Hits+1, c.stats().Hits)
+}
+
+func TestLRUCacheConcurrent(t *testing.T) {
+	c := newLRUCache[string](100)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := fmt.Sprint((i * g) % 300)
+				assert.Equal(t, key, c.get(key, func(key string) string { return key }))
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	stats := c.stats()
+	assert.Equal(t, uint64(8000), stats.Hits+stats.Misses)
+	assert.LessOrEqual(t, stats.Size, 100)
+}
+
+func TestPatternLimits(t *testing.T) {
+	SetCacheLimits(CacheLimits{MaxPatternLength: 32, MaxPatternComplexity: 100})
+	defer SetCacheLimits(DefaultCacheLimits)
+
+	tests := []struct {
+		name    string
+		pattern string
+		err     error
+	}{
+		{"Simple", `^user-\d+$`, nil},
+		{"TooLong", strings.Repeat("a", 33), errPatternTooLong},
+		{"TooComplex", `(a|b){50}`, errPatternTooComplex},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileRegexCached(tt.pattern)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.err, ValidatePattern(tt.pattern))
+		})
+	}
+
+	assert.False(t, Matcher[model.OPRegex](strings.Repeat("a", 40), strings.Repeat("a", 33)))
+
+	assert.NoError(t, ValidateClauses(
+		&model.Clause{TraitKey: "plan", Operator: model.OPEqual, TraitValue: "("},
+		&model.Clause{TraitKey: "email", Operator: model.OPRegex, TraitValue: `@flagbase\.com$`},
+	))
+	assert.ErrorIs(t, ValidateClauses(
+		&model.Clause{TraitKey: "email", Operator: model.OPRegex, TraitValue: `(a|b){50}`},
+	), errPatternTooComplex)
+	assert.Error(t, ValidateClauses(&model.Clause{TraitKey: "email", Operator: model.OPRegex, TraitValue: "("}))
+}
+
+func TestMatcherCacheStats(t *testing.T) {
+	SetCacheLimits(DefaultCacheLimits)
+
+	Matcher[model.OPGreaterThan](float64(5), "3")
+	Matcher[model.OPGreaterThan](float64(5), "3")
+	Matcher[model.OPRegex]("abc", "^a")
+	Matcher[model.OPSemverEqual]("1.0.0", "1.0.0")
+
+	stats := MatcherCacheStats()
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1, Size: 1}, stats["float"])
+	assert.Equal(t, CacheStats{Misses: 1, Size: 1}, stats["regex"])
+	assert.Equal(t, CacheStats{Misses: 1, Size: 1}, stats["semver"])
+}
@@ -0,0 +1,154 @@
+package evaluator
+
+import (
+	"core/pkg/model"
+	"strconv"
+	"strings"
+)
+
+// Trait coercion rules, applied before comparing a trait against a rule value:
+//
+//   - equality (equal, not_equal, equal_ignore_case): string traits are compared as-is,
+//     boolean traits match "true" / "false" (case-insensitive) and numeric traits are
+//     compared numerically against a rule value parsed as a number (i.e. 42 equals "42.0").
+//   - numeric comparisons (greater_than, less_than, ...): numeric traits are used as-is,
+//     numeric strings (e.g. "42", "-1.5") are parsed as numbers, anything else never matches.
+//   - string operators (contains, starts_with, ends_with, regex): only string traits can match.
+//   - list operators (in, not_in): string traits are used as-is, numeric and boolean traits
+//     are converted into their shortest string form (e.g. 42 -> "42", true -> "true").
+//
+// Traits which can't be coerced never match, regardless of the operator (incl. not_equal & not_in).
+
+// traitToFloat converts numeric traits and numeric strings into a float
+func traitToFloat(i interface{}) (float64, bool) {
+	switch v := i.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, false
+		}
+		return f, true
+	default:
+		return 0, false
+	}
+}
+
+// traitToString converts scalar traits into their string representation
+// so they can be compared against list values (e.g. 42 -> "42", true -> "true")
+func traitToString(i interface{}) (string, bool) {
+	switch v := i.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
+
+// parseBoolRule parses "true" / "false" rule values (case-insensitive)
+func parseBoolRule(r string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(r)) {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// equalTrait checks if the trait is equal to the rule value, ok is false if the
+// trait can't be coerced into the rule value's type (e.g. a boolean trait compared
+// against a rule value which isn't "true" / "false"), so neither equal nor not_equal match
+func equalTrait(i interface{}, r *operand, foldCase bool) (eq bool, ok bool) {
+	switch v := i.(type) {
+	case string:
+		if foldCase {
+			return strings.EqualFold(v, r.value), true
+		}
+		return v == r.value, true
+	case bool:
+		rV, ok := r.toBool()
+		if !ok {
+			return false, false
+		}
+		return v == rV, true
+	case float64, int, int64:
+		iV, _ := traitToFloat(v)
+		rV, ok := r.toFloat()
+		if !ok {
+			return false, false
+		}
+		return iV == rV, true
+	default:
+		return false, false
+	}
+}
+
+// compareFloat compares the input number against the rule number,
+// ok is false if either of them is not numeric
+func compareFloat(i interface{}, r *operand) (c int, ok bool) {
+	iV, ok := traitToFloat(i)
+	if !ok {
+		return 0, false
+	}
+	rV, ok := r.toFloat()
+	if !ok {
+		return 0, false
+	}
+	switch {
+	case iV < rV:
+		return -1, true
+	case iV > rV:
+		return 1, true
+	default:
+		return 0, true
+	}
+}
+
+// containsTrait checks if the trait's string representation (see traitToString)
+// is one of the values, ok is false if the trait isn't a scalar
+func containsTrait(values model.ValueSet, i interface{}) (found bool, ok bool) {
+	if iS, ok := i.(string); ok {
+		return values.Contains(iS), true
+	}
+	// numbers & booleans are converted on the stack, so matching them doesn't allocate
+	var scratch [32]byte
+	b, ok := appendTraitString(scratch[:0], i)
+	if !ok {
+		return false, false
+	}
+	_, found = values[string(b)]
+	return found, true
+}
+
+// appendTraitString appends the trait's string representation to buf (see traitToString)
+func appendTraitString(buf []byte, i interface{}) ([]byte, bool) {
+	switch v := i.(type) {
+	case string:
+		return append(buf, v...), true
+	case float64:
+		return strconv.AppendFloat(buf, v, 'f', -1, 64), true
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10), true
+	case int64:
+		return strconv.AppendInt(buf, v, 10), true
+	case bool:
+		return strconv.AppendBool(buf, v), true
+	default:
+		return buf, false
+	}
+}
@@ -0,0 +1,495 @@
+package evaluator
+
+import (
+	"core/pkg/model"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// CompiledFlagset a flagset prepared for repeated evaluation. Rule values are parsed
+// (numbers, booleans, semantic versions, dates) and regular expressions compiled once,
+// weight tables are precomputed and flags are ordered by their prerequisites. Evaluating
+// a compiled flagset is sequential and serves the same variations as EvaluateWithPrerequisites.
+// A compiled flagset is immutable, so it can be shared between goroutines.
+type CompiledFlagset struct {
+	// flags in dependency order (see DependencyLevels)
+	flags []*compiledFlag
+	// saltSize largest salt input, used to size the scratch buffer
+	saltSize int
+}
+
+type compiledFlag struct {
+	// pos position of the flag in the original flagset
+	pos     int
+	flagKey string
+	// off the flag was killed or its targeting is disabled (see isOff)
+	off bool
+	// control the variation served when the flag is off or a prerequisite
+	// failed, i.e. the off variation (see controlVariation)
+	control      string
+	controlValue model.Value
+	bucketBy     string
+	saltPrefix   string
+	// lengthPrefixed the salt's keys are prefixed by their length (i.e. the flag has a seed)
+	lengthPrefixed bool
+	prerequisites  []compiledPrerequisite
+	rules          []compiledRule
+	fallthroughs   compiledWeights
+	fallthroughWhy model.Reason
+	// holdout the environment's global holdout (see evaluateHoldout)
+	holdout int32
+	layer   *compiledLayer
+	// sticky the values of every variation the flag serves, nil if the flag isn't sticky
+	sticky map[string]model.Value
+}
+
+// compiledLayer the flag's layer, identities excluded from the
+// flag's experiment are served the flag's control variation
+type compiledLayer struct {
+	saltPrefix string
+	holdout    int32
+	start      int32
+	end        int32
+}
+
+type compiledPrerequisite struct {
+	// pos position of the prerequisite flag in the original flagset,
+	// -1 if the prerequisite can never be met (i.e. not in the flagset or cyclic)
+	pos          int
+	prerequisite *model.Prerequisite
+}
+
+type compiledRule struct {
+	ruleType     model.RuleType
+	identityKey  string
+	negate       bool
+	matchAny     bool
+	clauses      []compiledClause
+	segmentRules []compiledRule
+	variations   compiledWeights
+	reason       model.Reason
+}
+
+type compiledWeights struct {
+	variationKeys      []string
+	values             []model.Value
+	accumulatedWeights []uint32
+}
+
+// compiledClause a trait condition with its rule value parsed upfront (see parseOperand)
+type compiledClause struct {
+	traitKey string
+	operator model.Operator
+	negate   bool
+	operand  operand
+}
+
+// Compile prepares a flagset for evaluation (see CompiledFlagset)
+func Compile(flagset []*model.Flag) *CompiledFlagset {
+	c := &CompiledFlagset{
+		flags: make([]*compiledFlag, 0, len(flagset)),
+	}
+
+	pos := make(map[*model.Flag]int, len(flagset))
+	for idx, f := range flagset {
+		pos[f] = idx
+	}
+
+	// prerequisites are only met by flags evaluated in an earlier level,
+	// which mirrors evaluating each level concurrently
+	evaluatedPos := make(map[string]int, len(flagset))
+	for _, level := range DependencyLevels(flagset) {
+		compiled := make([]*compiledFlag, 0, len(level))
+		for _, f := range level {
+			cf := compileFlag(*f, pos[f], evaluatedPos)
+			if size := len(cf.saltPrefix) + 64; size > c.saltSize {
+				c.saltSize = size
+			}
+			if size := len(holdoutPrefix) + 64; size > c.saltSize {
+				c.saltSize = size
+			}
+			if cf.layer != nil {
+				if size := len(cf.layer.saltPrefix) + 64; size > c.saltSize {
+					c.saltSize = size
+				}
+			}
+			compiled = append(compiled, cf)
+		}
+		for _, cf := range compiled {
+			evaluatedPos[cf.flagKey] = cf.pos
+		}
+		c.flags = append(c.flags, compiled...)
+	}
+
+	return c
+}
+
+// Len number of flags in the compiled flagset
+func (c *CompiledFlagset) Len() int {
+	return len(c.flags)
+}
+
+// Evaluate evaluates every flag given the context, evaluations are
+// returned in the same order as the flagset that was compiled
+func (c *CompiledFlagset) Evaluate(ectx model.Context) model.Evaluations {
+	evals := c.EvaluateInto(ectx, nil)
+	o := make(model.Evaluations, len(evals))
+	for idx := range evals {
+		o[idx] = &evals[idx]
+	}
+	return o
+}
+
+// EvaluateInto evaluates every flag given the context into dst (grown if it's too
+// small), so callers can reuse the evaluations between calls. Evaluations are in
+// the same order as the flagset that was compiled.
+func (c *CompiledFlagset) EvaluateInto(ectx model.Context, dst []model.Evaluation) []model.Evaluation {
+	if cap(dst) < len(c.flags) {
+		dst = make([]model.Evaluation, len(c.flags))
+	}
+	dst = dst[:len(c.flags)]
+
+	var scratch [256]byte
+	buf := scratch[:0]
+	if c.saltSize > len(scratch) {
+		buf = make([]byte, 0, c.saltSize)
+	}
+
+	for _, f := range c.flags {
+		buf = f.evaluate(ectx, dst, buf)
+	}
+	return dst
+}
+
+// evaluate evaluates the flag into evals[f.pos], buf is a scratch buffer used to derive the salt
+func (f *compiledFlag) evaluate(ectx model.Context, evals []model.Evaluation, buf []byte) []byte {
+	o := &evals[f.pos]
+	*o = model.Evaluation{FlagKey: f.flagKey, Off: f.off}
+
+	prerequisitesMet := true
+	for _, p := range f.prerequisites {
+		if p.pos < 0 || !prerequisiteMet(p.prerequisite, &evals[p.pos]) {
+			prerequisitesMet = false
+			break
+		}
+	}
+
+	if !prerequisitesMet {
+		o.Reason = model.ReasonPrerequisiteFailed
+		o.VariationKey, o.Value = f.control, f.controlValue
+		return buf
+	}
+
+	if o.Off {
+		o.Reason = model.ReasonOff
+		o.VariationKey, o.Value = f.control, f.controlValue
+		return buf
+	}
+
+	var bucket uint32
+	bucket, buf = f.bucket(ectx, buf)
+
+	for idx := range f.rules {
+		r := &f.rules[idx]
+		if r.matches(ectx) {
+			o.Reason = r.reason
+			o.VariationKey, o.Value = r.variations.derive(bucket)
+			buf = f.evaluateHoldout(ectx, o, buf)
+			buf = f.evaluateLayer(ectx, o, buf)
+			f.evaluateSticky(ectx, o)
+			return buf
+		}
+	}
+
+	o.Reason = f.fallthroughWhy
+	o.VariationKey, o.Value = f.fallthroughs.derive(bucket)
+	buf = f.evaluateHoldout(ectx, o, buf)
+	buf = f.evaluateLayer(ectx, o, buf)
+	f.evaluateSticky(ectx, o)
+	return buf
+}
+
+// evaluateSticky serves the identity's recorded variation (see evaluateSticky)
+func (f *compiledFlag) evaluateSticky(ectx model.Context, o *model.Evaluation) {
+	if f.sticky == nil || !isWeighted(o.Reason) {
+		return
+	}
+	variationKey, ok := ectx.Assignments[f.flagKey]
+	if !ok {
+		return
+	}
+	if value, ok := f.sticky[variationKey]; ok {
+		o.Reason = model.ReasonSticky
+		o.VariationKey, o.Value = variationKey, value
+	}
+}
+
+// holdoutPrefix the global holdout's salt prefix (see deriveHoldoutBucket)
+var holdoutPrefix = lengthPrefixed(holdoutSaltPrefix)
+
+// evaluateHoldout serves the control variation if the identity is part
+// of the environment's global holdout (see evaluateHoldout)
+func (f *compiledFlag) evaluateHoldout(ectx model.Context, o *model.Evaluation, buf []byte) []byte {
+	if f.holdout <= 0 || !isWeighted(o.Reason) {
+		return buf
+	}
+
+	buf = f.appendBucketValue(ectx, append(buf[:0], holdoutPrefix...))
+	buf = insertLengthPrefix(buf, len(holdoutPrefix))
+	if inHoldout(f.holdout, hashBucket(buf)) {
+		o.Reason = model.ReasonHoldout
+		o.VariationKey, o.Value = f.control, f.controlValue
+	}
+	return buf
+}
+
+// evaluateLayer serves the control variation if the identity is excluded from
+// the flag's experiment by its layer (see evaluateLayer)
+func (f *compiledFlag) evaluateLayer(ectx model.Context, o *model.Evaluation, buf []byte) []byte {
+	if f.layer == nil || !isWeighted(o.Reason) {
+		return buf
+	}
+
+	buf = f.appendBucketValue(ectx, append(buf[:0], f.layer.saltPrefix...))
+	buf = insertLengthPrefix(buf, len(f.layer.saltPrefix))
+	reason, excluded := layerReason(model.Layer{
+		Holdout: f.layer.holdout,
+		Start:   f.layer.start,
+		End:     f.layer.end,
+	}, hashBucket(buf))
+	if excluded {
+		o.Reason = reason
+		o.VariationKey, o.Value = f.control, f.controlValue
+	}
+	return buf
+}
+
+// bucket derives the same bucket as deriveBucket(DeriveSalt(flag, ectx)),
+// using buf to avoid allocating the salt
+func (f *compiledFlag) bucket(ectx model.Context, buf []byte) (uint32, []byte) {
+	buf = f.appendBucketValue(ectx, append(buf[:0], f.saltPrefix...))
+	if f.lengthPrefixed {
+		buf = insertLengthPrefix(buf, len(f.saltPrefix))
+	}
+
+	return hashBucket(buf), buf
+}
+
+// appendBucketValue appends the value the context is bucketed by to buf (see bucketValue)
+func (f *compiledFlag) appendBucketValue(ectx model.Context, buf []byte) []byte {
+	if f.bucketBy != "" {
+		if trait, ok := ectx.Traits[f.bucketBy]; ok {
+			n := len(buf)
+			if buf, ok = appendTraitString(buf, trait); ok && len(buf) > n {
+				return buf
+			}
+			buf = buf[:n]
+		}
+	}
+	return append(buf, ectx.Identifier...)
+}
+
+// lengthPrefixed prefixes the key by its length (see hashutil.HashLengthPrefixed)
+func lengthPrefixed(key string) string {
+	return strconv.Itoa(len(key)) + ":" + key
+}
+
+// insertLengthPrefix prefixes the key appended to buf at pos by its length, without allocating
+func insertLengthPrefix(buf []byte, pos int) []byte {
+	var scratch [24]byte
+	prefix := append(strconv.AppendInt(scratch[:0], int64(len(buf)-pos), 10), ':')
+	buf = append(buf, prefix...)
+	copy(buf[pos+len(prefix):], buf[pos:len(buf)-len(prefix)])
+	copy(buf[pos:], prefix)
+	return buf
+}
+
+// hashBucket derives the same bucket as deriveBucket(HashKeys(...)) given the concatenated keys
+func hashBucket(keys []byte) uint32 {
+	sum := sha256.Sum256(keys)
+	var salt [sha256.Size * 2]byte
+	hex.Encode(salt[:], sum[:])
+	return bucketFromHash(xxhash.Sum64(salt[:]))
+}
+
+// derive selects the variation the bucket falls into (see deriveVariationWithAccumulatedWeights)
+func (w *compiledWeights) derive(bucket uint32) (string, model.Value) {
+	if len(w.variationKeys) == 0 {
+		return "", nil
+	}
+	idx := variationIndex(bucket, w.accumulatedWeights)
+	return w.variationKeys[idx], w.values[idx]
+}
+
+func (r *compiledRule) matches(ectx model.Context) bool {
+	var matches bool
+	switch r.ruleType {
+	case model.RuleTypeIdentity:
+		matches = matchIdentity(r.identityKey, ectx)
+		if r.negate {
+			matches = !matches
+		}
+	case model.RuleTypeSegment:
+		matches = len(r.segmentRules) > 0
+		for idx := range r.segmentRules {
+			if !r.segmentRules[idx].matchesTrait(ectx) {
+				matches = false
+				break
+			}
+		}
+		if r.negate {
+			matches = !matches
+		}
+	default:
+		matches = r.matchesTrait(ectx)
+	}
+	return matches
+}
+
+// matchesTrait combines the rule's clauses (see matchTrait)
+func (r *compiledRule) matchesTrait(ectx model.Context) bool {
+	for idx := range r.clauses {
+		if r.clauses[idx].matches(ectx) == r.matchAny {
+			return r.matchAny
+		}
+	}
+	return !r.matchAny
+}
+
+// matches checks the clause against the context (see matchClause)
+func (c *compiledClause) matches(ectx model.Context) bool {
+	trait, ok := ectx.Traits[c.traitKey]
+	return ok && matchOperandNegatable(c.operator, c.negate, trait, &c.operand)
+}
+
+func compileFlag(flag model.Flag, pos int, evaluatedPos map[string]int) *compiledFlag {
+	cf := &compiledFlag{
+		pos:          pos,
+		flagKey:      flag.FlagKey,
+		off:          isOff(flag),
+		holdout:      flag.Holdout,
+		bucketBy:     flag.BucketBy,
+		saltPrefix:   flag.FlagKey,
+		fallthroughs: compileWeights(flag, flag.FallthroughVariations),
+	}
+	if flag.Seed != "" {
+		cf.saltPrefix = lengthPrefixed(flag.FlagKey) + lengthPrefixed(flag.Seed)
+		cf.lengthPrefixed = true
+	}
+	cf.fallthroughWhy = model.ReasonFallthrough
+	if len(flag.FallthroughVariations) > 1 {
+		cf.fallthroughWhy = model.ReasonFallthroughWeighted
+	}
+	cf.control = controlVariation(flag)
+	cf.controlValue = variationValue(flag, cf.control)
+	if flag.Layer != nil {
+		cf.layer = &compiledLayer{
+			saltPrefix: lengthPrefixed(layerSaltPrefix) + lengthPrefixed(flag.Layer.LayerKey),
+			holdout:    flag.Layer.Holdout,
+			start:      flag.Layer.Start,
+			end:        flag.Layer.End,
+		}
+	}
+	if flag.Sticky {
+		cf.sticky = make(map[string]model.Value)
+		for _, v := range flag.FallthroughVariations {
+			cf.sticky[v.VariationKey] = variationValue(flag, v.VariationKey)
+		}
+		for _, r := range flag.Rules {
+			for _, v := range r.RuleVariations {
+				cf.sticky[v.VariationKey] = variationValue(flag, v.VariationKey)
+			}
+		}
+	}
+
+	for _, p := range flag.Prerequisites {
+		cp := compiledPrerequisite{pos: -1, prerequisite: p}
+		if idx, ok := evaluatedPos[p.FlagKey]; ok {
+			cp.pos = idx
+		}
+		cf.prerequisites = append(cf.prerequisites, cp)
+	}
+
+	cf.rules = make([]compiledRule, len(flag.Rules))
+	for idx, r := range flag.Rules {
+		cf.rules[idx] = compileRule(*r)
+		cf.rules[idx].variations = compileWeights(flag, r.RuleVariations)
+		cf.rules[idx].reason = model.ReasonTargeted
+		if len(r.RuleVariations) > 1 {
+			cf.rules[idx].reason = model.ReasonTargetedWeighted
+		}
+	}
+
+	return cf
+}
+
+func compileRule(rule model.Rule) compiledRule {
+	cr := compiledRule{
+		ruleType:    rule.RuleType,
+		identityKey: rule.IdentityKey,
+		negate:      rule.Negate,
+		matchAny:    rule.Match == model.MatchAny,
+	}
+
+	switch rule.RuleType {
+	case model.RuleTypeIdentity:
+	case model.RuleTypeSegment:
+		cr.segmentRules = make([]compiledRule, len(rule.SegmentRules))
+		for idx, sr := range rule.SegmentRules {
+			cr.segmentRules[idx] = compileRule(model.Rule{
+				TraitKey:    sr.TraitKey,
+				TraitValue:  sr.TraitValue,
+				TraitValues: sr.TraitValues,
+				Operator:    sr.Operator,
+				Negate:      sr.Negate,
+				Match:       sr.Match,
+				Clauses:     sr.Clauses,
+			})
+		}
+	default:
+		cr.ruleType = model.RuleTypeTrait
+		if len(rule.Clauses) == 0 {
+			// the rule's own trait condition is a single clause
+			cr.matchAny = false
+			cr.clauses = []compiledClause{compileClause(model.Clause{
+				TraitKey:    rule.TraitKey,
+				TraitValue:  rule.TraitValue,
+				TraitValues: rule.TraitValues,
+				Operator:    rule.Operator,
+				Negate:      rule.Negate,
+			})}
+			return cr
+		}
+		cr.clauses = make([]compiledClause, len(rule.Clauses))
+		for idx, c := range rule.Clauses {
+			cr.clauses[idx] = compileClause(*c)
+		}
+	}
+
+	return cr
+}
+
+func compileClause(clause model.Clause) compiledClause {
+	return compiledClause{
+		traitKey: clause.TraitKey,
+		operator: clause.Operator,
+		negate:   clause.Negate,
+		operand:  parseOperand(clause.Operator, clause.TraitValue, clause.TraitValues),
+	}
+}
+
+func compileWeights(flag model.Flag, variations []*model.Variation) compiledWeights {
+	w := compiledWeights{
+		variationKeys:      make([]string, len(variations)),
+		values:             make([]model.Value, len(variations)),
+		accumulatedWeights: precalculateAccumulatedWeights(variations),
+	}
+	for idx, v := range variations {
+		w.variationKeys[idx] = v.VariationKey
+		w.values[idx] = variationValue(flag, v.VariationKey)
+	}
+	return w
+}
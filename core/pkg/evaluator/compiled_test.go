@@ -0,0 +1,509 @@
+package evaluator
+
+import (
+	"core/pkg/model"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// compiledTestFlagset flagset covering every rule type, operator & flag state
+const compiledTestFlagset = `[
+	{
+		"flagKey": "payments",
+		"valueType": "boolean",
+		"fallthroughVariations": [
+			{"variationKey": "control", "weight": 50000, "value": false},
+			{"variationKey": "treatment", "weight": 50000, "value": true}
+		],
+		"rules": [
+			{"ruleType": "identity", "identityKey": "identity-7", "ruleVariations": [{"variationKey": "treatment", "weight": 100000}]},
+			{"ruleType": "trait", "traitKey": "plan", "operator": "in", "traitValues": ["enterprise", "team"], "ruleVariations": [{"variationKey": "treatment", "weight": 100000}]}
+		]
+	},
+	{
+		"flagKey": "checkout",
+		"valueType": "string",
+		"bucketBy": "company",
+		"seed": "2024",
+		"prerequisites": [{"flagKey": "payments", "variationKeys": ["treatment"]}],
+		"offVariationKey": "control",
+		"fallthroughVariations": [
+			{"variationKey": "control", "weight": 33333, "value": "a"},
+			{"variationKey": "treatment", "weight": 33333, "value": "b"},
+			{"variationKey": "other", "weight": 33334, "value": "c"}
+		],
+		"rules": [
+			{
+				"ruleType": "trait",
+				"match": "any",
+				"clauses": [
+					{"traitKey": "age", "operator": "greater_than_or_equal", "traitValue": "65"},
+					{"traitKey": "email", "operator": "ends_with", "traitValue": "@flagbase.com"},
+					{"traitKey": "country", "operator": "equal_ignore_case", "traitValue": "nz"}
+				],
+				"ruleVariations": [
+					{"variationKey": "control", "weight": 10000},
+					{"variationKey": "treatment", "weight": 90000}
+				]
+			},
+			{
+				"ruleType": "segment",
+				"segmentKey": "beta",
+				"segmentRules": [
+					{"traitKey": "version", "operator": "semver_greater_than_or_equal", "traitValue": "2.0.0"},
+					{"traitKey": "signup", "operator": "before", "traitValue": "now-30d"}
+				],
+				"ruleVariations": [{"variationKey": "other", "weight": 100000}]
+			}
+		]
+	},
+	{
+		"flagKey": "search",
+		"useFallthrough": true,
+		"offVariationKey": "control",
+		"fallthroughVariations": [
+			{"variationKey": "control", "weight": 0},
+			{"variationKey": "treatment", "weight": 100000}
+		]
+	},
+	{
+		"flagKey": "legacy-off",
+		"useFallthrough": true,
+		"fallthroughVariations": [
+			{"variationKey": "control", "weight": 20000},
+			{"variationKey": "treatment", "weight": 80000}
+		]
+	},
+	{
+		"flagKey": "killed",
+		"killed": true,
+		"fallthroughVariations": [
+			{"variationKey": "control", "weight": 0},
+			{"variationKey": "treatment", "weight": 100000}
+		]
+	},
+	{
+		"flagKey": "operators",
+		"prerequisites": [{"flagKey": "checkout", "variationKeys": ["treatment", "other"]}],
+		"fallthroughVariations": [{"variationKey": "control", "weight": 100000}],
+		"rules": [
+			{"ruleType": "trait", "traitKey": "plan", "operator": "not_in", "traitValues": ["free"], "negate": true, "ruleVariations": [{"variationKey": "a", "weight": 100000}]},
+			{"ruleType": "trait", "traitKey": "email", "operator": "regex", "traitValue": "^identity-[0-9]*5@", "ruleVariations": [{"variationKey": "b", "weight": 100000}]},
+			{"ruleType": "trait", "traitKey": "beta", "operator": "equal", "traitValue": "true", "ruleVariations": [{"variationKey": "c", "weight": 100000}]},
+			{"ruleType": "trait", "traitKey": "beta", "operator": "not_equal", "traitValue": "yes", "ruleVariations": [{"variationKey": "f", "weight": 100000}]},
+			{"ruleType": "trait", "traitKey": "age", "operator": "not_equal", "traitValue": "30", "ruleVariations": [{"variationKey": "d", "weight": 100000}]},
+			{"ruleType": "identity", "identityKey": "identity-3", "negate": true, "ruleVariations": [{"variationKey": "e", "weight": 100000}]}
+		]
+	},
+	{
+		"flagKey": "layer-a",
+		"bucketBy": "company",
+		"layer": {"layerKey": "checkout-page", "holdout": 10000, "start": 10000, "end": 55000},
+		"fallthroughVariations": [
+			{"variationKey": "control", "weight": 50000, "value": false},
+			{"variationKey": "treatment", "weight": 50000, "value": true}
+		],
+		"rules": [
+			{"ruleType": "identity", "identityKey": "identity-7", "ruleVariations": [{"variationKey": "treatment", "weight": 100000}]}
+		]
+	},
+	{
+		"flagKey": "layer-b",
+		"layer": {"layerKey": "checkout-page", "holdout": 10000, "start": 55000, "end": 100000},
+		"offVariationKey": "control",
+		"fallthroughVariations": [
+			{"variationKey": "treatment", "weight": 50000},
+			{"variationKey": "control", "weight": 50000}
+		],
+		"rules": [
+			{"ruleType": "trait", "traitKey": "beta", "operator": "equal", "traitValue": "true", "ruleVariations": [{"variationKey": "treatment", "weight": 50000}, {"variationKey": "control", "weight": 50000}]}
+		]
+	},
+	{
+		"flagKey": "sticky",
+		"sticky": true,
+		"valueType": "string",
+		"fallthroughVariations": [
+			{"variationKey": "control", "weight": 30000, "value": "a"},
+			{"variationKey": "treatment", "weight": 70000, "value": "b"}
+		],
+		"rules": [
+			{"ruleType": "trait", "traitKey": "beta", "operator": "equal", "traitValue": "true", "ruleVariations": [{"variationKey": "beta", "weight": 100000, "value": "c"}]}
+		]
+	},
+	{
+		"flagKey": "cycle-a",
+		"prerequisites": [{"flagKey": "cycle-b", "variationKeys": ["control"]}],
+		"fallthroughVariations": [{"variationKey": "control", "weight": 100000}]
+	},
+	{
+		"flagKey": "cycle-b",
+		"prerequisites": [{"flagKey": "cycle-a", "variationKeys": ["control"]}],
+		"fallthroughVariations": [{"variationKey": "control", "weight": 100000}]
+	}
+]`
+
+// randomOperators every operator, incl. one that isn't supported
+var randomOperators = []model.Operator{
+	model.OPEqual, model.OPNotEqual, model.OPEqualIgnoreCase,
+	model.OPContains, model.OPStartsWith, model.OPEndsWith,
+	model.OPGreaterThan, model.OPGreaterThanOrEqual, model.OPLessThan, model.OPLessThanOrEqual,
+	model.OPRegex,
+	model.OPSemverEqual, model.OPSemverGreaterThan, model.OPSemverGreaterThanOrEqual,
+	model.OPSemverLessThan, model.OPSemverLessThanOrEqual,
+	model.OPBefore, model.OPAfter,
+	model.OPIn, model.OPNotIn,
+	"similar_to",
+}
+
+// randomTraits the trait values contexts are drawn from, rule values are drawn from their
+// string form (see randomRuleValues) so rules match some of the time
+var randomTraits = map[string][]interface{}{
+	"plan":    {"free", "team", "enterprise", "Team", float64(42), true},
+	"email":   {"identity-5@flagbase.com", "identity-15@example.com", "someone@flagbase.com"},
+	"country": {"NZ", "nz", "US", ""},
+	"version": {"1.0.0", "1.5.0", "2.0.0", "2.0.0-beta.2", "2.6.0", "v2", float64(2)},
+	"signup":  {"2023-01-01", "2023-09-15T13:00:00+02:00", float64(1695000000000), int64(1600000000000), "yesterday"},
+	"beta":    {true, false, "true", "yes"},
+	"age":     {float64(17), float64(30), 65.5, "30", " 45 ", int(45), int64(90), "abc"},
+	"company": {"company-1", "company-2", float64(3), ""},
+}
+
+var randomRuleValues = []string{
+	"free", "team", "enterprise", "NZ", "nz", "42", "17", "30", "30.0", "45.5", "65", "true", "TRUE", "yes",
+	"1.0.0", "1.5.0", "2.0.0", "2.0.0-beta.10", "2023-06-01", "2023-09-15T12:00:00Z", "1695000000000",
+	"now", "now-30d", "now+1w", "now-x", "^identity-[0-9]*5@", "@flagbase.com", "identity-", "(", "",
+}
+
+func randomTraitKey(rnd *rand.Rand) string {
+	keys := []string{"plan", "email", "country", "version", "signup", "beta", "age", "company", "unknown"}
+	return keys[rnd.Intn(len(keys))]
+}
+
+func randomClause(rnd *rand.Rand) *model.Clause {
+	c := &model.Clause{
+		TraitKey:   randomTraitKey(rnd),
+		Operator:   randomOperators[rnd.Intn(len(randomOperators))],
+		TraitValue: randomRuleValues[rnd.Intn(len(randomRuleValues))],
+		Negate:     rnd.Intn(4) == 0,
+	}
+	if c.Operator == model.OPIn || c.Operator == model.OPNotIn {
+		values := make([]string, 1+rnd.Intn(3))
+		for idx := range values {
+			values[idx] = randomRuleValues[rnd.Intn(len(randomRuleValues))]
+		}
+		c.TraitValues = model.NewValueSet(values...)
+	}
+	return c
+}
+
+// randomCondition a rule with either a single trait condition or clauses
+func randomCondition(rnd *rand.Rand) *model.Rule {
+	r := &model.Rule{RuleType: model.RuleTypeTrait}
+	if rnd.Intn(2) == 0 {
+		c := randomClause(rnd)
+		r.TraitKey, r.Operator, r.TraitValue, r.TraitValues, r.Negate = c.TraitKey, c.Operator, c.TraitValue, c.TraitValues, c.Negate
+		return r
+	}
+	r.Match = []model.MatchType{"", model.MatchAll, model.MatchAny}[rnd.Intn(3)]
+	for n := rnd.Intn(4); n > 0; n-- {
+		r.Clauses = append(r.Clauses, randomClause(rnd))
+	}
+	return r
+}
+
+// randomVariations weights mostly add up to 100000, the remainder is served to the last variation
+func randomVariations(rnd *rand.Rand) []*model.Variation {
+	keys := []string{"control", "treatment", "other"}
+	variations := make([]*model.Variation, 1+rnd.Intn(len(keys)))
+	remaining := int32(100000)
+	if rnd.Intn(5) == 0 {
+		remaining = rnd.Int31n(100000)
+	}
+	for idx := range variations {
+		v := &model.Variation{VariationKey: keys[idx]}
+		if idx == len(variations)-1 {
+			v.Weight = remaining
+		} else {
+			v.Weight = rnd.Int31n(remaining + 1)
+		}
+		remaining -= v.Weight
+		if rnd.Intn(2) == 0 {
+			v.Value = model.Value(fmt.Sprintf(`"%s"`, v.VariationKey))
+		}
+		variations[idx] = v
+	}
+	return variations
+}
+
+// randomFlagset generates a flagset covering every rule type, operator & flag
+// state, incl. missing & cyclic prerequisites
+func randomFlagset(rnd *rand.Rand, size int) []*model.Flag {
+	flags := make([]*model.Flag, size)
+	for idx := range flags {
+		f := &model.Flag{
+			FlagKey:               fmt.Sprintf("flag-%d", idx),
+			UseFallthrough:        rnd.Intn(8) == 0,
+			Killed:                rnd.Intn(12) == 0,
+			BucketBy:              []string{"", "", "company", "age", "beta"}[rnd.Intn(5)],
+			Seed:                  []string{"", "", "2024"}[rnd.Intn(3)],
+			Sticky:                rnd.Intn(4) == 0,
+			FallthroughVariations: randomVariations(rnd),
+		}
+		if rnd.Intn(3) == 0 {
+			f.OffVariationKey = "control"
+		}
+		if rnd.Intn(4) == 0 {
+			f.Holdout = rnd.Int31n(30000)
+		}
+		if rnd.Intn(4) == 0 {
+			start := rnd.Int31n(100000)
+			f.Layer = &model.Layer{
+				LayerKey: []string{"layer-a", "layer-b"}[rnd.Intn(2)],
+				Holdout:  rnd.Int31n(20000),
+				Start:    start,
+				End:      start + rnd.Int31n(100000-start+1),
+			}
+		}
+		for n := rnd.Intn(3); n > 0; n-- {
+			flagKey := fmt.Sprintf("flag-%d", rnd.Intn(size+1))
+			f.Prerequisites = append(f.Prerequisites, &model.Prerequisite{
+				FlagKey:       flagKey,
+				VariationKeys: []string{[]string{"control", "treatment", "other"}[rnd.Intn(3)]},
+			})
+		}
+		for n := rnd.Intn(5); n > 0; n-- {
+			var r *model.Rule
+			switch rnd.Intn(4) {
+			case 0:
+				r = &model.Rule{
+					RuleType:    model.RuleTypeIdentity,
+					IdentityKey: fmt.Sprintf("identity-%d", rnd.Intn(20)),
+					Negate:      rnd.Intn(4) == 0,
+				}
+			case 1:
+				r = &model.Rule{RuleType: model.RuleTypeSegment, SegmentKey: "segment", Negate: rnd.Intn(4) == 0}
+				for n := rnd.Intn(3); n > 0; n-- {
+					r.SegmentRules = append(r.SegmentRules, randomCondition(rnd))
+				}
+			default:
+				r = randomCondition(rnd)
+			}
+			r.RuleVariations = randomVariations(rnd)
+			f.Rules = append(f.Rules, r)
+		}
+		flags[idx] = f
+	}
+	return flags
+}
+
+// randomContext generates a context with some of the traits
+// (see randomTraits) & sticky assignments for the flags
+func randomContext(rnd *rand.Rand, flags []*model.Flag) model.Context {
+	o := model.Context{
+		Identifier: fmt.Sprintf("identity-%d", rnd.Intn(20)),
+		Traits:     make(map[string]interface{}),
+	}
+	for key, values := range randomTraits {
+		if rnd.Intn(4) != 0 {
+			o.Traits[key] = values[rnd.Intn(len(values))]
+		}
+	}
+	for _, f := range flags {
+		if rnd.Intn(3) == 0 {
+			if o.Assignments == nil {
+				o.Assignments = make(map[string]string)
+			}
+			o.Assignments[f.FlagKey] = []string{"control", "treatment", "other", "deleted"}[rnd.Intn(4)]
+		}
+	}
+	return o
+}
+
+// evaluateFlagset evaluates the flagset the same way the evaluation service did before
+// flagsets were compiled (i.e. a goroutine per flag, one level at a time)
+func evaluateFlagset(flags []*model.Flag, ectx model.Context) model.Evaluations {
+	evaluated := make(map[string]*model.Evaluation, len(flags))
+	for _, level := range DependencyLevels(flags) {
+		results := make(chan *model.Evaluation, len(level))
+		for _, flag := range level {
+			go func(flag model.Flag) {
+				results <- EvaluateWithPrerequisites(flag, DeriveSalt(flag, ectx), ectx, evaluated)
+			}(*flag)
+		}
+
+		evals := make([]*model.Evaluation, 0, len(level))
+		for range level {
+			evals = append(evals, <-results)
+		}
+		for _, eval := range evals {
+			evaluated[eval.FlagKey] = eval
+		}
+	}
+
+	o := make(model.Evaluations, len(flags))
+	for idx, flag := range flags {
+		o[idx] = evaluated[flag.FlagKey]
+	}
+	return o
+}
+
+func TestCompiledFlagsetMatchesEvaluate(t *testing.T) {
+	var flags []*model.Flag
+	assert.NoError(t, json.Unmarshal([]byte(compiledTestFlagset), &flags))
+	compiled := Compile(flags)
+	assert.Equal(t, len(flags), compiled.Len())
+
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		ectx := randomContext(rnd, flags)
+		assert.Equal(t, evaluateFlagset(flags, ectx), compiled.Evaluate(ectx), ectx.Identifier)
+	}
+}
+
+// TestCompiledFlagsetDifferential compiled flagsets serve the same evaluations
+// as evaluating each flag, given randomized flagsets & contexts
+func TestCompiledFlagsetDifferential(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		flags := randomFlagset(rnd, 1+rnd.Intn(12))
+		compiled := Compile(flags)
+		for j := 0; j < 40; j++ {
+			ectx := randomContext(rnd, flags)
+			if !assert.Equal(t, evaluateFlagset(flags, ectx), compiled.Evaluate(ectx), "flagset %d, context %d", i, j) {
+				return
+			}
+		}
+	}
+}
+
+func TestCompiledFlagsetReasons(t *testing.T) {
+	var flags []*model.Flag
+	assert.NoError(t, json.Unmarshal([]byte(compiledTestFlagset), &flags))
+
+	evals := Compile(flags).Evaluate(model.Context{Identifier: "identity-7"})
+
+	reasons := make(map[string]model.Reason)
+	for _, eval := range evals {
+		reasons[eval.FlagKey] = eval.Reason
+	}
+	assert.Equal(t, map[string]model.Reason{
+		"payments":   model.ReasonTargeted,
+		"checkout":   model.ReasonFallthroughWeighted,
+		"search":     model.ReasonOff,
+		"legacy-off": model.ReasonOff,
+		"killed":     model.ReasonOff,
+		"operators":  model.ReasonTargeted,
+		"layer-a":    model.ReasonTargeted,
+		"layer-b":    model.ReasonLayerExcluded,
+		"sticky":     model.ReasonFallthroughWeighted,
+		"cycle-a":    model.ReasonPrerequisiteFailed,
+		"cycle-b":    model.ReasonPrerequisiteFailed,
+	}, reasons)
+}
+
+func TestCompiledFlagsetConcurrent(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	flags := randomFlagset(rnd, 20)
+	contexts := make([]model.Context, 1000)
+	for idx := range contexts {
+		contexts[idx] = randomContext(rnd, flags)
+	}
+	compiled := Compile(flags)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			var evals []model.Evaluation
+			for i := g; i < len(contexts); i += 8 {
+				evals = compiled.EvaluateInto(contexts[i], evals)
+				for idx, eval := range evaluateFlagset(flags, contexts[i]) {
+					assert.Equal(t, *eval, evals[idx])
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func TestCompiledFlagsetEvaluateIntoAllocations(t *testing.T) {
+	var flags []*model.Flag
+	assert.NoError(t, json.Unmarshal([]byte(compiledTestFlagset), &flags))
+	compiled := Compile(flags)
+	ectx := model.Context{
+		Identifier: "identity-1",
+		Traits: map[string]interface{}{
+			"plan":    float64(42),
+			"email":   "identity-1@example.com",
+			"country": "US",
+			"version": "1.1.0",
+			"signup":  "2023-02-01",
+			"beta":    false,
+			"age":     float64(1),
+			"company": "company-1",
+		},
+		Assignments: map[string]string{"sticky": "control"},
+	}
+	evals := compiled.EvaluateInto(ectx, nil)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		evals = compiled.EvaluateInto(ectx, evals)
+	})
+	assert.Zero(t, allocs)
+}
+
+func BenchmarkEvaluateFlagset(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	flags := randomFlagset(rnd, 50)
+	ectx := randomContext(rnd, flags)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		evaluateFlagset(flags, ectx)
+	}
+}
+
+func BenchmarkCompiledFlagsetEvaluate(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	flags := randomFlagset(rnd, 50)
+	compiled := Compile(flags)
+	ectx := randomContext(rnd, flags)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		compiled.Evaluate(ectx)
+	}
+}
+
+func BenchmarkCompiledFlagsetEvaluateInto(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	flags := randomFlagset(rnd, 50)
+	compiled := Compile(flags)
+	ectx := randomContext(rnd, flags)
+	var evals []model.Evaluation
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		evals = compiled.EvaluateInto(ectx, evals)
+	}
+}
+
+func BenchmarkCompile(b *testing.B) {
+	flags := randomFlagset(rand.New(rand.NewSource(1)), 50)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Compile(flags)
+	}
+}
@@ -0,0 +1,111 @@
+package evaluator
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// now current time, overridden in tests
+var now = time.Now
+
+var errInvalidTime = errors.New("invalid date or timestamp")
+
+// parseTimeTrait converts a trait into a point in time. Traits can either be
+// RFC3339 / date (YYYY-MM-DD) strings or epoch milliseconds.
+func parseTimeTrait(i interface{}) (time.Time, error) {
+	switch v := i.(type) {
+	case string:
+		return parseTimeString(v)
+	case float64:
+		return time.UnixMilli(int64(v)), nil
+	case int:
+		return time.UnixMilli(int64(v)), nil
+	case int64:
+		return time.UnixMilli(v), nil
+	default:
+		return time.Time{}, errInvalidTime
+	}
+}
+
+// parseTimeRule converts a rule value into a point in time. Rule values can
+// either be RFC3339 / date (YYYY-MM-DD) strings, epoch milliseconds or a time
+// relative to the evaluation (i.e. "now", "now-30d", "now+12h").
+func parseTimeRule(r string) (time.Time, error) {
+	if strings.HasPrefix(r, "now") {
+		offset, err := parseRelativeOffset(r[len("now"):])
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now().Add(offset), nil
+	}
+
+	if ms, err := strconv.ParseInt(r, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+
+	return parseTimeString(r)
+}
+
+func parseTimeString(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errInvalidTime
+}
+
+// parseRelativeOffset parses offsets such as "-30d" or "+2h". Supported units
+// are s (seconds), m (minutes), h (hours), d (days) & w (weeks).
+func parseRelativeOffset(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if len(s) < 3 || (s[0] != '-' && s[0] != '+') {
+		return 0, errInvalidTime
+	}
+
+	n, err := strconv.ParseInt(s[1:len(s)-1], 10, 64)
+	if err != nil || n < 0 {
+		return 0, errInvalidTime
+	}
+
+	var unit time.Duration
+	switch s[len(s)-1] {
+	case 's':
+		unit = time.Second
+	case 'm':
+		unit = time.Minute
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, errInvalidTime
+	}
+
+	offset := time.Duration(n) * unit
+	if s[0] == '-' {
+		offset = -offset
+	}
+	return offset, nil
+}
+
+// compareTime compares the input time against the rule time,
+// ok is false if either of them can't be converted into a time
+func compareTime(i interface{}, r *operand) (c int, ok bool) {
+	iT, err := parseTimeTrait(i)
+	if err != nil {
+		return 0, false
+	}
+	rT, ok := r.toTime()
+	if !ok {
+		return 0, false
+	}
+	return iT.Compare(rT), true
+}
@@ -0,0 +1,75 @@
+package evaluator
+
+import (
+	"core/pkg/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTimeRule(t *testing.T) {
+	fixed := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return fixed }
+	defer func() { now = time.Now }()
+
+	tests := []struct {
+		rule     string
+		expected time.Time
+		valid    bool
+	}{
+		{"2024-01-01T00:00:00Z", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"2024-01-01T10:00:00+10:00", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"2024-01-01", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"1704067200000", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"now", fixed, true},
+		{"now-30d", fixed.Add(-30 * 24 * time.Hour), true},
+		{"now+2w", fixed.Add(14 * 24 * time.Hour), true},
+		{"now-12h", fixed.Add(-12 * time.Hour), true},
+		{"now-30", time.Time{}, false},
+		{"now*3d", time.Time{}, false},
+		{"now-3y", time.Time{}, false},
+		{"yesterday", time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			result, err := parseTimeRule(tt.rule)
+			assert.Equal(t, tt.valid, err == nil)
+			if tt.valid {
+				assert.True(t, tt.expected.Equal(result), "expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestTimeMatcher(t *testing.T) {
+	fixed := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return fixed }
+	defer func() { now = time.Now }()
+
+	tests := []struct {
+		name     string
+		operator model.Operator
+		input    interface{}
+		rule     string
+		expected bool
+	}{
+		{"BeforeRFC3339", model.OPBefore, "2023-12-31T23:59:59Z", "2024-01-01", true},
+		{"BeforeEpochMillis", model.OPBefore, float64(1704067199000), "2024-01-01T00:00:00Z", true},
+		{"NotBefore", model.OPBefore, "2024-01-01T00:00:00Z", "2024-01-01T00:00:00Z", false},
+		{"AfterRelative", model.OPAfter, "2024-06-01T00:00:00Z", "now-30d", true},
+		{"NotAfterRelative", model.OPAfter, "2024-05-01T00:00:00Z", "now-30d", false},
+		{"TrialEndsAfterNow", model.OPAfter, float64(fixed.Add(time.Hour).UnixMilli()), "now", true},
+		{"InvalidInput", model.OPAfter, "last tuesday", "now", false},
+		{"InvalidType", model.OPBefore, true, "now", false},
+		{"InvalidRule", model.OPBefore, "2024-01-01", "soon", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Matcher[tt.operator](tt.input, tt.rule)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -9,15 +9,46 @@ func Evaluate(
 	flag model.Flag,
 	salt string,
 	ectx model.Context,
+) *model.Evaluation {
+	return evaluate(flag, salt, ectx, nil)
+}
+
+// Explain evaluates a flag the same way as Evaluate, while tracing how the
+// variation was derived (i.e. rules & clauses checked, bucket & weight ranges)
+func Explain(
+	flag model.Flag,
+	salt string,
+	ectx model.Context,
+) *model.Evaluation {
+	trace := newTrace()
+	o := evaluate(flag, salt, ectx, trace)
+	o.Trace = trace
+	return o
+}
+
+// evaluate derives the flag's variation, the trace is only
+// recorded when one is passed (i.e. explain mode)
+func evaluate(
+	flag model.Flag,
+	salt string,
+	ectx model.Context,
+	trace *model.Trace,
 ) *model.Evaluation {
 	o := &model.Evaluation{
 		FlagKey: flag.FlagKey,
+		Off:     isOff(flag),
+	}
+
+	if o.Off {
+		o.Reason, o.VariationKey = evaluateOff(flag, trace)
+		o.Value = variationValue(flag, o.VariationKey)
+		return o
 	}
 
 	matched := false
 
-	if !flag.UseFallthrough && len(flag.Rules) > 0 {
-		eval, match := evaluateRules(flag.Rules, salt, ectx)
+	if len(flag.Rules) > 0 {
+		eval, match := evaluateRules(flag.Rules, salt, ectx, trace)
 		if match {
 			o.Reason = eval.Reason
 			o.VariationKey = eval.VariationKey
@@ -26,27 +57,107 @@ func Evaluate(
 	}
 
 	if !matched {
-		o.Reason = model.ReasonFallthrough
-		if len(flag.FallthroughVariations) > 1 {
-			o.Reason = model.ReasonFallthroughWeighted
+		o.Reason, o.VariationKey = evaluateFallthrough(flag, salt, trace)
+	}
+
+	// the global holdout & layers only split identities
+	// between experiments (i.e. bucketed variations)
+	if flag.Holdout > 0 && isWeighted(o.Reason) {
+		if evaluateHoldout(flag, ectx, trace) {
+			o.Reason, o.VariationKey = model.ReasonHoldout, controlVariation(flag)
+		}
+	}
+	if flag.Layer != nil && isWeighted(o.Reason) {
+		if reason, excluded := evaluateLayer(flag, ectx, trace); excluded {
+			o.Reason, o.VariationKey = reason, controlVariation(flag)
+		}
+	}
+
+	if flag.Sticky && isWeighted(o.Reason) {
+		if variationKey, ok := evaluateSticky(flag, ectx, trace); ok {
+			o.Reason, o.VariationKey = model.ReasonSticky, variationKey
 		}
-		o.VariationKey = deriveVariation(
-			salt,
-			flag.FallthroughVariations,
-		)
 	}
 
+	o.Value = variationValue(flag, o.VariationKey)
+
 	return o
 }
 
+// evaluateFallthrough derives the fallthrough variation
+func evaluateFallthrough(
+	flag model.Flag,
+	salt string,
+	trace *model.Trace,
+) (model.Reason, string) {
+	reason := model.ReasonFallthrough
+	if len(flag.FallthroughVariations) > 1 {
+		reason = model.ReasonFallthroughWeighted
+	}
+	if trace != nil {
+		traceWeights(salt, flag.FallthroughVariations, trace)
+	}
+	return reason, deriveVariation(
+		salt,
+		flag.FallthroughVariations,
+	)
+}
+
+// isOff checks if the flag is off, i.e. it was killed or its targeting is disabled
+func isOff(flag model.Flag) bool {
+	return flag.Killed || flag.UseFallthrough
+}
+
+// evaluateOff derives the variation served when the flag is off, i.e. the control
+// variation (see controlVariation). Off flags never bucket identities into treatment.
+func evaluateOff(
+	flag model.Flag,
+	trace *model.Trace,
+) (model.Reason, string) {
+	if trace != nil {
+		trace.Off = true
+	}
+	return model.ReasonOff, controlVariation(flag)
+}
+
+// variationValue looks up the value served by the variation, variations are
+// listed under both the fallthrough and the rules of a flag
+func variationValue(flag model.Flag, variationKey string) model.Value {
+	for _, v := range flag.FallthroughVariations {
+		if v.VariationKey == variationKey && !v.Value.IsEmpty() {
+			return v.Value
+		}
+	}
+	for _, r := range flag.Rules {
+		for _, v := range r.RuleVariations {
+			if v.VariationKey == variationKey && !v.Value.IsEmpty() {
+				return v.Value
+			}
+		}
+	}
+	return nil
+}
+
 func evaluateRules(
 	rules []*model.Rule,
 	salt string,
 	ectx model.Context,
+	trace *model.Trace,
 ) (eval *model.Evaluation, matched bool) {
-	for _, r := range rules {
-		eval, matched = evaluateRule(*r, salt, ectx)
+	for idx, r := range rules {
+		var rt *model.RuleTrace
+		if trace != nil {
+			rt = newRuleTrace(idx, *r)
+			trace.Rules = append(trace.Rules, rt)
+		}
+
+		eval, matched = evaluateRule(*r, salt, ectx, rt)
 		if matched {
+			if trace != nil {
+				trace.MatchedRuleIndex = idx
+				trace.MatchedRuleID = r.ID
+				traceWeights(salt, r.RuleVariations, trace)
+			}
 			return eval, true
 		}
 	}
@@ -58,19 +169,28 @@ func evaluateRule(
 	rule model.Rule,
 	salt string,
 	ectx model.Context,
+	rt *model.RuleTrace,
 ) (o *model.Evaluation, matched bool) {
 	o = &model.Evaluation{}
 
-	if _, ok := ectx.Traits[rule.TraitKey]; !ok {
-		return nil, false
+	var matches bool
+	switch rule.RuleType {
+	case model.RuleTypeIdentity:
+		matches = matchIdentity(rule.IdentityKey, ectx)
+		if rule.Negate {
+			matches = !matches
+		}
+	case model.RuleTypeSegment:
+		matches = matchSegment(rule.SegmentRules, ectx, rt)
+		if rule.Negate {
+			matches = !matches
+		}
+	default:
+		matches = matchTrait(rule, ectx, rt)
 	}
 
-	matches := Matcher[rule.Operator](
-		ectx.Traits[rule.TraitKey],
-		rule.TraitValue,
-	)
-	if rule.Negate {
-		matches = !matches
+	if rt != nil {
+		rt.Matched = matches
 	}
 
 	if !matches {
@@ -87,3 +207,136 @@ func evaluateRule(
 	)
 	return o, true
 }
+
+// matchTrait checks the rule's trait conditions against the context. Rules with
+// clauses combine them using the rule's match type (defaults to all), otherwise
+// the rule's own trait condition is used.
+func matchTrait(
+	rule model.Rule,
+	ectx model.Context,
+	rt *model.RuleTrace,
+) bool {
+	if len(rule.Clauses) == 0 {
+		return matchClause(model.Clause{
+			TraitKey:    rule.TraitKey,
+			TraitValue:  rule.TraitValue,
+			TraitValues: rule.TraitValues,
+			Operator:    rule.Operator,
+			Negate:      rule.Negate,
+		}, ectx, rt)
+	}
+
+	if rt != nil {
+		// every clause is traced, rather than stopping at the first conclusive one
+		matches := make([]bool, len(rule.Clauses))
+		for idx, c := range rule.Clauses {
+			matches[idx] = matchClause(*c, ectx, rt)
+		}
+		return combineClauses(rule.Match, matches)
+	}
+
+	switch rule.Match {
+	case model.MatchAny:
+		for _, c := range rule.Clauses {
+			if matchClause(*c, ectx, nil) {
+				return true
+			}
+		}
+		return false
+	default:
+		for _, c := range rule.Clauses {
+			if !matchClause(*c, ectx, nil) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// combineClauses combines the clause results using the match type (defaults to all)
+func combineClauses(match model.MatchType, matches []bool) bool {
+	for _, m := range matches {
+		if m == (match == model.MatchAny) {
+			return m
+		}
+	}
+	return match != model.MatchAny
+}
+
+// matchClause checks a single trait condition against the context,
+// a clause never matches when the context is missing the trait
+func matchClause(
+	clause model.Clause,
+	ectx model.Context,
+	rt *model.RuleTrace,
+) bool {
+	trait, ok := ectx.Traits[clause.TraitKey]
+	matches := ok && matchTraitValue(clause, trait)
+	if rt != nil {
+		rt.Clauses = append(rt.Clauses, newClauseTrace(clause, trait, ok, matches))
+	}
+	return matches
+}
+
+// matchTraitValue checks the trait value against the clause's condition
+func matchTraitValue(
+	clause model.Clause,
+	trait interface{},
+) bool {
+	return matchOperandNegatable(clause.Operator, clause.Negate, trait, &operand{
+		value:  clause.TraitValue,
+		values: clause.TraitValues,
+	})
+}
+
+// matchOperandNegatable compares the trait against the operand (see matchOperand),
+// clauses with an unknown operator never match, even if they're negated
+func matchOperandNegatable(
+	op model.Operator,
+	negate bool,
+	trait interface{},
+	r *operand,
+) bool {
+	matches, ok := matchOperand(op, trait, r)
+	if !ok {
+		return false
+	}
+	return matches != negate
+}
+
+// matchIdentity checks whether the context identifier refers to the rule's identity
+func matchIdentity(
+	identityKey string,
+	ectx model.Context,
+) bool {
+	return identityKey != "" && ectx.Identifier == identityKey
+}
+
+// matchSegment an identity belongs to a segment when it satisfies every segment rule
+func matchSegment(
+	segmentRules []*model.Rule,
+	ectx model.Context,
+	rt *model.RuleTrace,
+) bool {
+	if len(segmentRules) == 0 {
+		return false
+	}
+
+	for idx, sr := range segmentRules {
+		var srt *model.RuleTrace
+		if rt != nil {
+			srt = newRuleTrace(idx, *sr)
+			rt.SegmentRules = append(rt.SegmentRules, srt)
+		}
+
+		matches := matchTrait(*sr, ectx, srt)
+		if srt != nil {
+			srt.Matched = matches
+		}
+		if !matches {
+			return false
+		}
+	}
+
+	return true
+}
@@ -2,6 +2,7 @@ package evaluator
 
 import (
 	"core/pkg/model"
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -15,11 +16,11 @@ func TestEvaluate(t *testing.T) {
 				TraitKey:       "age",
 				Operator:       model.OPGreaterThan,
 				TraitValue:     "18",
-				RuleVariations: []*model.Variation{{VariationKey: "A", Weight: 100}},
+				RuleVariations: []*model.Variation{{VariationKey: "A", Weight: 100000}},
 			},
 		},
 		UseFallthrough:        false,
-		FallthroughVariations: []*model.Variation{{VariationKey: "B", Weight: 100}},
+		FallthroughVariations: []*model.Variation{{VariationKey: "B", Weight: 100000}},
 	}
 
 	ectx := model.Context{
@@ -35,13 +36,124 @@ func TestEvaluate(t *testing.T) {
 	assert.Equal(t, model.ReasonTargeted, evaluation.Reason)
 }
 
+func TestEvaluateValue(t *testing.T) {
+	var flag model.Flag
+	err := json.Unmarshal([]byte(`{
+		"flagKey": "checkout_limit",
+		"valueType": "number",
+		"fallthroughVariations": [
+			{"variationKey": "control", "weight": 100000, "value": 10},
+			{"variationKey": "treatment", "weight": 0, "value": 25.5}
+		],
+		"rules": [
+			{
+				"ruleType": "trait",
+				"traitKey": "plan",
+				"traitValue": "enterprise",
+				"operator": "equal",
+				"ruleVariations": [
+					{"variationKey": "control", "weight": 0, "value": 10},
+					{"variationKey": "treatment", "weight": 100000, "value": 25.5}
+				]
+			}
+		]
+	}`), &flag)
+	assert.NoError(t, err)
+
+	targeted := Evaluate(flag, "some_salt", model.Context{Traits: map[string]interface{}{"plan": "enterprise"}})
+	assert.Equal(t, "treatment", targeted.VariationKey)
+	assert.Equal(t, model.Value(`25.5`), targeted.Value)
+
+	untargeted := Evaluate(flag, "some_salt", model.Context{})
+	assert.Equal(t, "control", untargeted.VariationKey)
+	assert.Equal(t, model.Value(`10`), untargeted.Value)
+
+	b, err := json.Marshal(untargeted)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"flagKey":"checkout_limit","variationKey":"control","reason":"FALLTHROUGH_WEIGHTED","value":10}`, string(b))
+}
+
+func TestEvaluateWithoutValue(t *testing.T) {
+	flag := model.Flag{
+		FlagKey:               "test_flag",
+		FallthroughVariations: []*model.Variation{{VariationKey: "B", Weight: 100000}},
+	}
+
+	evaluation := Evaluate(flag, "some_salt", model.Context{})
+
+	assert.Equal(t, "B", evaluation.VariationKey)
+	assert.Nil(t, evaluation.Value)
+
+	b, err := json.Marshal(evaluation)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), "value")
+}
+
+func TestEvaluateOff(t *testing.T) {
+	newFlag := func() model.Flag {
+		return model.Flag{
+			FlagKey: "test_flag",
+			Rules: []*model.Rule{
+				{
+					TraitKey:       "beta",
+					Operator:       model.OPEqual,
+					TraitValue:     "true",
+					RuleVariations: []*model.Variation{{VariationKey: "treatment", Weight: 100000}},
+				},
+			},
+			OffVariationKey: "control",
+			FallthroughVariations: []*model.Variation{
+				{VariationKey: "control", Weight: 0, Value: model.Value(`false`)},
+				{VariationKey: "treatment", Weight: 100000, Value: model.Value(`true`)},
+			},
+		}
+	}
+	beta := model.Context{Traits: map[string]interface{}{"beta": true}}
+
+	disabled := newFlag()
+	disabled.UseFallthrough = true
+	killed := newFlag()
+	killed.Killed = true
+	killedWithoutOff := newFlag()
+	killedWithoutOff.Killed = true
+	killedWithoutOff.OffVariationKey = ""
+	disabledWithoutOff := newFlag()
+	disabledWithoutOff.UseFallthrough = true
+	disabledWithoutOff.OffVariationKey = ""
+
+	tests := []struct {
+		name      string
+		flag      model.Flag
+		reason    model.Reason
+		variation string
+	}{
+		{"Enabled", newFlag(), model.ReasonTargeted, "treatment"},
+		{"Disabled", disabled, model.ReasonOff, "control"},
+		{"Killed", killed, model.ReasonOff, "control"},
+		// off flags never bucket identities, they serve control without an off variation
+		{"KilledWithoutOffVariation", killedWithoutOff, model.ReasonOff, "control"},
+		{"DisabledWithoutOffVariation", disabledWithoutOff, model.ReasonOff, "control"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluation := Evaluate(tt.flag, "some_salt", beta)
+			assert.Equal(t, tt.reason, evaluation.Reason)
+			assert.Equal(t, tt.variation, evaluation.VariationKey)
+		})
+	}
+
+	evaluation := Evaluate(killed, "some_salt", beta)
+	assert.Equal(t, model.Value(`false`), evaluation.Value)
+}
+
 func TestEvaluateRules(t *testing.T) {
 	rules := []*model.Rule{
 		{
 			TraitKey:       "age",
 			Operator:       model.OPGreaterThan,
 			TraitValue:     "18",
-			RuleVariations: []*model.Variation{{VariationKey: "A", Weight: 100}},
+			RuleVariations: []*model.Variation{{VariationKey: "A", Weight: 100000}},
 		},
 	}
 
@@ -52,7 +164,7 @@ func TestEvaluateRules(t *testing.T) {
 		},
 	}
 
-	evaluation, _ := evaluateRules(rules, salt, ectx)
+	evaluation, _ := evaluateRules(rules, salt, ectx, nil)
 
 	assert.Equal(t, "A", evaluation.VariationKey)
 	assert.Equal(t, model.ReasonTargeted, evaluation.Reason)
@@ -63,7 +175,7 @@ func TestEvaluateRule(t *testing.T) {
 		TraitKey:       "age",
 		Operator:       model.OPGreaterThan,
 		TraitValue:     "18",
-		RuleVariations: []*model.Variation{{VariationKey: "A", Weight: 100}},
+		RuleVariations: []*model.Variation{{VariationKey: "A", Weight: 100000}},
 	}
 
 	salt := "some_salt"
@@ -73,8 +185,195 @@ func TestEvaluateRule(t *testing.T) {
 		},
 	}
 
-	evaluation, _ := evaluateRule(rule, salt, ectx)
+	evaluation, _ := evaluateRule(rule, salt, ectx, nil)
 
 	assert.Equal(t, "A", evaluation.VariationKey)
 	assert.Equal(t, model.ReasonTargeted, evaluation.Reason)
 }
+
+func TestEvaluateRuleSegment(t *testing.T) {
+	rule := model.Rule{
+		RuleType:   model.RuleTypeSegment,
+		SegmentKey: "beta-customers",
+		SegmentRules: []*model.Rule{
+			{
+				TraitKey:   "plan",
+				Operator:   model.OPEqual,
+				TraitValue: "enterprise",
+			},
+			{
+				TraitKey:   "age",
+				Operator:   model.OPGreaterThanOrEqual,
+				TraitValue: "18",
+			},
+		},
+		RuleVariations: []*model.Variation{{VariationKey: "A", Weight: 100000}},
+	}
+
+	tests := []struct {
+		name     string
+		traits   map[string]interface{}
+		negate   bool
+		expected bool
+	}{
+		{"AllSegmentRulesMatch", map[string]interface{}{"plan": "enterprise", "age": float64(30)}, false, true},
+		{"OneSegmentRuleFails", map[string]interface{}{"plan": "free", "age": float64(30)}, false, false},
+		{"MissingTrait", map[string]interface{}{"plan": "enterprise"}, false, false},
+		{"NegatedNonMember", map[string]interface{}{"plan": "free", "age": float64(30)}, true, true},
+		{"NegatedMember", map[string]interface{}{"plan": "enterprise", "age": float64(30)}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := rule
+			r.Negate = tt.negate
+			evaluation, matched := evaluateRule(r, "some_salt", model.Context{Traits: tt.traits}, nil)
+
+			assert.Equal(t, tt.expected, matched)
+			if tt.expected {
+				assert.Equal(t, "A", evaluation.VariationKey)
+				assert.Equal(t, model.ReasonTargeted, evaluation.Reason)
+			}
+		})
+	}
+}
+
+func TestEvaluateRuleEmptySegment(t *testing.T) {
+	rule := model.Rule{
+		RuleType:       model.RuleTypeSegment,
+		SegmentKey:     "empty",
+		RuleVariations: []*model.Variation{{VariationKey: "A", Weight: 100000}},
+	}
+
+	_, matched := evaluateRule(rule, "some_salt", model.Context{
+		Traits: map[string]interface{}{"plan": "enterprise"},
+	}, nil)
+
+	assert.False(t, matched)
+}
+
+func TestEvaluateRuleIdentity(t *testing.T) {
+	rule := model.Rule{
+		RuleType:       model.RuleTypeIdentity,
+		IdentityKey:    "internal-tester",
+		RuleVariations: []*model.Variation{{VariationKey: "A", Weight: 100000}},
+	}
+
+	tests := []struct {
+		name       string
+		identifier string
+		negate     bool
+		expected   bool
+	}{
+		{"MatchingIdentifier", "internal-tester", false, true},
+		{"OtherIdentifier", "someone-else", false, false},
+		{"NegatedOtherIdentifier", "someone-else", true, true},
+		{"NegatedMatchingIdentifier", "internal-tester", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := rule
+			r.Negate = tt.negate
+			evaluation, matched := evaluateRule(r, "some_salt", model.Context{Identifier: tt.identifier}, nil)
+
+			assert.Equal(t, tt.expected, matched)
+			if tt.expected {
+				assert.Equal(t, "A", evaluation.VariationKey)
+			}
+		})
+	}
+}
+
+func TestEvaluateRuleClauses(t *testing.T) {
+	clauses := []*model.Clause{
+		{TraitKey: "country", Operator: model.OPEqual, TraitValue: "AU"},
+		{TraitKey: "plan", Operator: model.OPEqual, TraitValue: "enterprise"},
+	}
+
+	tests := []struct {
+		name     string
+		match    model.MatchType
+		clauses  []*model.Clause
+		traits   map[string]interface{}
+		expected bool
+	}{
+		{"AllMatch", model.MatchAll, clauses, map[string]interface{}{"country": "AU", "plan": "enterprise"}, true},
+		{"AllOneFails", model.MatchAll, clauses, map[string]interface{}{"country": "AU", "plan": "free"}, false},
+		{"DefaultsToAll", "", clauses, map[string]interface{}{"country": "AU", "plan": "free"}, false},
+		{"AnyOneMatches", model.MatchAny, clauses, map[string]interface{}{"country": "NZ", "plan": "enterprise"}, true},
+		{"AnyNoneMatch", model.MatchAny, clauses, map[string]interface{}{"country": "NZ", "plan": "free"}, false},
+		{"AnyMissingTraits", model.MatchAny, clauses, map[string]interface{}{}, false},
+		{
+			"NegatedClause",
+			model.MatchAll,
+			[]*model.Clause{
+				{TraitKey: "country", Operator: model.OPEqual, TraitValue: "AU"},
+				{TraitKey: "plan", Operator: model.OPEqual, TraitValue: "free", Negate: true},
+			},
+			map[string]interface{}{"country": "AU", "plan": "enterprise"},
+			true,
+		},
+		{
+			"BoolClause",
+			model.MatchAll,
+			[]*model.Clause{
+				{TraitKey: "isEmployee", Operator: model.OPEqual, TraitValue: "true"},
+				{TraitKey: "age", Operator: model.OPLessThan, TraitValue: "30"},
+			},
+			map[string]interface{}{"isEmployee": true, "age": float64(29)},
+			true,
+		},
+		{
+			"ListClause",
+			model.MatchAll,
+			[]*model.Clause{
+				{TraitKey: "country", Operator: model.OPIn, TraitValues: model.NewValueSet("AU", "NZ")},
+				{TraitKey: "plan", Operator: model.OPNotIn, TraitValues: model.NewValueSet("free", "trial")},
+			},
+			map[string]interface{}{"country": "NZ", "plan": "enterprise"},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := model.Rule{
+				RuleType:       model.RuleTypeTrait,
+				Match:          tt.match,
+				Clauses:        tt.clauses,
+				RuleVariations: []*model.Variation{{VariationKey: "A", Weight: 100000}},
+			}
+
+			_, matched := evaluateRule(rule, "some_salt", model.Context{Traits: tt.traits}, nil)
+
+			assert.Equal(t, tt.expected, matched)
+		})
+	}
+}
+
+func TestEvaluateRuleSegmentClauses(t *testing.T) {
+	rule := model.Rule{
+		RuleType: model.RuleTypeSegment,
+		SegmentRules: []*model.Rule{
+			{
+				Match: model.MatchAny,
+				Clauses: []*model.Clause{
+					{TraitKey: "country", Operator: model.OPEqual, TraitValue: "AU"},
+					{TraitKey: "country", Operator: model.OPEqual, TraitValue: "NZ"},
+				},
+			},
+		},
+		RuleVariations: []*model.Variation{{VariationKey: "A", Weight: 100000}},
+	}
+
+	_, matched := evaluateRule(rule, "some_salt", model.Context{
+		Traits: map[string]interface{}{"country": "NZ"},
+	}, nil)
+	assert.True(t, matched)
+
+	_, matched = evaluateRule(rule, "some_salt", model.Context{
+		Traits: map[string]interface{}{"country": "US"},
+	}, nil)
+	assert.False(t, matched)
+}
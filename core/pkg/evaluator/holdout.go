@@ -0,0 +1,38 @@
+package evaluator
+
+import (
+	"core/pkg/hashutil"
+	"core/pkg/model"
+)
+
+// holdoutSaltPrefix keeps global holdout buckets independent of flag & layer buckets
+const holdoutSaltPrefix = "holdout"
+
+// evaluateHoldout checks if the identity is part of the environment's global holdout.
+// The holdout bucket only depends on the flag's bucketing value (see bucketValue), so
+// held out identities never enter any of the environment's experiments.
+func evaluateHoldout(
+	flag model.Flag,
+	ectx model.Context,
+	trace *model.Trace,
+) bool {
+	bucket := deriveHoldoutBucket(bucketValue(flag, ectx))
+	if trace != nil {
+		trace.Holdout = &model.HoldoutTrace{
+			Bucket:  bucket,
+			Holdout: flag.Holdout,
+		}
+	}
+	return inHoldout(flag.Holdout, bucket)
+}
+
+// deriveHoldoutBucket derives the identity's bucket within the global holdout given the
+// flag's bucketing value, which is the same for every flag bucketing by the same trait
+func deriveHoldoutBucket(value string) uint32 {
+	return deriveBucket(hashutil.HashLengthPrefixed(holdoutSaltPrefix, value))
+}
+
+// inHoldout checks if the bucket falls into the first holdout buckets
+func inHoldout(holdout int32, bucket uint32) bool {
+	return int64(bucket) < int64(holdout)
+}
@@ -0,0 +1,72 @@
+package evaluator
+
+import (
+	"core/pkg/hashutil"
+	"core/pkg/model"
+)
+
+// layerSaltPrefix keeps layer buckets independent of flag buckets
+// (e.g. a flag without a seed using the same key as its layer)
+const layerSaltPrefix = "layer"
+
+// evaluateLayer checks if the identity is part of the flag's experiment. Identities in
+// the layer's holdout or another flag's range of the layer are excluded, in which case
+// the reason the control variation is served is returned.
+func evaluateLayer(
+	flag model.Flag,
+	ectx model.Context,
+	trace *model.Trace,
+) (model.Reason, bool) {
+	layer := *flag.Layer
+	bucket := deriveLayerBucket(layer, bucketValue(flag, ectx))
+	reason, excluded := layerReason(layer, bucket)
+	if trace != nil {
+		trace.Layer = &model.LayerTrace{
+			LayerKey: layer.LayerKey,
+			Bucket:   bucket,
+			Holdout:  layer.Holdout,
+			Start:    layer.Start,
+			End:      layer.End,
+			Reason:   reason,
+		}
+	}
+	return reason, excluded
+}
+
+// deriveLayerBucket derives the identity's bucket within the layer given the flag's bucketing
+// value (see bucketValue), which is the same for every flag of the layer bucketing by the same
+// trait. Keys are prefixed by their length, so the layer key can't run into the value.
+func deriveLayerBucket(layer model.Layer, value string) uint32 {
+	return deriveBucket(hashutil.HashLengthPrefixed(layerSaltPrefix, layer.LayerKey, value))
+}
+
+// layerReason checks which part of the layer's bucket space the bucket falls into,
+// the holdout takes precedence over the flag's range
+func layerReason(layer model.Layer, bucket uint32) (model.Reason, bool) {
+	switch b := int64(bucket); {
+	case b < int64(layer.Holdout):
+		return model.ReasonHoldout, true
+	case b < int64(layer.Start) || b >= int64(layer.End):
+		return model.ReasonLayerExcluded, true
+	default:
+		return "", false
+	}
+}
+
+// controlVariation the variation served to identities excluded from the experiment,
+// i.e. the off variation or the first fallthrough variation if there is none
+func controlVariation(flag model.Flag) string {
+	if flag.OffVariationKey != "" {
+		return flag.OffVariationKey
+	}
+	if len(flag.FallthroughVariations) > 0 {
+		return flag.FallthroughVariations[0].VariationKey
+	}
+	return ""
+}
+
+// isWeighted checks if the variation was bucketed (i.e. derived from weighted variations),
+// only bucketed variations are part of an experiment
+func isWeighted(reason model.Reason) bool {
+	return reason == model.ReasonFallthroughWeighted || reason == model.ReasonTargetedWeighted
+}
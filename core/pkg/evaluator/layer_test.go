@@ -0,0 +1,259 @@
+package evaluator
+
+import (
+	"core/pkg/model"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLayerReason(t *testing.T) {
+	layer := model.Layer{LayerKey: "some-layer", Holdout: 10000, Start: 40000, End: 60000}
+
+	tests := []struct {
+		name     string
+		bucket   uint32
+		reason   model.Reason
+		excluded bool
+	}{
+		{"Holdout", 0, model.ReasonHoldout, true},
+		{"HoldoutEnd", 9999, model.ReasonHoldout, true},
+		{"BeforeRange", 10000, model.ReasonLayerExcluded, true},
+		{"RangeStart", 40000, "", false},
+		{"RangeEnd", 59999, "", false},
+		{"AfterRange", 60000, model.ReasonLayerExcluded, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, excluded := layerReason(layer, tt.bucket)
+			assert.Equal(t, tt.reason, reason)
+			assert.Equal(t, tt.excluded, excluded)
+		})
+	}
+}
+
+func TestLayerMutuallyExclusive(t *testing.T) {
+	flags := []model.Flag{
+		{
+			FlagKey:         "experiment-a",
+			OffVariationKey: "control",
+			Layer:           &model.Layer{LayerKey: "checkout-page", Holdout: 10000, Start: 10000, End: 40000},
+			FallthroughVariations: []*model.Variation{
+				{VariationKey: "control", Weight: 50000},
+				{VariationKey: "treatment", Weight: 50000},
+			},
+		},
+		{
+			FlagKey:         "experiment-b",
+			OffVariationKey: "control",
+			Layer:           &model.Layer{LayerKey: "checkout-page", Holdout: 10000, Start: 40000, End: 70000},
+			FallthroughVariations: []*model.Variation{
+				{VariationKey: "control", Weight: 50000},
+				{VariationKey: "treatment", Weight: 50000},
+			},
+		},
+		{
+			FlagKey:         "experiment-c",
+			OffVariationKey: "control",
+			Layer:           &model.Layer{LayerKey: "checkout-page", Holdout: 10000, Start: 70000, End: 100000},
+			FallthroughVariations: []*model.Variation{
+				{VariationKey: "control", Weight: 50000},
+				{VariationKey: "treatment", Weight: 50000},
+			},
+		},
+	}
+
+	counts := make(map[model.Reason]int)
+	for i := 0; i < 10000; i++ {
+		ectx := model.Context{Identifier: fmt.Sprintf("identity-%d", i)}
+
+		experiments := 0
+		for _, flag := range flags {
+			eval := Evaluate(flag, DeriveSalt(flag, ectx), ectx)
+			counts[eval.Reason]++
+			switch eval.Reason {
+			case model.ReasonFallthroughWeighted:
+				experiments++
+			case model.ReasonHoldout:
+				assert.Equal(t, "control", eval.VariationKey)
+			case model.ReasonLayerExcluded:
+				assert.Equal(t, "control", eval.VariationKey)
+			}
+		}
+		assert.LessOrEqual(t, experiments, 1, ectx.Identifier)
+	}
+
+	// ~10% of identities are held out of every experiment
+	assert.InDelta(t, 3000, counts[model.ReasonHoldout], 300)
+	// ~30% of identities are part of each experiment
+	assert.InDelta(t, 9000, counts[model.ReasonFallthroughWeighted], 600)
+}
+
+func TestLayerOnlyAffectsBucketing(t *testing.T) {
+	flag := model.Flag{
+		FlagKey:         "experiment-a",
+		OffVariationKey: "control",
+		Layer:           &model.Layer{LayerKey: "checkout-page", Holdout: 10000, Start: 10000, End: 10001},
+		FallthroughVariations: []*model.Variation{
+			{VariationKey: "control", Weight: 50000},
+			{VariationKey: "treatment", Weight: 50000},
+		},
+		Rules: []*model.Rule{
+			{
+				RuleType:       model.RuleTypeIdentity,
+				IdentityKey:    "qa-user",
+				RuleVariations: []*model.Variation{{VariationKey: "treatment", Weight: 100000}},
+			},
+		},
+	}
+	ectx := model.Context{Identifier: "qa-user"}
+
+	eval := Evaluate(flag, DeriveSalt(flag, ectx), ectx)
+
+	assert.Equal(t, model.ReasonTargeted, eval.Reason)
+	assert.Equal(t, "treatment", eval.VariationKey)
+}
+
+func TestLayerControlVariation(t *testing.T) {
+	flag := model.Flag{
+		FlagKey: "experiment-a",
+		Layer:   &model.Layer{LayerKey: "checkout-page", Holdout: 10000, Start: 10000, End: 10001},
+		FallthroughVariations: []*model.Variation{
+			{VariationKey: "baseline", Weight: 50000},
+			{VariationKey: "treatment", Weight: 50000},
+		},
+	}
+
+	assert.Equal(t, "baseline", controlVariation(flag))
+}
+
+func TestLayerTrace(t *testing.T) {
+	flag := model.Flag{
+		FlagKey:         "experiment-a",
+		OffVariationKey: "control",
+		Layer:           &model.Layer{LayerKey: "checkout-page", Holdout: 10000, Start: 10000, End: 100000},
+		FallthroughVariations: []*model.Variation{
+			{VariationKey: "control", Weight: 50000},
+			{VariationKey: "treatment", Weight: 50000},
+		},
+	}
+	ectx := model.Context{Identifier: "identity-1"}
+
+	eval := Explain(flag, DeriveSalt(flag, ectx), ectx)
+
+	assert.NotNil(t, eval.Trace.Layer)
+	assert.Equal(t, "checkout-page", eval.Trace.Layer.LayerKey)
+	assert.Equal(t, deriveLayerBucket(*flag.Layer, ectx.Identifier), eval.Trace.Layer.Bucket)
+	assert.Equal(t, eval.Reason == model.ReasonHoldout, eval.Trace.Layer.Bucket < 10000)
+}
+
+func TestLayerBucketBy(t *testing.T) {
+	flag := model.Flag{
+		FlagKey:         "experiment-a",
+		OffVariationKey: "control",
+		BucketBy:        "company",
+		Layer:           &model.Layer{LayerKey: "checkout-page", Holdout: 10000, Start: 10000, End: 100000},
+		FallthroughVariations: []*model.Variation{
+			{VariationKey: "control", Weight: 50000},
+			{VariationKey: "treatment", Weight: 50000},
+		},
+	}
+	ectx := model.Context{
+		Identifier: "identity-1",
+		Traits:     map[string]interface{}{"company": "flagbase"},
+	}
+
+	// contexts are bucketed into the layer by the same value as the flag's variations
+	eval := Explain(flag, DeriveSalt(flag, ectx), ectx)
+	assert.Equal(t, deriveLayerBucket(*flag.Layer, "flagbase"), eval.Trace.Layer.Bucket)
+
+	for i := 0; i < 100; i++ {
+		other := model.Context{
+			Identifier: fmt.Sprintf("identity-%d", i),
+			Traits:     ectx.Traits,
+		}
+		assert.Equal(t, eval.Reason, Explain(flag, DeriveSalt(flag, other), other).Reason)
+	}
+}
+
+func TestLayerSaltSeparator(t *testing.T) {
+	// the layer key can't run into the bucketing value
+	a := deriveLayerBucket(model.Layer{LayerKey: "checkout"}, "-page1")
+	b := deriveLayerBucket(model.Layer{LayerKey: "checkout-page"}, "1")
+	assert.NotEqual(t, a, b)
+}
+
+func TestGlobalHoldout(t *testing.T) {
+	flags := []model.Flag{
+		{
+			FlagKey:         "experiment-a",
+			OffVariationKey: "control",
+			Holdout:         20000,
+			FallthroughVariations: []*model.Variation{
+				{VariationKey: "control", Weight: 50000},
+				{VariationKey: "treatment", Weight: 50000},
+			},
+		},
+		{
+			FlagKey: "experiment-b",
+			Holdout: 20000,
+			Layer:   &model.Layer{LayerKey: "checkout-page", Start: 0, End: 100000},
+			Rules: []*model.Rule{
+				{
+					RuleType:    model.RuleTypeIdentity,
+					IdentityKey: "identity-7",
+					RuleVariations: []*model.Variation{
+						{VariationKey: "baseline", Weight: 50000},
+						{VariationKey: "treatment", Weight: 50000},
+					},
+				},
+			},
+			FallthroughVariations: []*model.Variation{
+				{VariationKey: "baseline", Weight: 50000},
+				{VariationKey: "treatment", Weight: 50000},
+			},
+		},
+		{
+			FlagKey: "released",
+			Holdout: 20000,
+			FallthroughVariations: []*model.Variation{
+				{VariationKey: "on", Weight: 100000},
+			},
+		},
+	}
+
+	held := 0
+	for i := 0; i < 10000; i++ {
+		ectx := model.Context{Identifier: fmt.Sprintf("identity-%d", i)}
+		inHoldout := inHoldout(20000, deriveHoldoutBucket(ectx.Identifier))
+		if inHoldout {
+			held++
+		}
+
+		// held out identities are served control by every experiment of the environment
+		a := Evaluate(flags[0], DeriveSalt(flags[0], ectx), ectx)
+		assert.Equal(t, inHoldout, a.Reason == model.ReasonHoldout, ectx.Identifier)
+		b := Evaluate(flags[1], DeriveSalt(flags[1], ectx), ectx)
+		assert.Equal(t, inHoldout, b.Reason == model.ReasonHoldout, ectx.Identifier)
+		if inHoldout {
+			assert.Equal(t, "control", a.VariationKey)
+			assert.Equal(t, "baseline", b.VariationKey)
+		}
+
+		// variations which aren't bucketed aren't part of an experiment
+		c := Evaluate(flags[2], DeriveSalt(flags[2], ectx), ectx)
+		assert.Equal(t, model.ReasonFallthrough, c.Reason)
+	}
+
+	// ~20% of identities are held out
+	assert.InDelta(t, 2000, held, 200)
+
+	ectx := model.Context{Identifier: "identity-1"}
+	eval := Explain(flags[0], DeriveSalt(flags[0], ectx), ectx)
+	assert.Equal(t, &model.HoldoutTrace{
+		Bucket:  deriveHoldoutBucket("identity-1"),
+		Holdout: 20000,
+	}, eval.Trace.Holdout)
+}
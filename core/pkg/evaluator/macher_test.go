@@ -2,6 +2,8 @@ package evaluator
 
 import (
 	"core/pkg/model"
+	"encoding/json"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -31,3 +33,136 @@ func TestMatcher(t *testing.T) {
 		})
 	}
 }
+
+func TestMatcherOperators(t *testing.T) {
+	tests := []struct {
+		name     string
+		operator model.Operator
+		input    interface{}
+		rule     string
+		expected bool
+	}{
+		{"NotEqual", model.OPNotEqual, "apple", "pear", true},
+		{"NotEqualSame", model.OPNotEqual, "apple", "apple", false},
+		{"EqualIgnoreCase", model.OPEqualIgnoreCase, "Apple", "aPPLE", true},
+		{"EqualIgnoreCaseDifferent", model.OPEqualIgnoreCase, "Apple", "pear", false},
+		{"EqualIsCaseSensitive", model.OPEqual, "Apple", "apple", false},
+		{"StartsWith", model.OPStartsWith, "pineapple", "pine", true},
+		{"StartsWithNoMatch", model.OPStartsWith, "pineapple", "apple", false},
+		{"EndsWith", model.OPEndsWith, "pineapple", "apple", true},
+		{"EndsWithNoMatch", model.OPEndsWith, "pineapple", "pine", false},
+		{"LessThan", model.OPLessThan, float64(3), "5", true},
+		{"LessThanEqualValue", model.OPLessThan, float64(5), "5", false},
+		{"LessThanOrEqual", model.OPLessThanOrEqual, float64(5), "5", true},
+		{"LessThanOrEqualGreater", model.OPLessThanOrEqual, float64(6), "5", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Matcher[tt.operator](tt.input, tt.rule)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestMatcherCoercion(t *testing.T) {
+	tests := []struct {
+		name     string
+		operator model.Operator
+		input    interface{}
+		rule     string
+		expected bool
+	}{
+		// booleans match "true" / "false" (case-insensitive)
+		{"BoolEqual", model.OPEqual, true, "true", true},
+		{"BoolEqualFalse", model.OPEqual, false, "false", true},
+		{"BoolEqualUpperCase", model.OPEqual, true, "TRUE", true},
+		{"BoolEqualMismatch", model.OPEqual, true, "false", false},
+		{"BoolEqualInvalidRule", model.OPEqual, true, "yes", false},
+		{"BoolNotEqual", model.OPNotEqual, false, "true", true},
+		{"BoolNotEqualInvalidRule", model.OPNotEqual, true, "yes", false},
+		{"BoolEqualIgnoreCase", model.OPEqualIgnoreCase, true, "True", true},
+		{"BoolGreaterThan", model.OPGreaterThan, true, "0", false},
+		{"BoolContains", model.OPContains, true, "true", false},
+		// numbers are compared numerically for equality
+		{"NumberEqual", model.OPEqual, float64(42), "42", true},
+		{"NumberEqualDecimal", model.OPEqual, float64(42), "42.0", true},
+		{"NumberEqualInt", model.OPEqual, 42, "42", true},
+		{"NumberEqualInvalidRule", model.OPEqual, float64(42), "forty-two", false},
+		{"NumberNotEqual", model.OPNotEqual, float64(42), "43", true},
+		{"NumberNotEqualInvalidRule", model.OPNotEqual, float64(42), "forty-two", false},
+		{"NumberStartsWith", model.OPStartsWith, float64(42), "4", false},
+		// numeric strings are parsed for numeric comparisons
+		{"NumericStringGreaterThan", model.OPGreaterThan, "10", "9", true},
+		{"NumericStringLessThan", model.OPLessThan, " -1.5 ", "0", true},
+		{"NumericStringLessThanOrEqual", model.OPLessThanOrEqual, "2", "2.0", true},
+		{"NonNumericStringLessThan", model.OPLessThan, "abc", "5", false},
+		{"NumericStringEqualIsExact", model.OPEqual, "42.0", "42", false},
+		// traits which can't be coerced never match
+		{"NilNotEqual", model.OPNotEqual, nil, "apple", false},
+		{"ListNotEqual", model.OPNotEqual, []interface{}{"apple"}, "apple", false},
+		{"MapEqual", model.OPEqual, map[string]interface{}{}, "apple", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Matcher[tt.operator](tt.input, tt.rule)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestListMatcher(t *testing.T) {
+	countries := model.NewValueSet("AU", "NZ", "US")
+	ids := model.NewValueSet("1", "2.5", "true")
+
+	tests := []struct {
+		name     string
+		operator model.Operator
+		input    interface{}
+		rule     model.ValueSet
+		expected bool
+	}{
+		{"InString", model.OPIn, "NZ", countries, true},
+		{"InMissingValue", model.OPIn, "GB", countries, false},
+		{"InNumber", model.OPIn, float64(2.5), ids, true},
+		{"InWholeNumber", model.OPIn, float64(1), ids, true},
+		{"InBool", model.OPIn, true, ids, true},
+		{"InInvalidType", model.OPIn, []interface{}{"AU"}, countries, false},
+		{"InEmptyList", model.OPIn, "AU", model.ValueSet{}, false},
+		{"NotInString", model.OPNotIn, "GB", countries, true},
+		{"NotInPresentValue", model.OPNotIn, "AU", countries, false},
+		{"NotInInvalidType", model.OPNotIn, nil, countries, false},
+		{"NotInEmptyList", model.OPNotIn, "AU", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ListMatcher[tt.operator](tt.input, tt.rule)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestListMatcherLargeList(t *testing.T) {
+	values := make([]string, 100000)
+	for i := range values {
+		values[i] = fmt.Sprintf("user-%d", i)
+	}
+	set := model.NewValueSet(values...)
+
+	assert.True(t, ListMatcher[model.OPIn]("user-99999", set))
+	assert.False(t, ListMatcher[model.OPIn]("user-100000", set))
+}
+
+func TestValueSetJSON(t *testing.T) {
+	var clause model.Clause
+	err := json.Unmarshal([]byte(`{"traitKey":"country","operator":"in","traitValues":["NZ","AU","NZ"]}`), &clause)
+	assert.NoError(t, err)
+	assert.Len(t, clause.TraitValues, 2)
+	assert.True(t, clause.TraitValues.Contains("AU"))
+
+	b, err := json.Marshal(clause.TraitValues)
+	assert.NoError(t, err)
+	assert.Equal(t, `["AU","NZ"]`, string(b))
+}
@@ -2,107 +2,412 @@ package evaluator
 
 import (
 	"core/pkg/model"
+	"errors"
+	"fmt"
 	"regexp"
+	"regexp/syntax"
 	"strconv"
 	"strings"
-	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // EvalMapper map containing comparators for all valid operands
 type EvalMapper map[model.Operator](func(input interface{}, rule string) bool)
 
-// Matcher instance of EvalMapper, used to select the appropriate comparator given the operand
-var Matcher = EvalMapper{
-	(model.OPEqual): func(i interface{}, r string) bool {
-		iS, ok := i.(string)
-		if !ok {
-			return false
+// Matcher instance of EvalMapper, used to select the appropriate comparator given the operand.
+// Traits are coerced into the rule value's type as described in coerce.go.
+var Matcher = newEvalMapper(
+	model.OPEqual,
+	model.OPNotEqual,
+	model.OPEqualIgnoreCase,
+	model.OPContains,
+	model.OPStartsWith,
+	model.OPEndsWith,
+	model.OPGreaterThan,
+	model.OPGreaterThanOrEqual,
+	model.OPLessThan,
+	model.OPLessThanOrEqual,
+	model.OPRegex,
+	model.OPSemverEqual,
+	model.OPSemverGreaterThan,
+	model.OPSemverGreaterThanOrEqual,
+	model.OPSemverLessThan,
+	model.OPSemverLessThanOrEqual,
+	model.OPBefore,
+	model.OPAfter,
+)
+
+// ListEvalMapper map containing comparators for operands that match against a list of values
+type ListEvalMapper map[model.Operator](func(input interface{}, rule model.ValueSet) bool)
+
+// ListMatcher instance of ListEvalMapper, used to select the appropriate list comparator given the operand.
+// Rule values are kept in a set, so membership checks are constant-time regardless of the list size.
+var ListMatcher = newListEvalMapper(
+	model.OPIn,
+	model.OPNotIn,
+)
+
+func newEvalMapper(operators ...model.Operator) EvalMapper {
+	m := make(EvalMapper, len(operators))
+	for _, op := range operators {
+		op := op
+		m[op] = func(i interface{}, r string) bool {
+			matches, _ := matchOperand(op, i, &operand{value: r})
+			return matches
 		}
-		return iS == r
-	},
-	(model.OPContains): func(i interface{}, r string) bool {
-		iS, ok := i.(string)
-		if !ok {
-			return false
+	}
+	return m
+}
+
+func newListEvalMapper(operators ...model.Operator) ListEvalMapper {
+	m := make(ListEvalMapper, len(operators))
+	for _, op := range operators {
+		op := op
+		m[op] = func(i interface{}, r model.ValueSet) bool {
+			matches, _ := matchOperand(op, i, &operand{values: r})
+			return matches
 		}
-		return strings.Contains(iS, r)
-	},
-	(model.OPGreaterThan): func(i interface{}, r string) bool {
-		iV, ok := i.(float64)
-		if !ok {
-			return false
+	}
+	return m
+}
+
+// operand the rule value a trait is compared against. Rule values are either parsed
+// on every comparison (backed by the caches below) or parsed upfront by parseOperand
+// (i.e. compiled flagsets), both are compared the same way (see matchOperand).
+type operand struct {
+	value  string
+	values model.ValueSet
+
+	// parsed the rule value was parsed upfront into the fields below,
+	// the ok fields are false if it can't be parsed into that type
+	parsed bool
+	num    float64
+	numOK  bool
+	boolV  bool
+	boolOK bool
+	re     *regexp.Regexp
+	sem    semver
+	semOK  bool
+	// relative rule times (e.g. now-30d) are resolved on every comparison
+	relative bool
+	offset   time.Duration
+	at       time.Time
+	atOK     bool
+}
+
+// parseOperand parses the rule value upfront into the type the operator compares it as
+func parseOperand(op model.Operator, value string, values model.ValueSet) operand {
+	r := operand{value: value, values: values, parsed: true}
+
+	switch op {
+	case model.OPEqual, model.OPNotEqual, model.OPEqualIgnoreCase,
+		model.OPGreaterThan, model.OPGreaterThanOrEqual, model.OPLessThan, model.OPLessThanOrEqual:
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			r.num, r.numOK = v, true
 		}
-		rV, err := parseFloatCached(r)
-		if err != nil {
-			return false
+		r.boolV, r.boolOK = parseBoolRule(value)
+	case model.OPRegex:
+		r.re, _ = compileRegexCached(value)
+	case model.OPSemverEqual, model.OPSemverGreaterThan, model.OPSemverGreaterThanOrEqual,
+		model.OPSemverLessThan, model.OPSemverLessThanOrEqual:
+		if v, err := parseSemver(value); err == nil {
+			r.sem, r.semOK = v, true
 		}
-		return iV > rV
-	},
-	(model.OPGreaterThanOrEqual): func(i interface{}, r string) bool {
-		iV, ok := i.(float64)
-		if !ok {
-			return false
+	case model.OPBefore, model.OPAfter:
+		if strings.HasPrefix(value, "now") {
+			offset, err := parseRelativeOffset(value[len("now"):])
+			r.relative, r.offset = err == nil, offset
+			break
 		}
-		rV, err := parseFloatCached(r)
-		if err != nil {
-			return false
+		if t, err := parseTimeRule(value); err == nil {
+			r.at, r.atOK = t, true
 		}
-		return iV >= rV
-	},
-	(model.OPRegex): func(i interface{}, r string) bool {
+	}
+
+	return r
+}
+
+func (r *operand) toFloat() (float64, bool) {
+	if r.parsed {
+		return r.num, r.numOK
+	}
+	v, err := parseFloatCached(r.value)
+	return v, err == nil
+}
+
+func (r *operand) toBool() (bool, bool) {
+	if r.parsed {
+		return r.boolV, r.boolOK
+	}
+	return parseBoolRule(r.value)
+}
+
+func (r *operand) toRegex() *regexp.Regexp {
+	if r.parsed {
+		return r.re
+	}
+	re, _ := compileRegexCached(r.value)
+	return re
+}
+
+func (r *operand) toSemver() (semver, bool) {
+	if r.parsed {
+		return r.sem, r.semOK
+	}
+	v, err := parseSemverCached(r.value)
+	return v, err == nil
+}
+
+func (r *operand) toTime() (time.Time, bool) {
+	if !r.parsed {
+		t, err := parseTimeRule(r.value)
+		return t, err == nil
+	}
+	if r.relative {
+		return now().Add(r.offset), true
+	}
+	return r.at, r.atOK
+}
+
+// matchOperand compares the trait against the operand using the operator,
+// ok is false if the operator is unknown
+func matchOperand(op model.Operator, i interface{}, r *operand) (matches bool, ok bool) {
+	switch op {
+	case model.OPEqual:
+		eq, ok := equalTrait(i, r, false)
+		return ok && eq, true
+	case model.OPNotEqual:
+		eq, ok := equalTrait(i, r, false)
+		return ok && !eq, true
+	case model.OPEqualIgnoreCase:
+		eq, ok := equalTrait(i, r, true)
+		return ok && eq, true
+	case model.OPContains:
+		iS, ok := i.(string)
+		return ok && strings.Contains(iS, r.value), true
+	case model.OPStartsWith:
+		iS, ok := i.(string)
+		return ok && strings.HasPrefix(iS, r.value), true
+	case model.OPEndsWith:
+		iS, ok := i.(string)
+		return ok && strings.HasSuffix(iS, r.value), true
+	case model.OPGreaterThan:
+		c, ok := compareFloat(i, r)
+		return ok && c > 0, true
+	case model.OPGreaterThanOrEqual:
+		c, ok := compareFloat(i, r)
+		return ok && c >= 0, true
+	case model.OPLessThan:
+		c, ok := compareFloat(i, r)
+		return ok && c < 0, true
+	case model.OPLessThanOrEqual:
+		c, ok := compareFloat(i, r)
+		return ok && c <= 0, true
+	case model.OPRegex:
 		iS, ok := i.(string)
 		if !ok {
-			return false
+			return false, true
 		}
-		matched, err := matchStringCached(r, iS)
-		if err != nil {
-			return false
-		}
-		return matched
-	},
+		re := r.toRegex()
+		return re != nil && re.MatchString(iS), true
+	case model.OPSemverEqual:
+		c, ok := compareSemver(i, r)
+		return ok && c == 0, true
+	case model.OPSemverGreaterThan:
+		c, ok := compareSemver(i, r)
+		return ok && c > 0, true
+	case model.OPSemverGreaterThanOrEqual:
+		c, ok := compareSemver(i, r)
+		return ok && c >= 0, true
+	case model.OPSemverLessThan:
+		c, ok := compareSemver(i, r)
+		return ok && c < 0, true
+	case model.OPSemverLessThanOrEqual:
+		c, ok := compareSemver(i, r)
+		return ok && c <= 0, true
+	case model.OPBefore:
+		c, ok := compareTime(i, r)
+		return ok && c < 0, true
+	case model.OPAfter:
+		c, ok := compareTime(i, r)
+		return ok && c > 0, true
+	case model.OPIn:
+		found, ok := containsTrait(r.values, i)
+		return ok && found, true
+	case model.OPNotIn:
+		found, ok := containsTrait(r.values, i)
+		return ok && !found, true
+	default:
+		return false, false
+	}
+}
+
+// compareSemver compares the input version against the rule version,
+// ok is false if either of them is not a valid semantic version
+func compareSemver(i interface{}, r *operand) (c int, ok bool) {
+	iS, ok := i.(string)
+	if !ok {
+		return 0, false
+	}
+	iV, err := parseSemver(iS)
+	if err != nil {
+		return 0, false
+	}
+	rV, ok := r.toSemver()
+	if !ok {
+		return 0, false
+	}
+	return iV.compare(rV), true
+}
+
+// CacheLimits limits of the caches used to parse rule values (i.e. numbers,
+// semantic versions & regular expressions). Rule values are parsed once and
+// kept until they are evicted, while patterns exceeding the limits never match.
+type CacheLimits struct {
+	// Size max number of values kept per cache
+	Size int
+	// MaxPatternLength max length of a regular expression
+	MaxPatternLength int
+	// MaxPatternComplexity max number of instructions of a compiled regular expression
+	MaxPatternComplexity int
+}
+
+// DefaultCacheLimits limits used unless they are overridden (see SetCacheLimits)
+var DefaultCacheLimits = CacheLimits{
+	Size:                 10000,
+	MaxPatternLength:     1024,
+	MaxPatternComplexity: 10000,
 }
 
 var (
-	floatCache   = make(map[string]float64)
-	floatCacheMu sync.Mutex
+	errPatternTooLong    = errors.New("pattern exceeds the max pattern length")
+	errPatternTooComplex = errors.New("pattern exceeds the max pattern complexity")
 )
 
+type floatResult struct {
+	v   float64
+	err error
+}
+
+type regexResult struct {
+	re  *regexp.Regexp
+	err error
+}
+
+type semverResult struct {
+	v   semver
+	err error
+}
+
+type matcherCaches struct {
+	limits  CacheLimits
+	floats  *lruCache[floatResult]
+	regexes *lruCache[regexResult]
+	semvers *lruCache[semverResult]
+}
+
+// caches swapped atomically, so lookups never wait for the limits to be changed
+var caches atomic.Pointer[matcherCaches]
+
+func init() {
+	SetCacheLimits(DefaultCacheLimits)
+}
+
+// SetCacheLimits replaces the matcher caches, discarding every cached value.
+// Limits that aren't set (i.e. <= 0) fall back to DefaultCacheLimits.
+func SetCacheLimits(limits CacheLimits) {
+	if limits.Size <= 0 {
+		limits.Size = DefaultCacheLimits.Size
+	}
+	if limits.MaxPatternLength <= 0 {
+		limits.MaxPatternLength = DefaultCacheLimits.MaxPatternLength
+	}
+	if limits.MaxPatternComplexity <= 0 {
+		limits.MaxPatternComplexity = DefaultCacheLimits.MaxPatternComplexity
+	}
+
+	caches.Store(&matcherCaches{
+		limits:  limits,
+		floats:  newLRUCache[floatResult](limits.Size),
+		regexes: newLRUCache[regexResult](limits.Size),
+		semvers: newLRUCache[semverResult](limits.Size),
+	})
+}
+
+// MatcherCacheStats returns the stats of every matcher cache (i.e. float, regex & semver)
+func MatcherCacheStats() map[string]CacheStats {
+	c := caches.Load()
+	return map[string]CacheStats{
+		"float":  c.floats.stats(),
+		"regex":  c.regexes.stats(),
+		"semver": c.semvers.stats(),
+	}
+}
+
 func parseFloatCached(s string) (float64, error) {
-	floatCacheMu.Lock()
-	defer floatCacheMu.Unlock()
+	r := caches.Load().floats.get(s, func(s string) floatResult {
+		v, err := strconv.ParseFloat(s, 64)
+		return floatResult{v, err}
+	})
+	return r.v, r.err
+}
+
+// compileRegexCached compiles the pattern, rejecting patterns which exceed the limits
+func compileRegexCached(pattern string) (*regexp.Regexp, error) {
+	c := caches.Load()
+	r := c.regexes.get(pattern, func(pattern string) regexResult {
+		re, err := compileRegex(pattern, c.limits)
+		return regexResult{re, err}
+	})
+	return r.re, r.err
+}
 
-	v, ok := floatCache[s]
-	if ok {
-		return v, nil
+func compileRegex(pattern string, limits CacheLimits) (*regexp.Regexp, error) {
+	if len(pattern) > limits.MaxPatternLength {
+		return nil, errPatternTooLong
 	}
 
-	v, err := strconv.ParseFloat(s, 64)
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	prog, err := syntax.Compile(parsed.Simplify())
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	if len(prog.Inst) > limits.MaxPatternComplexity {
+		return nil, errPatternTooComplex
 	}
 
-	floatCache[s] = v
-	return v, nil
+	return regexp.Compile(pattern)
 }
 
-var (
-	regexCache   = make(map[string]*regexp.Regexp)
-	regexCacheMu sync.Mutex
-)
-
-func matchStringCached(pattern, s string) (bool, error) {
-	regexCacheMu.Lock()
-	defer regexCacheMu.Unlock()
+// ValidatePattern checks the regular expression compiles within the current pattern
+// limits (see SetCacheLimits), so rules are rejected when they're written rather
+// than never matching once they're evaluated
+func ValidatePattern(pattern string) error {
+	_, err := compileRegex(pattern, caches.Load().limits)
+	return err
+}
 
-	re, ok := regexCache[pattern]
-	if !ok {
-		var err error
-		re, err = regexp.Compile(pattern)
-		if err != nil {
-			return false, err
+// ValidateClauses checks the pattern of every regex clause (see ValidatePattern)
+func ValidateClauses(clauses ...*model.Clause) error {
+	for _, c := range clauses {
+		if c == nil || c.Operator != model.OPRegex {
+			continue
+		}
+		if err := ValidatePattern(c.TraitValue); err != nil {
+			return fmt.Errorf("trait %s: %w", c.TraitKey, err)
 		}
-		regexCache[pattern] = re
 	}
+	return nil
+}
 
-	return re.MatchString(s), nil
+func parseSemverCached(s string) (semver, error) {
+	r := caches.Load().semvers.get(s, func(s string) semverResult {
+		v, err := parseSemver(s)
+		return semverResult{v, err}
+	})
+	return r.v, r.err
 }
@@ -0,0 +1,228 @@
+package evaluator
+
+import (
+	"core/pkg/model"
+)
+
+// EvaluateWithPrerequisites evaluates a flag which may depend on other flags. The
+// evaluations of the flag's prerequisites are looked up in evaluated, so flags have
+// to be evaluated in dependency order (see DependencyLevels). The control variation
+// (see controlVariation) is served when a prerequisite is missing, off or doesn't
+// serve one of the required variations.
+func EvaluateWithPrerequisites(
+	flag model.Flag,
+	salt string,
+	ectx model.Context,
+	evaluated map[string]*model.Evaluation,
+) *model.Evaluation {
+	return evaluateWithPrerequisites(flag, salt, ectx, evaluated, nil)
+}
+
+// ExplainWithPrerequisites evaluates a flag the same way as EvaluateWithPrerequisites,
+// while tracing how the variation was derived (incl. the prerequisites checked)
+func ExplainWithPrerequisites(
+	flag model.Flag,
+	salt string,
+	ectx model.Context,
+	evaluated map[string]*model.Evaluation,
+) *model.Evaluation {
+	trace := newTrace()
+	o := evaluateWithPrerequisites(flag, salt, ectx, evaluated, trace)
+	o.Trace = trace
+	return o
+}
+
+func evaluateWithPrerequisites(
+	flag model.Flag,
+	salt string,
+	ectx model.Context,
+	evaluated map[string]*model.Evaluation,
+	trace *model.Trace,
+) *model.Evaluation {
+	if prerequisitesMet(flag.Prerequisites, evaluated, trace) {
+		return evaluate(flag, salt, ectx, trace)
+	}
+
+	o := &model.Evaluation{
+		FlagKey:      flag.FlagKey,
+		Reason:       model.ReasonPrerequisiteFailed,
+		VariationKey: controlVariation(flag),
+		Off:          isOff(flag),
+	}
+	if trace != nil {
+		trace.Off = o.Off
+	}
+	o.Value = variationValue(flag, o.VariationKey)
+	return o
+}
+
+// prerequisitesMet checks if every prerequisite flag served one of its required
+// variations, prerequisites which are off or failed themselves are never met
+func prerequisitesMet(
+	prerequisites []*model.Prerequisite,
+	evaluated map[string]*model.Evaluation,
+	trace *model.Trace,
+) bool {
+	for _, p := range prerequisites {
+		met := prerequisiteMet(p, evaluated[p.FlagKey])
+		if trace != nil {
+			pt := &model.PrerequisiteTrace{
+				FlagKey:       p.FlagKey,
+				VariationKeys: p.VariationKeys,
+				Met:           met,
+			}
+			if eval := evaluated[p.FlagKey]; eval != nil {
+				pt.VariationKey = eval.VariationKey
+				pt.Reason = eval.Reason
+			}
+			trace.Prerequisites = append(trace.Prerequisites, pt)
+		}
+		if !met {
+			return false
+		}
+	}
+	return true
+}
+
+// prerequisiteMet checks if the prerequisite flag's evaluation served one of the required
+// variations, the flag's off state is checked rather than the reason (i.e. disabled flags
+// without an off variation serve the fallthrough variation, but are still off)
+func prerequisiteMet(
+	p *model.Prerequisite,
+	eval *model.Evaluation,
+) bool {
+	if eval == nil ||
+		eval.Off ||
+		eval.Reason == model.ReasonPrerequisiteFailed {
+		return false
+	}
+
+	for _, v := range p.VariationKeys {
+		if eval.VariationKey == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Subset returns the flags with the given keys along with their (transitive) prerequisites,
+// so the subset evaluates the same way as the whole flagset. Flags keep their order within
+// the flagset and keys which aren't part of the flagset are ignored.
+func Subset(flags []*model.Flag, flagKeys []string) []*model.Flag {
+	byKey := make(map[string]*model.Flag, len(flags))
+	for _, f := range flags {
+		byKey[f.FlagKey] = f
+	}
+
+	included := make(map[string]bool, len(flagKeys))
+	pending := append([]string{}, flagKeys...)
+	for len(pending) > 0 {
+		key := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		f, ok := byKey[key]
+		if !ok || included[key] {
+			continue
+		}
+		included[key] = true
+		for _, p := range f.Prerequisites {
+			pending = append(pending, p.FlagKey)
+		}
+	}
+
+	o := make([]*model.Flag, 0, len(included))
+	for _, f := range flags {
+		if included[f.FlagKey] {
+			o = append(o, f)
+		}
+	}
+	return o
+}
+
+// DependencyLevels groups flags so that every flag's prerequisites are part of an
+// earlier level, flags within the same level can be evaluated concurrently.
+// Prerequisites outside of the flagset are ignored, while flags that are part of a
+// cycle are placed in the last level (their prerequisites will never be met).
+func DependencyLevels(flags []*model.Flag) [][]*model.Flag {
+	inFlagset := make(map[string]bool, len(flags))
+	for _, f := range flags {
+		inFlagset[f.FlagKey] = true
+	}
+
+	var levels [][]*model.Flag
+	placed := make(map[string]bool, len(flags))
+	remaining := flags
+
+	for len(remaining) > 0 {
+		var level, next []*model.Flag
+		for _, f := range remaining {
+			ready := true
+			for _, p := range f.Prerequisites {
+				if inFlagset[p.FlagKey] && !placed[p.FlagKey] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				level = append(level, f)
+			} else {
+				next = append(next, f)
+			}
+		}
+
+		if len(level) == 0 {
+			// the remaining flags depend on each other
+			return append(levels, next)
+		}
+
+		for _, f := range level {
+			placed[f.FlagKey] = true
+		}
+		levels = append(levels, level)
+		remaining = next
+	}
+
+	return levels
+}
+
+// DetectCycle returns the path of a prerequisite cycle reachable from the flag
+// (e.g. [a b c a]), or nil if the flag's dependencies are acyclic. deps maps
+// each flag key to the keys of its prerequisite flags.
+func DetectCycle(deps map[string][]string, flagKey string) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var path []string
+
+	var visit func(key string) []string
+	visit = func(key string) []string {
+		switch state[key] {
+		case visiting:
+			// the cycle starts where the key was first visited
+			for i, k := range path {
+				if k == key {
+					cycle := append([]string{}, path[i:]...)
+					return append(cycle, key)
+				}
+			}
+		case visited:
+			return nil
+		}
+
+		state[key] = visiting
+		path = append(path, key)
+		for _, dep := range deps[key] {
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[key] = visited
+		return nil
+	}
+
+	return visit(flagKey)
+}
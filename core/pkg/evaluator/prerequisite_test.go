@@ -0,0 +1,270 @@
+package evaluator
+
+import (
+	"core/pkg/model"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateWithPrerequisites(t *testing.T) {
+	flag := model.Flag{
+		FlagKey:       "new-checkout",
+		Prerequisites: []*model.Prerequisite{{FlagKey: "payments-v2", VariationKeys: []string{"treatment"}}},
+		Rules: []*model.Rule{
+			{
+				RuleType:       model.RuleTypeTrait,
+				TraitKey:       "beta",
+				Operator:       model.OPEqual,
+				TraitValue:     "true",
+				RuleVariations: []*model.Variation{{VariationKey: "treatment", Weight: 100000}},
+			},
+		},
+		FallthroughVariations: []*model.Variation{{VariationKey: "control", Weight: 100000}},
+	}
+	beta := model.Context{Traits: map[string]interface{}{"beta": true}}
+
+	tests := []struct {
+		name      string
+		evaluated map[string]*model.Evaluation
+		reason    model.Reason
+		variation string
+	}{
+		{
+			"Met",
+			map[string]*model.Evaluation{"payments-v2": {VariationKey: "treatment", Reason: model.ReasonTargeted}},
+			model.ReasonTargeted,
+			"treatment",
+		},
+		{
+			"WrongVariation",
+			map[string]*model.Evaluation{"payments-v2": {VariationKey: "control", Reason: model.ReasonFallthrough}},
+			model.ReasonPrerequisiteFailed,
+			"control",
+		},
+		{
+			"PrerequisiteFailed",
+			map[string]*model.Evaluation{"payments-v2": {VariationKey: "treatment", Reason: model.ReasonPrerequisiteFailed}},
+			model.ReasonPrerequisiteFailed,
+			"control",
+		},
+		{
+			"PrerequisiteOff",
+			map[string]*model.Evaluation{"payments-v2": {VariationKey: "treatment", Reason: model.ReasonOff, Off: true}},
+			model.ReasonPrerequisiteFailed,
+			"control",
+		},
+		{
+			"PrerequisiteOffWithoutOffVariation",
+			map[string]*model.Evaluation{"payments-v2": {VariationKey: "treatment", Reason: model.ReasonFallthrough, Off: true}},
+			model.ReasonPrerequisiteFailed,
+			"control",
+		},
+		{
+			"Missing",
+			map[string]*model.Evaluation{},
+			model.ReasonPrerequisiteFailed,
+			"control",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eval := EvaluateWithPrerequisites(flag, "some_salt", beta, tt.evaluated)
+			assert.Equal(t, tt.reason, eval.Reason)
+			assert.Equal(t, tt.variation, eval.VariationKey)
+		})
+	}
+}
+
+func TestEvaluateWithPrerequisitesControl(t *testing.T) {
+	tests := []struct {
+		name      string
+		flag      model.Flag
+		variation string
+	}{
+		{
+			"OffVariation",
+			model.Flag{
+				FlagKey:         "new-checkout",
+				OffVariationKey: "off",
+				Prerequisites:   []*model.Prerequisite{{FlagKey: "payments-v2", VariationKeys: []string{"treatment"}}},
+				FallthroughVariations: []*model.Variation{
+					{VariationKey: "control", Weight: 100000},
+					{VariationKey: "off"},
+				},
+			},
+			"off",
+		},
+		{
+			// the weighted fallthrough is never served, even if every identity is bucketed into treatment
+			"WithoutOffVariation",
+			model.Flag{
+				FlagKey:       "new-checkout",
+				Prerequisites: []*model.Prerequisite{{FlagKey: "payments-v2", VariationKeys: []string{"treatment"}}},
+				FallthroughVariations: []*model.Variation{
+					{VariationKey: "control"},
+					{VariationKey: "treatment", Weight: 100000},
+				},
+			},
+			"control",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eval := EvaluateWithPrerequisites(tt.flag, "some_salt", model.Context{Identifier: "user-1"}, map[string]*model.Evaluation{})
+			assert.Equal(t, model.ReasonPrerequisiteFailed, eval.Reason)
+			assert.Equal(t, tt.variation, eval.VariationKey)
+
+			compiled := Compile([]*model.Flag{&tt.flag}).Evaluate(model.Context{Identifier: "user-1"})
+			assert.Equal(t, model.ReasonPrerequisiteFailed, compiled[0].Reason)
+			assert.Equal(t, tt.variation, compiled[0].VariationKey)
+		})
+	}
+}
+
+func TestEvaluateWithDisabledPrerequisite(t *testing.T) {
+	prerequisite := model.Flag{
+		FlagKey:               "payments-v2",
+		UseFallthrough:        true,
+		FallthroughVariations: []*model.Variation{{VariationKey: "treatment", Weight: 100000}},
+	}
+	flag := model.Flag{
+		FlagKey:               "new-checkout",
+		Prerequisites:         []*model.Prerequisite{{FlagKey: "payments-v2", VariationKeys: []string{"treatment"}}},
+		FallthroughVariations: []*model.Variation{{VariationKey: "control", Weight: 100000}},
+	}
+
+	evaluated := map[string]*model.Evaluation{}
+	evaluated["payments-v2"] = EvaluateWithPrerequisites(prerequisite, "some_salt", model.Context{}, evaluated)
+	assert.True(t, evaluated["payments-v2"].Off)
+
+	eval := EvaluateWithPrerequisites(flag, "some_salt", model.Context{}, evaluated)
+	assert.Equal(t, model.ReasonPrerequisiteFailed, eval.Reason)
+
+	compiled := Compile([]*model.Flag{&prerequisite, &flag}).Evaluate(model.Context{})
+	assert.Equal(t, model.ReasonPrerequisiteFailed, compiled[1].Reason)
+}
+
+func TestDependencyLevels(t *testing.T) {
+	a := &model.Flag{FlagKey: "a"}
+	b := &model.Flag{FlagKey: "b", Prerequisites: []*model.Prerequisite{{FlagKey: "a"}}}
+	c := &model.Flag{FlagKey: "c", Prerequisites: []*model.Prerequisite{{FlagKey: "a"}, {FlagKey: "b"}}}
+	d := &model.Flag{FlagKey: "d", Prerequisites: []*model.Prerequisite{{FlagKey: "unknown"}}}
+
+	levels := DependencyLevels([]*model.Flag{c, b, d, a})
+
+	assert.Equal(t, [][]*model.Flag{{d, a}, {b}, {c}}, levels)
+}
+
+func TestDependencyLevelsCycle(t *testing.T) {
+	a := &model.Flag{FlagKey: "a"}
+	b := &model.Flag{FlagKey: "b", Prerequisites: []*model.Prerequisite{{FlagKey: "c"}}}
+	c := &model.Flag{FlagKey: "c", Prerequisites: []*model.Prerequisite{{FlagKey: "b"}}}
+
+	levels := DependencyLevels([]*model.Flag{a, b, c})
+
+	assert.Equal(t, [][]*model.Flag{{a}, {b, c}}, levels)
+}
+
+func TestDependencyLevelsEvaluation(t *testing.T) {
+	treatment := []*model.Variation{{VariationKey: "treatment", Weight: 100000}}
+	flags := []*model.Flag{
+		{
+			FlagKey:               "c",
+			Prerequisites:         []*model.Prerequisite{{FlagKey: "b", VariationKeys: []string{"treatment"}}},
+			FallthroughVariations: treatment,
+		},
+		{
+			FlagKey:               "b",
+			Prerequisites:         []*model.Prerequisite{{FlagKey: "a", VariationKeys: []string{"treatment"}}},
+			FallthroughVariations: treatment,
+		},
+		{
+			FlagKey:               "a",
+			FallthroughVariations: treatment,
+		},
+	}
+
+	evaluated := make(map[string]*model.Evaluation)
+	for _, level := range DependencyLevels(flags) {
+		for _, f := range level {
+			evaluated[f.FlagKey] = EvaluateWithPrerequisites(*f, "some_salt", model.Context{}, evaluated)
+		}
+	}
+
+	for _, key := range []string{"a", "b", "c"} {
+		assert.Equal(t, model.ReasonFallthrough, evaluated[key].Reason, key)
+		assert.Equal(t, "treatment", evaluated[key].VariationKey, key)
+	}
+}
+
+func TestSubset(t *testing.T) {
+	a := &model.Flag{FlagKey: "a"}
+	b := &model.Flag{FlagKey: "b", Prerequisites: []*model.Prerequisite{{FlagKey: "a"}}}
+	c := &model.Flag{FlagKey: "c", Prerequisites: []*model.Prerequisite{{FlagKey: "b"}}}
+	d := &model.Flag{FlagKey: "d"}
+	e := &model.Flag{FlagKey: "e", Prerequisites: []*model.Prerequisite{{FlagKey: "f"}}}
+	f := &model.Flag{FlagKey: "f", Prerequisites: []*model.Prerequisite{{FlagKey: "e"}}}
+	flags := []*model.Flag{c, b, d, a, e, f}
+
+	tests := []struct {
+		name     string
+		flagKeys []string
+		expected []*model.Flag
+	}{
+		{"NoPrerequisites", []string{"d"}, []*model.Flag{d}},
+		{"Transitive", []string{"c"}, []*model.Flag{c, b, a}},
+		{"Overlapping", []string{"a", "d", "b"}, []*model.Flag{b, d, a}},
+		{"Cycle", []string{"e"}, []*model.Flag{e, f}},
+		{"Unknown", []string{"unknown"}, []*model.Flag{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Subset(flags, tt.flagKeys))
+		})
+	}
+}
+
+func TestSubsetEvaluation(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	flags := randomFlagset(rnd, 20)
+	subset := Subset(flags, []string{"flag-3", "flag-7"})
+
+	for i := 0; i < 100; i++ {
+		ectx := randomContext(rnd, flags)
+		all := make(map[string]*model.Evaluation)
+		for _, eval := range evaluateFlagset(flags, ectx) {
+			all[eval.FlagKey] = eval
+		}
+		for _, eval := range evaluateFlagset(subset, ectx) {
+			assert.Equal(t, all[eval.FlagKey], eval, ectx.Identifier)
+		}
+	}
+}
+
+func TestDetectCycle(t *testing.T) {
+	tests := []struct {
+		name     string
+		deps     map[string][]string
+		flagKey  string
+		expected []string
+	}{
+		{"NoPrerequisites", map[string][]string{}, "a", nil},
+		{"Chain", map[string][]string{"a": {"b"}, "b": {"c"}}, "a", nil},
+		{"Diamond", map[string][]string{"a": {"b", "c"}, "b": {"d"}, "c": {"d"}}, "a", nil},
+		{"SelfReference", map[string][]string{"a": {"a"}}, "a", []string{"a", "a"}},
+		{"Direct", map[string][]string{"a": {"b"}, "b": {"a"}}, "a", []string{"a", "b", "a"}},
+		{"Indirect", map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}}, "a", []string{"a", "b", "c", "a"}},
+		{"Downstream", map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"b"}}, "a", []string{"b", "c", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DetectCycle(tt.deps, tt.flagKey))
+		})
+	}
+}
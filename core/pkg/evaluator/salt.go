@@ -0,0 +1,35 @@
+package evaluator
+
+import (
+	"core/pkg/hashutil"
+	"core/pkg/model"
+)
+
+// DeriveSalt derives the salt used to bucket the context into a flag's variations
+// (see bucketValue). The flag's seed is mixed into the salt, each key being prefixed
+// by its length so rotating the seed can't line up with another bucketing value (e.g. seed
+// "a" & value "bc" vs. seed "ab" & value "c"). Flags without a seed keep the original salt
+// (i.e. HashKeys(flagKey, identifier)), so their identities aren't reshuffled.
+func DeriveSalt(
+	flag model.Flag,
+	ectx model.Context,
+) string {
+	value := bucketValue(flag, ectx)
+	if flag.Seed == "" {
+		return hashutil.HashKeys(flag.FlagKey, value)
+	}
+	return hashutil.HashLengthPrefixed(flag.FlagKey, flag.Seed, value)
+}
+
+// bucketValue the value the context is bucketed by, i.e. the flag's bucketBy
+// trait, falling back to the identifier if the trait is missing
+func bucketValue(flag model.Flag, ectx model.Context) string {
+	if flag.BucketBy != "" {
+		if trait, ok := ectx.Traits[flag.BucketBy]; ok {
+			if v, ok := traitToString(trait); ok && v != "" {
+				return v
+			}
+		}
+	}
+	return ectx.Identifier
+}
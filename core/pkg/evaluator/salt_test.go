@@ -0,0 +1,87 @@
+package evaluator
+
+import (
+	"core/pkg/hashutil"
+	"core/pkg/model"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveSalt(t *testing.T) {
+	ectx := model.Context{
+		Identifier: "user-1",
+		Traits: map[string]interface{}{
+			"companyId": "acme",
+			"seats":     float64(25),
+			"empty":     "",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		flag     model.Flag
+		expected string
+	}{
+		{"Identifier", model.Flag{FlagKey: "f"}, hashutil.HashKeys("f", "user-1")},
+		{"BucketByTrait", model.Flag{FlagKey: "f", BucketBy: "companyId"}, hashutil.HashKeys("f", "acme")},
+		{"BucketByNumericTrait", model.Flag{FlagKey: "f", BucketBy: "seats"}, hashutil.HashKeys("f", "25")},
+		{"BucketByMissingTrait", model.Flag{FlagKey: "f", BucketBy: "teamId"}, hashutil.HashKeys("f", "user-1")},
+		{"BucketByEmptyTrait", model.Flag{FlagKey: "f", BucketBy: "empty"}, hashutil.HashKeys("f", "user-1")},
+		{"Seed", model.Flag{FlagKey: "f", Seed: "2022-09"}, hashutil.HashLengthPrefixed("f", "2022-09", "user-1")},
+		{"SeedAndBucketBy", model.Flag{FlagKey: "f", Seed: "2022-09", BucketBy: "companyId"}, hashutil.HashLengthPrefixed("f", "2022-09", "acme")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DeriveSalt(tt.flag, ectx))
+		})
+	}
+}
+
+func TestDeriveSaltBucketsCompanyTogether(t *testing.T) {
+	flag := model.Flag{
+		FlagKey:  "b2b-rollout",
+		BucketBy: "companyId",
+		FallthroughVariations: []*model.Variation{
+			{VariationKey: "A", Weight: 50000},
+			{VariationKey: "B", Weight: 50000},
+		},
+	}
+
+	expected := ""
+	for _, id := range []string{"alice", "bob", "carol", "dave"} {
+		ectx := model.Context{Identifier: id, Traits: map[string]interface{}{"companyId": "acme"}}
+		eval := Evaluate(flag, DeriveSalt(flag, ectx), ectx)
+		if expected == "" {
+			expected = eval.VariationKey
+		}
+		assert.Equal(t, expected, eval.VariationKey, id)
+	}
+}
+
+func TestDeriveSaltSeedRotation(t *testing.T) {
+	flag := model.Flag{
+		FlagKey: "experiment",
+		FallthroughVariations: []*model.Variation{
+			{VariationKey: "A", Weight: 50000},
+			{VariationKey: "B", Weight: 50000},
+		},
+	}
+	rotated := flag
+	rotated.Seed = "reshuffle-1"
+
+	changed := 0
+	for i := 0; i < 1000; i++ {
+		ectx := model.Context{Identifier: "user-" + strconv.Itoa(i)}
+		before := Evaluate(flag, DeriveSalt(flag, ectx), ectx)
+		after := Evaluate(rotated, DeriveSalt(rotated, ectx), ectx)
+		if before.VariationKey != after.VariationKey {
+			changed++
+		}
+	}
+
+	// roughly half of the identities are reassigned after rotating the seed
+	assert.InDelta(t, 500, changed, 100)
+}
@@ -0,0 +1,178 @@
+package evaluator
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// semver semantic version, parsed according to the SemVer 2.0.0 spec (https://semver.org).
+// Pre-release identifiers are kept as a dot-separated string, so parsing doesn't allocate.
+type semver struct {
+	major      uint64
+	minor      uint64
+	patch      uint64
+	prerelease string
+}
+
+var errInvalidSemver = errors.New("invalid semantic version")
+
+// parseSemver parses a version string (i.e. MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD])
+func parseSemver(s string) (semver, error) {
+	var v semver
+
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		if !validIdentifiers(s[i+1:], false) {
+			return semver{}, errInvalidSemver
+		}
+		s = s[:i]
+	}
+
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		if !validIdentifiers(s[i+1:], true) {
+			return semver{}, errInvalidSemver
+		}
+		v.prerelease = s[i+1:]
+		s = s[:i]
+	}
+
+	parts := [3]uint64{}
+	for i := range parts {
+		c := s
+		if i < len(parts)-1 {
+			dot := strings.IndexByte(s, '.')
+			if dot < 0 {
+				return semver{}, errInvalidSemver
+			}
+			c, s = s[:dot], s[dot+1:]
+		}
+		if !isNumeric(c) || (len(c) > 1 && c[0] == '0') {
+			return semver{}, errInvalidSemver
+		}
+		n, err := strconv.ParseUint(c, 10, 64)
+		if err != nil {
+			return semver{}, errInvalidSemver
+		}
+		parts[i] = n
+	}
+	v.major, v.minor, v.patch = parts[0], parts[1], parts[2]
+
+	return v, nil
+}
+
+// validIdentifiers checks a dot-separated list of identifiers,
+// numeric pre-release identifiers can't have leading zeros
+func validIdentifiers(s string, prerelease bool) bool {
+	for {
+		id, rest, more := strings.Cut(s, ".")
+		if !isSemverIdentifier(id) {
+			return false
+		}
+		if prerelease && isNumeric(id) && len(id) > 1 && id[0] == '0' {
+			return false
+		}
+		if !more {
+			return true
+		}
+		s = rest
+	}
+}
+
+// compare returns -1, 0 or 1 depending on the precedence of v relative to o.
+// build metadata is ignored when determining precedence.
+func (v semver) compare(o semver) int {
+	if c := compareUint(v.major, o.major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.minor, o.minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.patch, o.patch); c != 0 {
+		return c
+	}
+
+	// a pre-release version has lower precedence than a normal version
+	switch {
+	case v.prerelease == "" && o.prerelease == "":
+		return 0
+	case v.prerelease == "":
+		return 1
+	case o.prerelease == "":
+		return -1
+	}
+
+	a, b := v.prerelease, o.prerelease
+	for {
+		aID, aRest, aMore := strings.Cut(a, ".")
+		bID, bRest, bMore := strings.Cut(b, ".")
+		if c := comparePrereleaseIdentifier(aID, bID); c != 0 {
+			return c
+		}
+
+		// a larger set of pre-release fields has a higher precedence
+		switch {
+		case !aMore && !bMore:
+			return 0
+		case !aMore:
+			return -1
+		case !bMore:
+			return 1
+		}
+		a, b = aRest, bRest
+	}
+}
+
+func comparePrereleaseIdentifier(a, b string) int {
+	aNumeric, bNumeric := isNumeric(a), isNumeric(b)
+	switch {
+	case aNumeric && bNumeric:
+		// compare numerically without overflowing on large identifiers
+		if c := compareUint(uint64(len(a)), uint64(len(b))); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	case aNumeric:
+		// numeric identifiers have lower precedence than alphanumeric ones
+		return -1
+	case bNumeric:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isSemverIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package evaluator
+
+import (
+	"core/pkg/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSemver(t *testing.T) {
+	tests := []struct {
+		input string
+		valid bool
+	}{
+		{"1.2.3", true},
+		{"0.0.0", true},
+		{"4.10.0", true},
+		{"1.0.0-alpha", true},
+		{"1.0.0-alpha.1", true},
+		{"1.0.0-0.3.7", true},
+		{"1.0.0-x-y-z.--", true},
+		{"1.0.0+20130313144700", true},
+		{"1.0.0-beta+exp.sha.5114f85", true},
+		{"1.2", false},
+		{"1.2.3.4", false},
+		{"v1.2.3", false},
+		{"01.2.3", false},
+		{"1.02.3", false},
+		{"1.2.3-", false},
+		{"1.2.3-01", false},
+		{"1.2.3-alpha..1", false},
+		{"1.2.3+", false},
+		{"1.2.3+build_1", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := parseSemver(tt.input)
+			assert.Equal(t, tt.valid, err == nil)
+		})
+	}
+}
+
+func TestSemverCompare(t *testing.T) {
+	// precedence example taken from the SemVer 2.0.0 spec (ascending order)
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"2.0.0",
+		"2.1.0",
+		"2.1.1",
+		"4.9.0",
+		"4.10.0",
+	}
+
+	for i := 0; i < len(ordered)-1; i++ {
+		a, err := parseSemver(ordered[i])
+		assert.NoError(t, err)
+		b, err := parseSemver(ordered[i+1])
+		assert.NoError(t, err)
+
+		assert.Equal(t, -1, a.compare(b), "%s < %s", ordered[i], ordered[i+1])
+		assert.Equal(t, 1, b.compare(a), "%s > %s", ordered[i+1], ordered[i])
+	}
+
+	a, _ := parseSemver("1.0.0+build.1")
+	b, _ := parseSemver("1.0.0+build.2")
+	assert.Equal(t, 0, a.compare(b), "build metadata is ignored")
+}
+
+func TestSemverMatcher(t *testing.T) {
+	tests := []struct {
+		name     string
+		operator model.Operator
+		input    interface{}
+		rule     string
+		expected bool
+	}{
+		{"Equal", model.OPSemverEqual, "4.10.0", "4.10.0", true},
+		{"EqualIgnoresBuild", model.OPSemverEqual, "4.10.0+1", "4.10.0", true},
+		{"GreaterThan", model.OPSemverGreaterThan, "4.10.0", "4.9.0", true},
+		{"GreaterThanPrerelease", model.OPSemverGreaterThan, "4.10.0-rc.1", "4.10.0", false},
+		{"GreaterThanOrEqual", model.OPSemverGreaterThanOrEqual, "4.10.0", "4.10.0", true},
+		{"LessThan", model.OPSemverLessThan, "4.10.0-beta", "4.10.0", true},
+		{"LessThanOrEqual", model.OPSemverLessThanOrEqual, "4.9.9", "4.10.0", true},
+		{"InvalidInput", model.OPSemverGreaterThan, "latest", "4.10.0", false},
+		{"InvalidType", model.OPSemverEqual, float64(4), "4.0.0", false},
+		{"InvalidRule", model.OPSemverLessThan, "4.10.0", "4.x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Matcher[tt.operator](tt.input, tt.rule)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
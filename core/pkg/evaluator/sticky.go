@@ -0,0 +1,47 @@
+package evaluator
+
+import (
+	"core/pkg/model"
+)
+
+// evaluateSticky serves the identity's recorded variation rather than the bucketed one, so
+// changing the weights of a sticky flag doesn't reshuffle identities. Only bucketed variations
+// (i.e. not excluded by the flag's layer) are sticky, recorded variations the flag no longer
+// serves are ignored.
+func evaluateSticky(
+	flag model.Flag,
+	ectx model.Context,
+	trace *model.Trace,
+) (string, bool) {
+	variationKey, ok := ectx.Assignments[flag.FlagKey]
+	if !ok || !hasVariation(flag, variationKey) {
+		return "", false
+	}
+	if trace != nil {
+		trace.Sticky = true
+	}
+	return variationKey, true
+}
+
+// hasVariation checks if the variation is listed under the fallthrough or any of the rules of a flag
+func hasVariation(flag model.Flag, variationKey string) bool {
+	for _, v := range flag.FallthroughVariations {
+		if v.VariationKey == variationKey {
+			return true
+		}
+	}
+	for _, r := range flag.Rules {
+		for _, v := range r.RuleVariations {
+			if v.VariationKey == variationKey {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Assignable checks if the evaluation should be recorded as the identity's assignment,
+// i.e. the flag is sticky and the variation was bucketed rather than recorded
+func Assignable(flag model.Flag, eval *model.Evaluation) bool {
+	return flag.Sticky && isWeighted(eval.Reason)
+}
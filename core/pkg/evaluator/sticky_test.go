@@ -0,0 +1,132 @@
+package evaluator
+
+import (
+	"core/pkg/model"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStickyAssignmentsSurviveWeightChanges(t *testing.T) {
+	before := model.Flag{
+		FlagKey: "sticky",
+		Sticky:  true,
+		FallthroughVariations: []*model.Variation{
+			{VariationKey: "control", Weight: 50000, Value: model.Value(`"a"`)},
+			{VariationKey: "treatment", Weight: 50000, Value: model.Value(`"b"`)},
+		},
+	}
+	// the control variation's weight is lowered after identities were assigned
+	after := model.Flag{
+		FlagKey: "sticky",
+		Sticky:  true,
+		FallthroughVariations: []*model.Variation{
+			{VariationKey: "control", Weight: 10000, Value: model.Value(`"a"`)},
+			{VariationKey: "treatment", Weight: 90000, Value: model.Value(`"b"`)},
+		},
+	}
+
+	reshuffled := 0
+	for i := 0; i < 1000; i++ {
+		ectx := model.Context{Identifier: fmt.Sprintf("identity-%d", i)}
+
+		first := Evaluate(before, DeriveSalt(before, ectx), ectx)
+		assert.Equal(t, model.ReasonFallthroughWeighted, first.Reason)
+		assert.True(t, Assignable(before, first))
+
+		if Evaluate(after, DeriveSalt(after, ectx), ectx).VariationKey != first.VariationKey {
+			reshuffled++
+		}
+
+		ectx.Assignments = map[string]string{before.FlagKey: first.VariationKey}
+		eval := Evaluate(after, DeriveSalt(after, ectx), ectx)
+		assert.Equal(t, model.ReasonSticky, eval.Reason)
+		assert.Equal(t, first.VariationKey, eval.VariationKey)
+		assert.Equal(t, first.Value, eval.Value)
+		assert.False(t, Assignable(after, eval))
+	}
+	// without assignments identities are reshuffled
+	assert.Greater(t, reshuffled, 0)
+}
+
+func TestStickyIgnored(t *testing.T) {
+	tests := []struct {
+		name        string
+		flag        func(flag *model.Flag)
+		identifier  string
+		assignments map[string]string
+		reason      model.Reason
+	}{
+		{"NoAssignment", func(*model.Flag) {}, "identity-1", nil, model.ReasonFallthroughWeighted},
+		{"DeletedVariation", func(*model.Flag) {}, "identity-1", map[string]string{"sticky": "deleted"}, model.ReasonFallthroughWeighted},
+		{"NotSticky", func(f *model.Flag) { f.Sticky = false }, "identity-1", map[string]string{"sticky": "control"}, model.ReasonFallthroughWeighted},
+		{"NotWeighted", func(*model.Flag) {}, "identity-beta", map[string]string{"sticky": "control"}, model.ReasonTargeted},
+		{"Off", func(f *model.Flag) { f.UseFallthrough = true }, "identity-1", map[string]string{"sticky": "control"}, model.ReasonOff},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flag := model.Flag{
+				FlagKey: "sticky",
+				Sticky:  true,
+				FallthroughVariations: []*model.Variation{
+					{VariationKey: "control", Weight: 50000, Value: model.Value(`"a"`)},
+					{VariationKey: "treatment", Weight: 50000, Value: model.Value(`"b"`)},
+				},
+				Rules: []*model.Rule{
+					{
+						RuleType:       model.RuleTypeIdentity,
+						IdentityKey:    "identity-beta",
+						RuleVariations: []*model.Variation{{VariationKey: "beta", Weight: 100000}},
+					},
+				},
+			}
+			tt.flag(&flag)
+			ectx := model.Context{Identifier: tt.identifier, Assignments: tt.assignments}
+			eval := Evaluate(flag, DeriveSalt(flag, ectx), ectx)
+			assert.Equal(t, tt.reason, eval.Reason)
+		})
+	}
+}
+
+func TestStickyLayerExcluded(t *testing.T) {
+	flag := model.Flag{
+		FlagKey: "sticky",
+		Sticky:  true,
+		FallthroughVariations: []*model.Variation{
+			{VariationKey: "control", Weight: 50000, Value: model.Value(`"a"`)},
+			{VariationKey: "treatment", Weight: 50000, Value: model.Value(`"b"`)},
+		},
+		Layer: &model.Layer{LayerKey: "checkout-page", Holdout: 100000, Start: 0, End: 100000},
+	}
+
+	ectx := model.Context{
+		Identifier:  "identity-1",
+		Assignments: map[string]string{"sticky": "treatment"},
+	}
+	eval := Evaluate(flag, DeriveSalt(flag, ectx), ectx)
+	assert.Equal(t, model.ReasonHoldout, eval.Reason)
+	assert.Equal(t, "control", eval.VariationKey)
+	assert.False(t, Assignable(flag, eval))
+}
+
+func TestStickyTrace(t *testing.T) {
+	flag := model.Flag{
+		FlagKey: "sticky",
+		Sticky:  true,
+		FallthroughVariations: []*model.Variation{
+			{VariationKey: "control", Weight: 50000, Value: model.Value(`"a"`)},
+			{VariationKey: "treatment", Weight: 50000, Value: model.Value(`"b"`)},
+		},
+	}
+	ectx := model.Context{
+		Identifier:  "identity-1",
+		Assignments: map[string]string{"sticky": "treatment"},
+	}
+
+	eval := Explain(flag, DeriveSalt(flag, ectx), ectx)
+	assert.Equal(t, model.ReasonSticky, eval.Reason)
+	assert.True(t, eval.Trace.Sticky)
+	assert.NotNil(t, eval.Trace.Bucket)
+}
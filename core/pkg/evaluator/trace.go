@@ -0,0 +1,67 @@
+package evaluator
+
+import "core/pkg/model"
+
+func newTrace() *model.Trace {
+	return &model.Trace{
+		MatchedRuleIndex: -1,
+	}
+}
+
+func newRuleTrace(idx int, rule model.Rule) *model.RuleTrace {
+	ruleType := rule.RuleType
+	if ruleType == "" {
+		ruleType = model.RuleTypeTrait
+	}
+	return &model.RuleTrace{
+		Index:    idx,
+		ID:       rule.ID,
+		RuleType: ruleType,
+	}
+}
+
+func newClauseTrace(
+	clause model.Clause,
+	trait interface{},
+	found bool,
+	matched bool,
+) *model.ClauseTrace {
+	return &model.ClauseTrace{
+		TraitKey:    clause.TraitKey,
+		Operator:    clause.Operator,
+		TraitValue:  clause.TraitValue,
+		TraitValues: clause.TraitValues,
+		Negate:      clause.Negate,
+		Seen:        trait,
+		Found:       found,
+		Matched:     matched,
+	}
+}
+
+// traceWeights records the identity's bucket and the range of buckets
+// each variation is served to (see deriveVariationWithAccumulatedWeights)
+func traceWeights(
+	salt string,
+	variations []*model.Variation,
+	trace *model.Trace,
+) {
+	if len(variations) == 0 {
+		return
+	}
+
+	accumulatedWeights := precalculateAccumulatedWeights(variations)
+	bucket := deriveBucket(salt)
+	trace.Bucket = &bucket
+
+	trace.Weights = make([]*model.WeightRange, len(variations))
+	var start uint32
+	for i, v := range variations {
+		trace.Weights[i] = &model.WeightRange{
+			VariationKey: v.VariationKey,
+			Weight:       v.Weight,
+			Start:        start,
+			End:          accumulatedWeights[i],
+		}
+		start = accumulatedWeights[i]
+	}
+}
@@ -0,0 +1,138 @@
+package evaluator
+
+import (
+	"core/pkg/model"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	flag := model.Flag{
+		FlagKey: "checkout",
+		Rules: []*model.Rule{
+			{
+				ID:             "rule-1",
+				RuleType:       model.RuleTypeIdentity,
+				IdentityKey:    "some-identity",
+				RuleVariations: []*model.Variation{{VariationKey: "control", Weight: 100000}},
+			},
+			{
+				ID:       "rule-2",
+				RuleType: model.RuleTypeTrait,
+				Match:    model.MatchAny,
+				Clauses: []*model.Clause{
+					{TraitKey: "country", Operator: model.OPEqual, TraitValue: "NZ"},
+					{TraitKey: "plan", Operator: model.OPEqual, TraitValue: "enterprise"},
+				},
+				RuleVariations: []*model.Variation{
+					{VariationKey: "control", Weight: 25000},
+					{VariationKey: "treatment", Weight: 75000},
+				},
+			},
+		},
+		FallthroughVariations: []*model.Variation{
+			{VariationKey: "control", Weight: 50000},
+			{VariationKey: "treatment", Weight: 50000},
+		},
+	}
+
+	t.Run("Rule", func(t *testing.T) {
+		ectx := model.Context{
+			Identifier: "other-identity",
+			Traits:     map[string]interface{}{"country": "NZ"},
+		}
+
+		eval := Explain(flag, "some_salt", ectx)
+		trace := eval.Trace
+
+		assert.Equal(t, Evaluate(flag, "some_salt", ectx).VariationKey, eval.VariationKey)
+		assert.Equal(t, 1, trace.MatchedRuleIndex)
+		assert.Equal(t, "rule-2", trace.MatchedRuleID)
+		assert.False(t, trace.Off)
+
+		assert.Len(t, trace.Rules, 2)
+		assert.False(t, trace.Rules[0].Matched)
+		assert.Equal(t, model.RuleTypeIdentity, trace.Rules[0].RuleType)
+		assert.True(t, trace.Rules[1].Matched)
+
+		// every clause is traced, even after a match any rule matched
+		assert.Equal(t, []*model.ClauseTrace{
+			{TraitKey: "country", Operator: model.OPEqual, TraitValue: "NZ", Seen: "NZ", Found: true, Matched: true},
+			{TraitKey: "plan", Operator: model.OPEqual, TraitValue: "enterprise", Found: false, Matched: false},
+		}, trace.Rules[1].Clauses)
+
+		assert.Equal(t, []*model.WeightRange{
+			{VariationKey: "control", Weight: 25000, Start: 0, End: 25000},
+			{VariationKey: "treatment", Weight: 75000, Start: 25000, End: 100000},
+		}, trace.Weights)
+		assert.NotNil(t, trace.Bucket)
+		for _, w := range trace.Weights {
+			if *trace.Bucket >= w.Start && *trace.Bucket < w.End {
+				assert.Equal(t, w.VariationKey, eval.VariationKey)
+			}
+		}
+	})
+
+	t.Run("Fallthrough", func(t *testing.T) {
+		eval := Explain(flag, "some_salt", model.Context{Identifier: "other-identity"})
+
+		assert.Equal(t, model.ReasonFallthroughWeighted, eval.Reason)
+		assert.Equal(t, -1, eval.Trace.MatchedRuleIndex)
+		assert.Len(t, eval.Trace.Rules, 2)
+		assert.Len(t, eval.Trace.Weights, 2)
+		assert.Equal(t, uint32(50000), eval.Trace.Weights[1].Start)
+	})
+
+	t.Run("Off", func(t *testing.T) {
+		off := flag
+		off.UseFallthrough = true
+		off.OffVariationKey = "control"
+
+		eval := Explain(off, "some_salt", model.Context{})
+
+		assert.Equal(t, model.ReasonOff, eval.Reason)
+		assert.True(t, eval.Trace.Off)
+		assert.Empty(t, eval.Trace.Rules)
+		assert.Nil(t, eval.Trace.Bucket)
+	})
+
+	t.Run("Prerequisites", func(t *testing.T) {
+		dependent := flag
+		dependent.Prerequisites = []*model.Prerequisite{{FlagKey: "payments", VariationKeys: []string{"treatment"}}}
+		evaluated := map[string]*model.Evaluation{
+			"payments": {FlagKey: "payments", VariationKey: "control", Reason: model.ReasonFallthrough},
+		}
+
+		eval := ExplainWithPrerequisites(dependent, "some_salt", model.Context{}, evaluated)
+
+		assert.Equal(t, model.ReasonPrerequisiteFailed, eval.Reason)
+		assert.Equal(t, []*model.PrerequisiteTrace{
+			{
+				FlagKey:       "payments",
+				VariationKeys: []string{"treatment"},
+				VariationKey:  "control",
+				Reason:        model.ReasonFallthrough,
+				Met:           false,
+			},
+		}, eval.Trace.Prerequisites)
+	})
+
+	t.Run("MatchesEvaluate", func(t *testing.T) {
+		for i := 0; i < 1000; i++ {
+			salt := fmt.Sprintf("salt-%d", i)
+			ectx := model.Context{
+				Identifier: fmt.Sprintf("identity-%d", i),
+				Traits:     map[string]interface{}{"plan": []string{"free", "enterprise"}[i%2]},
+			}
+
+			eval := Evaluate(flag, salt, ectx)
+			explained := Explain(flag, salt, ectx)
+
+			assert.Nil(t, eval.Trace)
+			assert.Equal(t, eval.Reason, explained.Reason)
+			assert.Equal(t, eval.VariationKey, explained.VariationKey)
+		}
+	})
+}
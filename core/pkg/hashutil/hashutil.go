@@ -3,6 +3,7 @@ package hashutil
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"strconv"
 )
 
 // HashKeys generates a SHA256 hash given a list of strings
@@ -16,3 +17,19 @@ func HashKeys(keys ...string) string {
 	hashBytes := hasher.Sum(nil)
 	return hex.EncodeToString(hashBytes)
 }
+
+// HashLengthPrefixed generates a SHA256 hash given a list of strings, each string is prefixed
+// by its length (e.g. "1:a2:bc"), so keys can't run into each other (unlike HashKeys, which
+// hashes ("a", "bc") and ("ab", "c") the same way)
+func HashLengthPrefixed(keys ...string) string {
+	hasher := sha256.New()
+
+	for _, key := range keys {
+		hasher.Write([]byte(strconv.Itoa(len(key))))
+		hasher.Write([]byte{':'})
+		hasher.Write([]byte(key))
+	}
+
+	hashBytes := hasher.Sum(nil)
+	return hex.EncodeToString(hashBytes)
+}
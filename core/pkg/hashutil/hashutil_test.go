@@ -37,3 +37,15 @@ func TestHashKeysMultipleKeys(t *testing.T) {
 		t.Errorf("HashKeys(%s, %s, %s) = %s; expected %s", keys[0], keys[1], keys[2], hash, expected)
 	}
 }
+
+func TestHashLengthPrefixed(t *testing.T) {
+	hash := HashLengthPrefixed("a", "bc")
+
+	// Precomputed SHA256 hash for "1:a2:bc"
+	if expected := HashKeys("1:a2:bc"); hash != expected {
+		t.Errorf("HashLengthPrefixed(a, bc) = %s; expected %s", hash, expected)
+	}
+	if other := HashLengthPrefixed("ab", "c"); hash == other {
+		t.Errorf("HashLengthPrefixed(a, bc) = HashLengthPrefixed(ab, c) = %s", hash)
+	}
+}
@@ -0,0 +1,20 @@
+package model
+
+// MatchType determines how the clauses of a rule are combined
+type MatchType string
+
+const (
+	// MatchAll every clause has to match (i.e. AND)
+	MatchAll MatchType = "all"
+	// MatchAny at least one clause has to match (i.e. OR)
+	MatchAny MatchType = "any"
+)
+
+// Clause represents a single trait condition (i.e. <trait> <operator> <value>)
+type Clause struct {
+	TraitKey    string   `json:"traitKey"`
+	TraitValue  string   `json:"traitValue"`
+	TraitValues ValueSet `json:"traitValues,omitempty"`
+	Operator    Operator `json:"operator"`
+	Negate      bool     `json:"negate"`
+}
@@ -1,6 +1,8 @@
 package model
 
 const (
-	DefaultVariationOnWeight  int8 = 100
-	DefaultVariationOffWeight int8 = 0
+	// DefaultVariationOnWeight weight of a variation served to everyone (100%, in thousandths of a percent)
+	DefaultVariationOnWeight int32 = 100000
+	// DefaultVariationOffWeight weight of a variation served to no one
+	DefaultVariationOffWeight int32 = 0
 )
@@ -5,6 +5,9 @@ package model
 type Context struct {
 	Identifier string                 `json:"identifier"`
 	Traits     map[string]interface{} `json:"traits,omitempty"`
+	// Assignments variations recorded for the identity by sticky flags (keyed by flag key),
+	// these are loaded by the server rather than sent by the caller
+	Assignments map[string]string `json:"-"`
 }
 
 // Evaluations evaluated flagset
@@ -16,4 +19,32 @@ type Evaluation struct {
 	FlagKey      string `json:"flagKey" jsonapi:"attr,flagKey"`
 	VariationKey string `json:"variationKey" jsonapi:"attr,variationKey"`
 	Reason       Reason `json:"reason" jsonapi:"attr,reason"`
+	Value        Value  `json:"value,omitempty" jsonapi:"attr,value,omitempty"`
+	Trace        *Trace `json:"trace,omitempty" jsonapi:"attr,trace,omitempty"`
+	// Off the flag was off (i.e. killed or targeting disabled) when it was evaluated,
+	// flags depending on it never meet their prerequisite (whichever variation it served)
+	Off bool `json:"-"`
+}
+
+// BatchEvaluation evaluated flagset of a single context within a batch
+type BatchEvaluation struct {
+	Identifier  string      `json:"identifier" jsonapi:"primary,evaluated_flagset"`
+	Revision    int64       `json:"revision" jsonapi:"attr,revision"`
+	Evaluations Evaluations `json:"evaluations" jsonapi:"attr,evaluations"`
+}
+
+// Clone copies the batch evaluation along with its evaluations, i.e.
+// the copy doesn't change if the original's evaluations are reused
+func (b *BatchEvaluation) Clone() *BatchEvaluation {
+	evals := make([]Evaluation, len(b.Evaluations))
+	o := &BatchEvaluation{
+		Identifier:  b.Identifier,
+		Revision:    b.Revision,
+		Evaluations: make(Evaluations, len(b.Evaluations)),
+	}
+	for idx, eval := range b.Evaluations {
+		evals[idx] = *eval
+		o.Evaluations[idx] = &evals[idx]
+	}
+	return o
 }
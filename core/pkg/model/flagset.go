@@ -5,9 +5,19 @@ type Flagset []*Flag
 
 // Flag represents the state of a feature flag that has not been evaluation
 type Flag struct {
-	ID                    string       `json:"id,omitempty" jsonapi:"primary,raw_flag"`
-	FlagKey               string       `json:"flagKey" jsonapi:"attr,flagKey"`
-	UseFallthrough        bool         `json:"useFallthrough" jsonapi:"attr,useFallthrough"`
-	FallthroughVariations []*Variation `json:"fallthroughVariations" jsonapi:"attr,fallthroughVariations"`
-	Rules                 []*Rule      `json:"rules,omitempty" jsonapi:"attr,rules"`
+	ID                    string          `json:"id,omitempty" jsonapi:"primary,raw_flag"`
+	FlagKey               string          `json:"flagKey" jsonapi:"attr,flagKey"`
+	ValueType             ValueType       `json:"valueType,omitempty" jsonapi:"attr,valueType,omitempty"`
+	UseFallthrough        bool            `json:"useFallthrough" jsonapi:"attr,useFallthrough"`
+	Killed                bool            `json:"killed,omitempty" jsonapi:"attr,killed,omitempty"`
+	OffVariationKey       string          `json:"offVariationKey,omitempty" jsonapi:"attr,offVariationKey,omitempty"`
+	BucketBy              string          `json:"bucketBy,omitempty" jsonapi:"attr,bucketBy,omitempty"`
+	Seed                  string          `json:"seed,omitempty" jsonapi:"attr,seed,omitempty"`
+	Sticky                bool            `json:"sticky,omitempty" jsonapi:"attr,sticky,omitempty"`
+	FallthroughVariations []*Variation    `json:"fallthroughVariations" jsonapi:"attr,fallthroughVariations"`
+	Rules                 []*Rule         `json:"rules,omitempty" jsonapi:"attr,rules"`
+	Prerequisites         []*Prerequisite `json:"prerequisites,omitempty" jsonapi:"attr,prerequisites,omitempty"`
+	Layer                 *Layer          `json:"layer,omitempty" jsonapi:"attr,layer,omitempty"`
+	// Holdout the environment's global holdout, the first Holdout buckets never enter any experiment
+	Holdout int32 `json:"holdout,omitempty" jsonapi:"attr,holdout,omitempty"`
 }
@@ -0,0 +1,13 @@
+package model
+
+// Layer the experiment layer a flag belongs to. The layer's bucket space is split
+// between its flags, so an identity is part of at most one of the layer's experiments.
+// Identities are bucketed into the layer by their identifier.
+type Layer struct {
+	LayerKey string `json:"layerKey"`
+	// Holdout the first Holdout buckets of the layer never enter any of its experiments
+	Holdout int32 `json:"holdout,omitempty"`
+	// Start, End the flag's range [Start, End) of the layer's bucket space
+	Start int32 `json:"start"`
+	End   int32 `json:"end"`
+}
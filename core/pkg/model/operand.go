@@ -6,12 +6,42 @@ type Operator string
 const (
 	// OPEqual exact match
 	OPEqual Operator = "equal"
+	// OPNotEqual not an exact match
+	OPNotEqual Operator = "not_equal"
+	// OPEqualIgnoreCase case-insensitive exact match
+	OPEqualIgnoreCase Operator = "equal_ignore_case"
 	// OPGreaterThan > (numeric comparisons)
 	OPGreaterThan Operator = "greater_than"
 	// OPGreaterThanOrEqual >= (numeric comparisons)
 	OPGreaterThanOrEqual Operator = "greater_than_or_equal"
+	// OPLessThan < (numeric comparisons)
+	OPLessThan Operator = "less_than"
+	// OPLessThanOrEqual <= (numeric comparisons)
+	OPLessThanOrEqual Operator = "less_than_or_equal"
 	// OPContains is a substring
 	OPContains Operator = "contains"
+	// OPStartsWith has a prefix
+	OPStartsWith Operator = "starts_with"
+	// OPEndsWith has a suffix
+	OPEndsWith Operator = "ends_with"
 	// OPRegex regular expression match
 	OPRegex Operator = "regex"
+	// OPSemverEqual semantic versions have the same precedence
+	OPSemverEqual Operator = "semver_equal"
+	// OPSemverGreaterThan > (semantic version precedence)
+	OPSemverGreaterThan Operator = "semver_greater_than"
+	// OPSemverGreaterThanOrEqual >= (semantic version precedence)
+	OPSemverGreaterThanOrEqual Operator = "semver_greater_than_or_equal"
+	// OPSemverLessThan < (semantic version precedence)
+	OPSemverLessThan Operator = "semver_less_than"
+	// OPSemverLessThanOrEqual <= (semantic version precedence)
+	OPSemverLessThanOrEqual Operator = "semver_less_than_or_equal"
+	// OPBefore < (date & time comparisons)
+	OPBefore Operator = "before"
+	// OPAfter > (date & time comparisons)
+	OPAfter Operator = "after"
+	// OPIn is one of the values in a list
+	OPIn Operator = "in"
+	// OPNotIn is none of the values in a list
+	OPNotIn Operator = "not_in"
 )
@@ -0,0 +1,7 @@
+package model
+
+// Prerequisite flag which has to serve one of the variations before the dependent flag is evaluated
+type Prerequisite struct {
+	FlagKey       string   `json:"flagKey" jsonapi:"attr,flagKey"`
+	VariationKeys []string `json:"variationKeys" jsonapi:"attr,variationKeys"`
+}
@@ -16,4 +16,18 @@ const (
 	ReasonTargeted Reason = "TARGETED"
 	// ReasonTargetedWeighted used a weighted targeted variation
 	ReasonTargetedWeighted Reason = "TARGETED_WEIGHTED"
+	// ReasonOff used the off variation since targeting is disabled or the flag is killed
+	ReasonOff Reason = "OFF"
+	// ReasonPrerequisiteFailed used the off variation (or fallthrough variation if there is none)
+	// since a prerequisite flag was not met
+	ReasonPrerequisiteFailed Reason = "PREREQUISITE_FAILED"
+	// ReasonHoldout used the control variation since the identity is part of the layer's holdout
+	ReasonHoldout Reason = "HOLDOUT"
+	// ReasonLayerExcluded used the control variation since the identity is part of another
+	// experiment of the flag's layer
+	ReasonLayerExcluded Reason = "LAYER_EXCLUDED"
+	// ReasonSticky used the variation the identity was first bucketed into by a sticky flag
+	ReasonSticky Reason = "STICKY"
+	// ReasonDefault used the caller's default since the flag couldn't be evaluated (e.g. it doesn't exist)
+	ReasonDefault Reason = "DEFAULT"
 )
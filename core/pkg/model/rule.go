@@ -1,12 +1,30 @@
 package model
 
+// RuleType represents the kind of condition a rule checks against the evaluation context
+type RuleType string
+
+const (
+	// RuleTypeTrait matches a trait on the evaluation context
+	RuleTypeTrait RuleType = "trait"
+	// RuleTypeIdentity matches a specific identity
+	RuleTypeIdentity RuleType = "identity"
+	// RuleTypeSegment matches identities which belong to a segment
+	RuleTypeSegment RuleType = "segment"
+)
+
 // Rule represents generic flagbase rule (used by targeting and segments)
 type Rule struct {
 	ID             string       `json:"id" jsonapi:"primary,rule"`
-	RuleType       string       `json:"ruleType" jsonapi:"attr,ruleType"`
+	RuleType       RuleType     `json:"ruleType" jsonapi:"attr,ruleType"`
 	TraitKey       string       `json:"traitKey" jsonapi:"attr,traitKey"`
 	TraitValue     string       `json:"traitValue" jsonapi:"attr,traitValue"`
+	TraitValues    ValueSet     `json:"traitValues,omitempty" jsonapi:"attr,traitValues,omitempty"`
 	Operator       Operator     `json:"operator" jsonapi:"attr,operator"`
 	Negate         bool         `json:"negate" jsonapi:"attr,negate"`
+	Match          MatchType    `json:"match,omitempty" jsonapi:"attr,match,omitempty"`
+	Clauses        []*Clause    `json:"clauses,omitempty" jsonapi:"attr,clauses,omitempty"`
+	IdentityKey    string       `json:"identityKey,omitempty" jsonapi:"attr,identityKey,omitempty"`
+	SegmentKey     string       `json:"segmentKey,omitempty" jsonapi:"attr,segmentKey,omitempty"`
+	SegmentRules   []*Rule      `json:"segmentRules,omitempty" jsonapi:"attr,segmentRules,omitempty"`
 	RuleVariations []*Variation `json:"ruleVariations" jsonapi:"relation,ruleVariations"`
 }
@@ -0,0 +1,82 @@
+package model
+
+// Trace explains how an evaluation was derived (i.e. which rules were checked,
+// how each clause was resolved and which bucket the identity fell into)
+type Trace struct {
+	// Off targeting was disabled or the flag was killed
+	Off bool `json:"off,omitempty"`
+	// Prerequisites the prerequisite flags checked before evaluating the flag
+	Prerequisites []*PrerequisiteTrace `json:"prerequisites,omitempty"`
+	// Rules the rules checked in order, rules after the matched rule are not checked
+	Rules []*RuleTrace `json:"rules,omitempty"`
+	// MatchedRuleIndex index of the matched rule (-1 if no rule matched)
+	MatchedRuleIndex int `json:"matchedRuleIndex"`
+	// MatchedRuleID ID of the matched rule
+	MatchedRuleID string `json:"matchedRuleId,omitempty"`
+	// Bucket the identity's bucket, compared against the weight ranges
+	Bucket *uint32 `json:"bucket,omitempty"`
+	// Holdout the identity's bucket within the environment's global holdout
+	Holdout *HoldoutTrace `json:"holdout,omitempty"`
+	// Layer the identity's bucket within the flag's layer
+	Layer *LayerTrace `json:"layer,omitempty"`
+	// Sticky the identity's recorded variation was served instead of the bucketed variation
+	Sticky bool `json:"sticky,omitempty"`
+	// Weights the weight ranges of the variations the served variation was derived from
+	Weights []*WeightRange `json:"weights,omitempty"`
+}
+
+// PrerequisiteTrace explains a prerequisite check
+type PrerequisiteTrace struct {
+	FlagKey       string   `json:"flagKey"`
+	VariationKeys []string `json:"variationKeys"`
+	VariationKey  string   `json:"variationKey,omitempty"`
+	Reason        Reason   `json:"reason,omitempty"`
+	Met           bool     `json:"met"`
+}
+
+// RuleTrace explains how a single rule was matched
+type RuleTrace struct {
+	Index        int            `json:"index"`
+	ID           string         `json:"id,omitempty"`
+	RuleType     RuleType       `json:"ruleType"`
+	Matched      bool           `json:"matched"`
+	Clauses      []*ClauseTrace `json:"clauses,omitempty"`
+	SegmentRules []*RuleTrace   `json:"segmentRules,omitempty"`
+}
+
+// ClauseTrace explains how a single trait condition was resolved,
+// the trait value seen is omitted when the context is missing the trait
+type ClauseTrace struct {
+	TraitKey    string      `json:"traitKey"`
+	Operator    Operator    `json:"operator"`
+	TraitValue  string      `json:"traitValue,omitempty"`
+	TraitValues ValueSet    `json:"traitValues,omitempty"`
+	Negate      bool        `json:"negate,omitempty"`
+	Seen        interface{} `json:"seen,omitempty"`
+	Found       bool        `json:"found"`
+	Matched     bool        `json:"matched"`
+}
+
+// LayerTrace explains how the identity was bucketed into the flag's layer
+type LayerTrace struct {
+	LayerKey string `json:"layerKey"`
+	Bucket   uint32 `json:"bucket"`
+	Holdout  int32  `json:"holdout"`
+	Start    int32  `json:"start"`
+	End      int32  `json:"end"`
+	Reason   Reason `json:"reason,omitempty"`
+}
+
+// HoldoutTrace explains how the identity was bucketed into the environment's global holdout
+type HoldoutTrace struct {
+	Bucket  uint32 `json:"bucket"`
+	Holdout int32  `json:"holdout"`
+}
+
+// WeightRange the range of buckets [Start, End) served a variation
+type WeightRange struct {
+	VariationKey string `json:"variationKey"`
+	Weight       int32  `json:"weight"`
+	Start        uint32 `json:"start"`
+	End          uint32 `json:"end"`
+}
@@ -0,0 +1,110 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ValueType type of the values served by a flag's variations
+type ValueType string
+
+func (t ValueType) String() string {
+	return string(t)
+}
+
+const (
+	// ValueTypeBoolean variation values are true / false
+	ValueTypeBoolean ValueType = "boolean"
+	// ValueTypeString variation values are strings
+	ValueTypeString ValueType = "string"
+	// ValueTypeNumber variation values are numbers
+	ValueTypeNumber ValueType = "number"
+	// ValueTypeJSON variation values are arbitrary JSON documents
+	ValueTypeJSON ValueType = "json"
+)
+
+// IsValid checks if the value type is supported
+func (t ValueType) IsValid() bool {
+	switch t {
+	case ValueTypeBoolean, ValueTypeString, ValueTypeNumber, ValueTypeJSON:
+		return true
+	default:
+		return false
+	}
+}
+
+// Validate checks if the value can be served by a flag of this type,
+// unset values are always valid
+func (t ValueType) Validate(v Value) error {
+	if v.IsEmpty() {
+		return nil
+	}
+	if !json.Valid(v) {
+		return errors.New("variation value is not valid JSON")
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(v, &decoded); err != nil {
+		return err
+	}
+
+	var ok bool
+	switch t {
+	case ValueTypeBoolean:
+		_, ok = decoded.(bool)
+	case ValueTypeString:
+		_, ok = decoded.(string)
+	case ValueTypeNumber:
+		_, ok = decoded.(float64)
+	case ValueTypeJSON:
+		ok = true
+	default:
+		return fmt.Errorf("unsupported value type %q", t)
+	}
+	if !ok {
+		return fmt.Errorf("variation value %s is not of type %s", v, t)
+	}
+	return nil
+}
+
+// DefaultValues values assigned to a new flag's control & treatment variations
+func (t ValueType) DefaultValues() (control Value, treatment Value) {
+	switch t {
+	case ValueTypeString:
+		return Value(`"control"`), Value(`"treatment"`)
+	case ValueTypeNumber:
+		return Value(`0`), Value(`1`)
+	case ValueTypeJSON:
+		return Value(`{}`), Value(`{}`)
+	default:
+		return Value(`false`), Value(`true`)
+	}
+}
+
+// Value raw JSON value served by a variation, a JSON null is treated as unset
+type Value json.RawMessage
+
+// IsEmpty checks if the value is unset
+func (v Value) IsEmpty() bool {
+	return len(bytes.TrimSpace(v)) == 0
+}
+
+// MarshalJSON returns the raw JSON value (null when unset)
+func (v Value) MarshalJSON() ([]byte, error) {
+	if v.IsEmpty() {
+		return []byte("null"), nil
+	}
+	return v, nil
+}
+
+// UnmarshalJSON stores a copy of the raw JSON value
+func (v *Value) UnmarshalJSON(data []byte) error {
+	if string(bytes.TrimSpace(data)) == "null" {
+		*v = nil
+		return nil
+	}
+	*v = append((*v)[0:0], data...)
+	return nil
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueTypeValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		valueType ValueType
+		value     Value
+		valid     bool
+	}{
+		{"BooleanTrue", ValueTypeBoolean, Value(`true`), true},
+		{"BooleanString", ValueTypeBoolean, Value(`"true"`), false},
+		{"String", ValueTypeString, Value(`"blue"`), true},
+		{"StringNumber", ValueTypeString, Value(`42`), false},
+		{"Number", ValueTypeNumber, Value(`-12.5`), true},
+		{"NumberString", ValueTypeNumber, Value(`"12"`), false},
+		{"JSONObject", ValueTypeJSON, Value(`{"limit":10,"tiers":["a","b"]}`), true},
+		{"JSONArray", ValueTypeJSON, Value(`[1,2,3]`), true},
+		{"JSONInvalid", ValueTypeJSON, Value(`{"limit":`), false},
+		{"Unset", ValueTypeNumber, nil, true},
+		{"UnsupportedType", ValueType("date"), Value(`"2022-01-01"`), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.valueType.Validate(tt.value)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestValueTypeDefaultValues(t *testing.T) {
+	for _, vt := range []ValueType{ValueTypeBoolean, ValueTypeString, ValueTypeNumber, ValueTypeJSON} {
+		control, treatment := vt.DefaultValues()
+		assert.NoError(t, vt.Validate(control), vt)
+		assert.NoError(t, vt.Validate(treatment), vt)
+	}
+}
+
+func TestValueJSON(t *testing.T) {
+	var v Variation
+	assert.NoError(t, json.Unmarshal([]byte(`{"variationKey":"A","weight":100,"value":{"color":"blue"}}`), &v))
+	assert.Equal(t, Value(`{"color":"blue"}`), v.Value)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"variationKey":"A","weight":100,"value":null}`), &v))
+	assert.True(t, v.Value.IsEmpty())
+
+	b, err := json.Marshal(v)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"variationKey":"A","weight":100}`, string(b))
+}
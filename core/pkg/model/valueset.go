@@ -0,0 +1,49 @@
+package model
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// ValueSet set of rule values, used for constant-time membership checks
+type ValueSet map[string]struct{}
+
+// NewValueSet creates a value set from a list of values
+func NewValueSet(values ...string) ValueSet {
+	s := make(ValueSet, len(values))
+	for _, v := range values {
+		s[v] = struct{}{}
+	}
+	return s
+}
+
+// Contains checks if the value is part of the set
+func (s ValueSet) Contains(v string) bool {
+	_, ok := s[v]
+	return ok
+}
+
+// Values returns the values of the set in sorted order
+func (s ValueSet) Values() []string {
+	o := make([]string, 0, len(s))
+	for v := range s {
+		o = append(o, v)
+	}
+	sort.Strings(o)
+	return o
+}
+
+// MarshalJSON encodes the set as a sorted list of values
+func (s ValueSet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Values())
+}
+
+// UnmarshalJSON decodes a list of values into the set
+func (s *ValueSet) UnmarshalJSON(data []byte) error {
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	*s = NewValueSet(values...)
+	return nil
+}
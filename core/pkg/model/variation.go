@@ -1,8 +1,10 @@
 package model
 
 // Variation represents a single flag state, with an appropriate weight
+// (in thousandths of a percent, i.e. 100000 = 100%)
 type Variation struct {
 	ID           string `json:"id,omitempty" jsonapi:"primary,variation"`
 	VariationKey string `json:"variationKey" jsonapi:"attr,variationKey"`
-	Weight       int8   `json:"weight" jsonapi:"attr,weight"`
+	Weight       int32  `json:"weight" jsonapi:"attr,weight"`
+	Value        Value  `json:"value,omitempty" jsonapi:"attr,value,omitempty"`
 }
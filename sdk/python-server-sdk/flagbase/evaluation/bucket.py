@@ -1,7 +1,9 @@
 import hashlib
 from typing import List, Dict
 
-BUCKET_SIZE = 100
+# weights are expressed in thousandths of a percent, i.e. they add up to 100000
+# (see the X-Flagbase-Bucket-Size header of raw flagset responses)
+BUCKET_SIZE = 100000
 
 def precalculate_accumulated_weights(variations: List[Dict[str, int]]) -> List[int]:
     accumulated_weights = [0] * len(variations)